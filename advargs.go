@@ -15,7 +15,13 @@
 
 package zapscript
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
 
 // IsActionDetails returns true if the action is "details" (case-insensitive).
 func IsActionDetails(action string) bool {
@@ -41,3 +47,204 @@ func IsRepeatAll(repeat string) bool {
 func IsRepeatOne(repeat string) bool {
 	return strings.EqualFold(repeat, RepeatOne)
 }
+
+// IsHidden reads Hidden as AdvArgs.GetBool does.
+func (a MisterScriptArgs) IsHidden() (bool, error) {
+	return parseAdvArgBool(KeyHidden, a.Hidden)
+}
+
+// parseAdvArgBool reads the value of the adv arg key as a bool, see
+// AdvArgs.GetBool.
+func parseAdvArgBool(key Key, value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "false", "0", "no":
+		return false, nil
+	case "true", "1", "yes":
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: adv arg %q is %q, want true, false, 1, 0, yes or no",
+			ErrInvalidAdvArgBool, key, value)
+	}
+}
+
+var tagFiltersType = reflect.TypeFor[[]TagFilter]()
+
+// DecodeAdvArgs fills the struct out points to, such as a *LaunchArgs, from
+// the adv args of cmd using the fields' advarg tags. Fields of embedded
+// structs such as GlobalArgs are filled too. String fields get the value as
+// is and []TagFilter fields are parsed with ParseTagFilters; fields whose key
+// is not set are left unchanged. Expressions are not evaluated, so callers
+// usually decode the result of Script.EvalExpressions.
+//
+// Adv args with no matching field are an *UnknownKeysError, returned after
+// the other fields are filled, unless WithIgnoreUnknownKeys is set. A value
+// that is not a non-nil pointer to a struct, or a tagged field of any other
+// type, is an ErrInvalidDecodeTarget error.
+func DecodeAdvArgs(cmd Command, out any, opts ...DecodeOption) error {
+	var o DecodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T, want a pointer to a struct", ErrInvalidDecodeTarget, out)
+	}
+	v = v.Elem()
+
+	fields := make(map[string]reflect.StructField)
+	for _, field := range advArgFields(v.Type()) {
+		fields[field.Tag.Get("advarg")] = field
+	}
+
+	var unknown []Key
+	for _, key := range cmd.AdvArgs.OrderedKeys() {
+		field, ok := fields[string(key)]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		value := cmd.AdvArgs.Get(key)
+		dst := v.FieldByIndex(field.Index)
+		switch {
+		case field.Type.Kind() == reflect.String:
+			dst.SetString(value)
+		case field.Type == tagFiltersType:
+			filters, err := ParseTagFilters(value)
+			if err != nil {
+				return fmt.Errorf("adv arg %q: %w", key, err)
+			}
+			dst.Set(reflect.ValueOf(filters))
+		default:
+			return fmt.Errorf("%w: field %s has unsupported type %s", ErrInvalidDecodeTarget, field.Name, field.Type)
+		}
+	}
+
+	if len(unknown) > 0 && !o.IgnoreUnknownKeys {
+		return &UnknownKeysError{CmdName: cmd.Name, Keys: unknown}
+	}
+	return nil
+}
+
+// EncodeAdvArgs returns the adv args of in, a struct such as LaunchArgs or a
+// pointer to one, using the fields' advarg tags. It is the inverse of
+// DecodeAdvArgs: fields of embedded structs are included, zero fields are
+// skipped and []TagFilter fields are written with FormatTagFilters. Keys are
+// in field order. A value that is not a struct or non-nil pointer to one, or
+// a tagged field of an unsupported type, is an ErrInvalidEncodeSource error.
+func EncodeAdvArgs(in any) (AdvArgs, error) {
+	v := reflect.ValueOf(in)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return AdvArgs{}, fmt.Errorf("%w: %T, want a struct", ErrInvalidEncodeSource, in)
+	}
+
+	raw := make(map[string]string)
+	var keys []string
+	for _, field := range advArgFields(v.Type()) {
+		src := v.FieldByIndex(field.Index)
+		var value string
+		switch {
+		case field.Type.Kind() == reflect.String:
+			value = src.String()
+		case field.Type == tagFiltersType:
+			value = FormatTagFilters(src.Interface().([]TagFilter))
+		default:
+			return AdvArgs{}, fmt.Errorf("%w: field %s has unsupported type %s",
+				ErrInvalidEncodeSource, field.Name, field.Type)
+		}
+		if value == "" {
+			continue
+		}
+		key := field.Tag.Get("advarg")
+		raw[key] = value
+		keys = append(keys, key)
+	}
+	return AdvArgs{raw: raw, keys: keys}, nil
+}
+
+// ValidateArgs checks the fields of in, a struct such as a LaunchArgs filled
+// by DecodeAdvArgs or a pointer to one, against their validate tags. Rules
+// are separated by commas:
+//
+//   - omitempty skips the remaining rules for an empty value
+//   - oneof=a b c requires one of the space-separated values, ignoring case
+//     as IsActionRun and the other helpers do
+//   - any other rule, such as launcher or system, calls the ArgValidator
+//     registered with WithArgValidator and passes if there is none, so hosts
+//     opt in to checks against their registries
+//
+// Each failure is an *AdvArgError naming the adv arg key; several are
+// joined with errors.Join in field order. Only string fields can have
+// validate tags. A value that is not a struct or non-nil pointer to one, or
+// a validate tag on another field type, is an ErrInvalidDecodeTarget error.
+func ValidateArgs(in any, opts ...ValidateOption) error {
+	var o ValidateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	v := reflect.ValueOf(in)
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T, want a struct", ErrInvalidDecodeTarget, in)
+	}
+
+	var errs []error
+	for _, field := range advArgFields(v.Type()) {
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		if field.Type.Kind() != reflect.String {
+			return fmt.Errorf("%w: field %s has validate tag on type %s",
+				ErrInvalidDecodeTarget, field.Name, field.Type)
+		}
+		value := v.FieldByIndex(field.Index).String()
+		if rule, ok := failedRule(value, rules, o.ArgValidators); !ok {
+			errs = append(errs, &AdvArgError{Key: Key(field.Tag.Get("advarg")), Value: value, Rule: rule})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// failedRule returns the first rule in the comma-separated rules that value
+// fails, see ValidateArgs.
+func failedRule(value, rules string, validators map[string]ArgValidator) (string, bool) {
+	for rule := range strings.SplitSeq(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "omitempty":
+			if value == "" {
+				return "", true
+			}
+		case "oneof":
+			if !slices.ContainsFunc(strings.Fields(param), func(option string) bool {
+				return strings.EqualFold(option, value)
+			}) {
+				return rule, false
+			}
+		default:
+			if fn, ok := validators[name]; ok && !fn(value) {
+				return rule, false
+			}
+		}
+	}
+	return "", true
+}
+
+// advArgFields returns the exported fields of t, including those promoted
+// from embedded structs, that have an advarg tag.
+func advArgFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for _, field := range reflect.VisibleFields(t) {
+		if tag := field.Tag.Get("advarg"); tag != "" && tag != "-" && field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
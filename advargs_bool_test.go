@@ -0,0 +1,149 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestAdvArgsGetBool(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "true", value: "true", want: true},
+		{name: "TRUE", value: "TRUE", want: true},
+		{name: "True", value: "True", want: true},
+		{name: "1", value: "1", want: true},
+		{name: "yes", value: "yes", want: true},
+		{name: "Yes", value: "Yes", want: true},
+		{name: "false", value: "false"},
+		{name: "FALSE", value: "FALSE"},
+		{name: "0", value: "0"},
+		{name: "no", value: "no"},
+		{name: "NO", value: "NO"},
+		{name: "empty", value: ""},
+		{name: "invalid", value: "maybe", wantErr: true},
+		{name: "number", value: "2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			args := zapscript.NewAdvArgs(map[string]string{"hidden": tt.value})
+			got, err := args.GetBool(zapscript.KeyHidden)
+			if tt.wantErr {
+				if !errors.Is(err, zapscript.ErrInvalidAdvArgBool) {
+					t.Fatalf("GetBool() error = %v, want ErrInvalidAdvArgBool", err)
+				}
+				if !strings.Contains(err.Error(), `"hidden"`) || !strings.Contains(err.Error(), `"`+tt.value+`"`) {
+					t.Errorf("GetBool() error = %v, want it to name the key and value", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetBool() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetBool() = %v, want %v", got, tt.want)
+			}
+
+			hidden, err := zapscript.MisterScriptArgs{Hidden: tt.value}.IsHidden()
+			if err != nil || hidden != tt.want {
+				t.Errorf("IsHidden() = %v, %v, want %v", hidden, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdvArgsGetBoolMissing(t *testing.T) {
+	t.Parallel()
+
+	got, err := zapscript.AdvArgs{}.GetBool(zapscript.KeyHidden)
+	if err != nil || got {
+		t.Errorf("GetBool() = %v, %v, want false, nil", got, err)
+	}
+}
+
+func TestAdvArgsGetBoolExpression(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser(`**mister.script:a.sh?hidden=[[media_playing]]`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	value := script.Cmds[0].AdvArgs.Get(zapscript.KeyHidden)
+
+	for _, playing := range []bool{true, false} {
+		env := zapscript.ArgExprEnv{MediaPlaying: playing}
+		evaluated, err := zapscript.NewParser(value).EvalExpressions(env)
+		if err != nil {
+			t.Fatalf("EvalExpressions() unexpected error: %v", err)
+		}
+		args := zapscript.NewAdvArgs(map[string]string{"hidden": evaluated})
+		got, err := args.GetBool(zapscript.KeyHidden)
+		if err != nil {
+			t.Fatalf("GetBool() unexpected error: %v", err)
+		}
+		if got != playing {
+			t.Errorf("GetBool() with media_playing=%v = %v", playing, got)
+		}
+	}
+}
+
+func TestAdvArgsIsFlagSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "present without value", input: "**launch.random:snes?verbose", want: true},
+		{name: "present and empty", input: "**launch.random:snes?verbose=", want: true},
+		{name: "present among others", input: "**launch.random:snes?verbose&system=snes", want: true},
+		{name: "true", input: "**launch.random:snes?verbose=true", want: true},
+		{name: "yes", input: "**launch.random:snes?verbose=YES", want: true},
+		{name: "false", input: "**launch.random:snes?verbose=false"},
+		{name: "0", input: "**launch.random:snes?verbose=0"},
+		{name: "invalid", input: "**launch.random:snes?verbose=maybe"},
+		{name: "absent", input: "**launch.random:snes?system=snes"},
+		{name: "no adv args", input: "**launch.random:snes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if got := script.Cmds[0].AdvArgs.IsFlagSet("verbose"); got != tt.want {
+				t.Errorf("IsFlagSet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+var testTagFilters = []zapscript.TagFilter{
+	{Type: "region", Value: "usa", Operator: zapscript.TagOperatorAND},
+	{Type: "unfinished", Value: "demo", Operator: zapscript.TagOperatorNOT},
+	{Type: "lang", Value: "en", Operator: zapscript.TagOperatorOR},
+}
+
+// populateAdvArgs sets every advarg field of the struct v points to and
+// returns the adv args that encode it, so new fields are covered without
+// updating the test.
+func populateAdvArgs(t *testing.T, v any) map[string]string {
+	t.Helper()
+	raw := make(map[string]string)
+	rv := reflect.ValueOf(v).Elem()
+	for _, field := range reflect.VisibleFields(rv.Type()) {
+		tag := field.Tag.Get("advarg")
+		if tag == "" {
+			continue
+		}
+		dst := rv.FieldByIndex(field.Index)
+		switch field.Type {
+		case reflect.TypeFor[string]():
+			dst.SetString("value of " + tag)
+			raw[tag] = "value of " + tag
+		case reflect.TypeFor[[]zapscript.TagFilter]():
+			dst.Set(reflect.ValueOf(testTagFilters))
+			raw[tag] = "+region:usa,-unfinished:demo,~lang:en"
+		default:
+			t.Fatalf("%s.%s has type %s, add it to populateAdvArgs", rv.Type().Name(), field.Name, field.Type)
+		}
+	}
+	return raw
+}
+
+// advArgsStructs lists every adv arg struct in types.go.
+var advArgsStructs = []struct {
+	newArgs func() any
+	name    string
+}{
+	{name: "GlobalArgs", newArgs: func() any { return &zapscript.GlobalArgs{} }},
+	{name: "LaunchArgs", newArgs: func() any { return &zapscript.LaunchArgs{} }},
+	{name: "LaunchRandomArgs", newArgs: func() any { return &zapscript.LaunchRandomArgs{} }},
+	{name: "LaunchSearchArgs", newArgs: func() any { return &zapscript.LaunchSearchArgs{} }},
+	{name: "LaunchTitleArgs", newArgs: func() any { return &zapscript.LaunchTitleArgs{} }},
+	{name: "LaunchLastArgs", newArgs: func() any { return &zapscript.LaunchLastArgs{} }},
+	{name: "PlaylistArgs", newArgs: func() any { return &zapscript.PlaylistArgs{} }},
+	{name: "MisterScriptArgs", newArgs: func() any { return &zapscript.MisterScriptArgs{} }},
+}
+
+func TestDecodeAdvArgsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range advArgsStructs {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := tt.newArgs()
+			raw := populateAdvArgs(t, want)
+
+			// write the command out and parse it back so quoting and
+			// escaping of the values is part of the round trip
+			written := zapscript.Command{Name: "cmd", AdvArgs: zapscript.NewAdvArgs(raw)}.String()
+			script, err := zapscript.Parse(written)
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", written, err)
+			}
+
+			got := tt.newArgs()
+			if err := zapscript.DecodeAdvArgs(script.Cmds[0], got); err != nil {
+				t.Fatalf("DecodeAdvArgs(%q) unexpected error: %v", written, err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("DecodeAdvArgs(%q) mismatch (-want +got):\n%s", written, diff)
+			}
+		})
+	}
+}
+
+func TestDecodeAdvArgs(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**launch.random:snes?when=[[media_playing]]&Launcher=retroarch&tags=region:usa")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	got := zapscript.LaunchRandomArgs{Slot: "kept"}
+	if err := zapscript.DecodeAdvArgs(script.Cmds[0], &got); err != nil {
+		t.Fatalf("DecodeAdvArgs() unexpected error: %v", err)
+	}
+	want := zapscript.LaunchRandomArgs{
+		GlobalArgs: zapscript.GlobalArgs{When: zapscript.TokExpStart + "media_playing" + zapscript.TokExprEnd},
+		Launcher:   "retroarch",
+		Slot:       "kept",
+		Tags:       []zapscript.TagFilter{{Type: "region", Value: "usa", Operator: zapscript.TagOperatorAND}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DecodeAdvArgs() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDecodeAdvArgsUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**playlist.play:a?zeta=1&mode=shuffle&alpha=2")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	cmd := script.Cmds[0]
+
+	var args zapscript.PlaylistArgs
+	err = zapscript.DecodeAdvArgs(cmd, &args)
+	var unknownErr *zapscript.UnknownKeysError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("DecodeAdvArgs() error = %v, want *UnknownKeysError", err)
+	}
+	if !errors.Is(err, zapscript.ErrUnknownAdvArg) {
+		t.Errorf("errors.Is(%v, ErrUnknownAdvArg) = false", err)
+	}
+	if diff := cmp.Diff([]zapscript.Key{"zeta", "alpha"}, unknownErr.Keys); diff != "" {
+		t.Errorf("Keys mismatch (-want +got):\n%s", diff)
+	}
+	if want := `command playlist.play: unknown adv arg "zeta", "alpha"`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+	if args.Mode != zapscript.ModeShuffle {
+		t.Errorf("Mode = %q, want known keys decoded despite the error", args.Mode)
+	}
+
+	args = zapscript.PlaylistArgs{}
+	if err := zapscript.DecodeAdvArgs(cmd, &args, zapscript.WithIgnoreUnknownKeys()); err != nil {
+		t.Fatalf("DecodeAdvArgs(WithIgnoreUnknownKeys) unexpected error: %v", err)
+	}
+	if args.Mode != zapscript.ModeShuffle {
+		t.Errorf("Mode = %q, want %q", args.Mode, zapscript.ModeShuffle)
+	}
+}
+
+func TestDecodeAdvArgsErrors(t *testing.T) {
+	t.Parallel()
+
+	cmd := zapscript.Command{Name: "cmd", AdvArgs: zapscript.NewAdvArgs(map[string]string{"count": "3"})}
+	var nilArgs *zapscript.LaunchArgs
+
+	tests := []struct {
+		out     any
+		wantErr error
+		cmd     zapscript.Command
+		name    string
+	}{
+		{name: "not a pointer", cmd: cmd, out: zapscript.LaunchArgs{}, wantErr: zapscript.ErrInvalidDecodeTarget},
+		{name: "nil pointer", cmd: cmd, out: nilArgs, wantErr: zapscript.ErrInvalidDecodeTarget},
+		{name: "pointer to non-struct", cmd: cmd, out: new(string), wantErr: zapscript.ErrInvalidDecodeTarget},
+		{
+			name: "unsupported field type",
+			cmd:  cmd,
+			out: &struct {
+				Count int `advarg:"count"`
+			}{},
+			wantErr: zapscript.ErrInvalidDecodeTarget,
+		},
+		{
+			name: "invalid tag filter",
+			cmd: zapscript.Command{
+				Name:    "launch.random",
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{"tags": "nocolon"}),
+			},
+			out: &zapscript.LaunchRandomArgs{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := zapscript.DecodeAdvArgs(tt.cmd, tt.out)
+			if err == nil {
+				t.Fatal("DecodeAdvArgs() expected an error, got nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeAdvArgs() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !strings.Contains(err.Error(), `"tags"`) {
+				t.Errorf("DecodeAdvArgs() error = %v, want it to name the key", err)
+			}
+		})
+	}
+}
+
+func TestEncodeAdvArgsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range advArgsStructs {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := tt.newArgs()
+			populateAdvArgs(t, want)
+
+			advArgs, err := zapscript.EncodeAdvArgs(want)
+			if err != nil {
+				t.Fatalf("EncodeAdvArgs() unexpected error: %v", err)
+			}
+			got := tt.newArgs()
+			if err := zapscript.DecodeAdvArgs(zapscript.Command{Name: "cmd", AdvArgs: advArgs}, got); err != nil {
+				t.Fatalf("DecodeAdvArgs() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("DecodeAdvArgs(EncodeAdvArgs()) mismatch (-want +got):\n%s", diff)
+			}
+
+			// zero structs encode to no adv args
+			empty, err := zapscript.EncodeAdvArgs(tt.newArgs())
+			if err != nil || !empty.IsEmpty() {
+				t.Errorf("EncodeAdvArgs(zero) = %v, %v, want no adv args", empty.Raw(), err)
+			}
+		})
+	}
+}
+
+func TestEncodeAdvArgs(t *testing.T) {
+	t.Parallel()
+
+	advArgs, err := zapscript.EncodeAdvArgs(zapscript.LaunchRandomArgs{
+		GlobalArgs: zapscript.GlobalArgs{When: zapscript.TokExpStart + "media_playing" + zapscript.TokExprEnd},
+		Launcher:   "retroarch",
+		Action:     zapscript.ActionDetails,
+		Tags:       testTagFilters,
+	})
+	if err != nil {
+		t.Fatalf("EncodeAdvArgs() unexpected error: %v", err)
+	}
+
+	cmd := zapscript.Command{Name: "launch.random", Args: []string{"snes"}, AdvArgs: advArgs}
+	want := "**launch.random:snes?when=[[media_playing]]&launcher=retroarch&action=details" +
+		`&tags="region:usa,-unfinished:demo,~lang:en"`
+	if got := cmd.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestEncodeAdvArgsErrors(t *testing.T) {
+	t.Parallel()
+
+	var nilArgs *zapscript.LaunchArgs
+	tests := []struct {
+		in   any
+		name string
+	}{
+		{name: "not a struct", in: "launcher=retroarch"},
+		{name: "nil pointer", in: nilArgs},
+		{name: "unsupported field type", in: struct {
+			Count int `advarg:"count"`
+		}{Count: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := zapscript.EncodeAdvArgs(tt.in); !errors.Is(err, zapscript.ErrInvalidEncodeSource) {
+				t.Errorf("EncodeAdvArgs() error = %v, want ErrInvalidEncodeSource", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAdvArgsIsJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		key   zapscript.Key
+		want  bool
+	}{
+		{name: "JSON object", input: `**cmd?data={"x":1}`, key: "data", want: true},
+		{name: "JSON followed by other arg", input: `**cmd?data={"x":1}&b=2`, key: "data", want: true},
+		{name: "plain string", input: `**cmd?data=abc`, key: "data", want: false},
+		{name: "other key", input: `**cmd?data={"x":1}&b=2`, key: "b", want: false},
+		{name: "missing key", input: `**cmd?data={"x":1}`, key: "missing", want: false},
+		{name: "brace not at value start", input: `**cmd?key=a{b`, key: "key", want: false},
+		{name: "quoted JSON", input: `**cmd?data='{"x":1}'`, key: "data", want: false},
+		{name: "text after JSON", input: `**cmd?data={"x":1}y`, key: "data", want: false},
+		{name: "later duplicate plain value", input: `**cmd?data={"x":1}&data=abc`, key: "data", want: false},
+		{name: "positional args", input: `**cmd:a?data={"x":1}`, key: "data", want: true},
+		{name: "media title", input: `@snes/Game?data={"x":1}`, key: "data", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if got := script.Cmds[0].AdvArgs.IsJSON(tt.key); got != tt.want {
+				t.Errorf("IsJSON(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdvArgsIsJSONWith(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser(`**cmd?a={"x":1}&b={"y":2}`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	advArgs := script.Cmds[0].AdvArgs.With("a", "plain")
+
+	if advArgs.IsJSON("a") {
+		t.Error("IsJSON(a) = true after With, want false")
+	}
+	if !advArgs.IsJSON("b") {
+		t.Error("IsJSON(b) = false after With, want true")
+	}
+	if !script.Cmds[0].AdvArgs.IsJSON("a") {
+		t.Error("With mutated the receiver's JSON metadata")
+	}
+}
+
+func TestAdvArgsIsJSONMerge(t *testing.T) {
+	t.Parallel()
+
+	base, err := zapscript.NewParser(`**cmd?a={"x":1}&b={"y":2}`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	overrides, err := zapscript.NewParser(`**cmd?a=plain&c={"z":3}`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	advArgs := base.Cmds[0].AdvArgs.Merge(overrides.Cmds[0].AdvArgs)
+
+	if advArgs.IsJSON("a") {
+		t.Error("IsJSON(a) = true after an override, want false")
+	}
+	if !advArgs.IsJSON("b") || !advArgs.IsJSON("c") {
+		t.Error("IsJSON(b) and IsJSON(c) = false after Merge, want true")
+	}
+}
+
+func TestAdvArgsGetJSON(t *testing.T) {
+	t.Parallel()
+
+	type data struct {
+		Name  string `json:"name"`
+		Items []int  `json:"items"`
+	}
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		key     zapscript.Key
+		want    data
+	}{
+		{
+			name:  "JSON object",
+			input: `**cmd?data={"name":"a","items":[1,2]}`,
+			key:   "data",
+			want:  data{Name: "a", Items: []int{1, 2}},
+		},
+		{
+			name:  "quoted JSON string",
+			input: `**cmd?data='{"name":"b"}'`,
+			key:   "data",
+			want:  data{Name: "b"},
+		},
+		{
+			name:    "plain string",
+			input:   `**cmd?data=abc`,
+			key:     "data",
+			wantErr: zapscript.ErrInvalidJSON,
+		},
+		{
+			name:    "brace not at value start",
+			input:   `**cmd?data=a{b`,
+			key:     "data",
+			wantErr: zapscript.ErrInvalidJSON,
+		},
+		{
+			name:    "missing key",
+			input:   `**cmd?data={"name":"a"}`,
+			key:     "missing",
+			wantErr: zapscript.ErrInvalidJSON,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+
+			var got data
+			err = script.Cmds[0].AdvArgs.GetJSON(tt.key, &got)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetJSON() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetJSON() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetJSON() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestAdvArgsLookup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		key       zapscript.Key
+		wantValue string
+		wantLen   int
+		wantSet   bool
+	}{
+		{name: "flag without value", input: "**launch:a?flag", key: "flag", wantSet: true, wantLen: 1},
+		{name: "explicitly empty", input: "**launch:a?name=&system=snes", key: zapscript.KeyName,
+			wantSet: true, wantLen: 2},
+		{name: "with value", input: "**launch:a?system=snes", key: zapscript.KeySystem,
+			wantValue: "snes", wantSet: true, wantLen: 1},
+		{name: "never set", input: "**launch:a?system=snes", key: zapscript.KeyName, wantLen: 1},
+		{name: "no adv args", input: "**launch:a", key: zapscript.KeyName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			args := script.Cmds[0].AdvArgs
+
+			value, ok := args.Lookup(tt.key)
+			if value != tt.wantValue || ok != tt.wantSet {
+				t.Errorf("Lookup(%q) = %q, %v, want %q, %v", tt.key, value, ok, tt.wantValue, tt.wantSet)
+			}
+			if got := args.Has(tt.key); got != tt.wantSet {
+				t.Errorf("Has(%q) = %v, want %v", tt.key, got, tt.wantSet)
+			}
+			if got := args.Len(); got != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", got, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestAdvArgsGetWhenLookup(t *testing.T) {
+	t.Parallel()
+
+	var unset zapscript.AdvArgs
+	if v, ok := unset.GetWhen(); v != "" || ok {
+		t.Errorf("GetWhen() on zero AdvArgs = %q, %v, want \"\", false", v, ok)
+	}
+
+	empty := zapscript.NewAdvArgs(map[string]string{"when": ""})
+	if v, ok := empty.GetWhen(); v != "" || !ok {
+		t.Errorf("GetWhen() with empty when = %q, %v, want \"\", true", v, ok)
+	}
+}
+
+func TestAdvArgsRawReturnsCopy(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**launch:a?system=snes&launcher=x")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	advArgs := script.Cmds[0].AdvArgs
+
+	raw := advArgs.Raw()
+	raw["system"] = "nes"
+	delete(raw, "launcher")
+	raw["name"] = "added"
+
+	if got := advArgs.Get(zapscript.KeySystem); got != "snes" {
+		t.Errorf("Get(system) = %q, want %q", got, "snes")
+	}
+	if !advArgs.Has(zapscript.KeyLauncher) || advArgs.Has(zapscript.KeyName) || advArgs.Len() != 2 {
+		t.Errorf("Raw() = %v after modifying a copy, want the original keys", advArgs.Raw())
+	}
+	if got, want := script.String(), "**launch:a?system=snes&launcher=x"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	if zapscript.NewAdvArgs(nil).Raw() != nil {
+		t.Error("NewAdvArgs(nil).Raw() != nil, want nil")
+	}
+}
+
+func BenchmarkAdvArgsRaw(b *testing.B) {
+	advArgs := zapscript.NewAdvArgs(map[string]string{
+		"system":   "snes",
+		"launcher": "retroarch",
+		"action":   "run",
+		"when":     "true",
+	})
+	b.ReportAllocs()
+	for b.Loop() {
+		if len(advArgs.Raw()) != 4 {
+			b.Fatal("Raw() lost keys")
+		}
+	}
+}
+
+// keyConstants returns the names and values of the Key constants declared in
+// types.go.
+func keyConstants(t *testing.T) map[string]string {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "types.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parsing types.go: %v", err)
+	}
+	consts := make(map[string]string)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs, _ := spec.(*ast.ValueSpec)
+			if typ, isIdent := vs.Type.(*ast.Ident); !isIdent || typ.Name != "Key" {
+				continue
+			}
+			for i, name := range vs.Names {
+				lit, _ := vs.Values[i].(*ast.BasicLit)
+				consts[name.Name] = strings.Trim(lit.Value, `"`)
+			}
+		}
+	}
+	if len(consts) == 0 {
+		t.Fatal("found no Key constants in types.go")
+	}
+	return consts
+}
+
+func TestAdvArgsKeyGetters(t *testing.T) {
+	t.Parallel()
+
+	lookup := reflect.TypeFor[func(zapscript.AdvArgs) (string, bool)]()
+	for name, key := range keyConstants(t) {
+		method := "Get" + strings.TrimPrefix(name, "Key")
+		m, ok := reflect.TypeFor[zapscript.AdvArgs]().MethodByName(method)
+		if !ok {
+			t.Errorf("%s has no AdvArgs.%s getter", name, method)
+			continue
+		}
+		if m.Type != lookup {
+			// typed getters such as GetTags parse the value instead
+			continue
+		}
+
+		for _, advArgs := range []zapscript.AdvArgs{
+			zapscript.NewAdvArgs(map[string]string{key: "value"}),
+			{},
+		} {
+			out := m.Func.Call([]reflect.Value{reflect.ValueOf(advArgs)})
+			wantValue, wantSet := advArgs.Lookup(zapscript.Key(key))
+			if out[0].String() != wantValue || out[1].Bool() != wantSet {
+				t.Errorf("%s() = %q, %t, want %q, %t", method, out[0].String(), out[1].Bool(), wantValue, wantSet)
+			}
+		}
+	}
+}
+
+// hotAdvArgs is a parsed AdvArgs of typical size for lookup benchmarks.
+func hotAdvArgs(tb testing.TB) zapscript.AdvArgs {
+	tb.Helper()
+
+	script, err := zapscript.Parse("**launch:game?system=snes&launcher=retroarch&action=run&when=[[media_playing]]")
+	if err != nil {
+		tb.Fatalf("Parse() unexpected error: %v", err)
+	}
+	return script.Cmds[0].AdvArgs
+}
+
+// Lookups run for every command of every scan, so they must not allocate.
+// AllocsPerRun cannot be used in a parallel test.
+func TestAdvArgsLookupsDoNotAllocate(t *testing.T) {
+	advArgs := hotAdvArgs(t)
+
+	for name, lookup := range map[string]func(){
+		"Get":       func() { _ = advArgs.Get(zapscript.KeyWhen) },
+		"GetWhen":   func() { _, _ = advArgs.GetWhen() },
+		"Lookup":    func() { _, _ = advArgs.Lookup(zapscript.KeyLauncher) },
+		"Has":       func() { _ = advArgs.Has(zapscript.KeyName) },
+		"GetSystem": func() { _, _ = advArgs.GetSystem() },
+	} {
+		if allocs := testing.AllocsPerRun(100, lookup); allocs != 0 {
+			t.Errorf("%s allocates %v times per call, want 0", name, allocs)
+		}
+	}
+}
+
+func BenchmarkAdvArgsGet(b *testing.B) {
+	advArgs := hotAdvArgs(b)
+
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if advArgs.Get(zapscript.KeyWhen) == "" {
+				b.Fatal("Get() lost when")
+			}
+		}
+	})
+
+	b.Run("GetWhen", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, ok := advArgs.GetWhen(); !ok {
+				b.Fatal("GetWhen() lost when")
+			}
+		}
+	})
+
+	b.Run("Missing", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if advArgs.Get(zapscript.KeyName) != "" {
+				b.Fatal("Get() found name")
+			}
+		}
+	})
+}
@@ -0,0 +1,364 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestAdvArgsOrderRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		wantKeys []zapscript.Key
+		want     string
+	}{
+		{
+			name:     "reverse alphabetical",
+			input:    "**cmd?b=2&a=1",
+			wantKeys: []zapscript.Key{"b", "a"},
+			want:     "**cmd?b=2&a=1",
+		},
+		{
+			name:     "with args",
+			input:    "**launch:game?system=snes&launcher=retroarch&action=run",
+			wantKeys: []zapscript.Key{"system", "launcher", "action"},
+			want:     "**launch:game?system=snes&launcher=retroarch&action=run",
+		},
+		{
+			name:     "duplicate keeps first position",
+			input:    "**cmd?b=2&a=1&b=3",
+			wantKeys: []zapscript.Key{"b", "a"},
+			want:     "**cmd?b=2&b=3&a=1",
+		},
+		{
+			name:     "auto launch",
+			input:    "game.rom?z=1&y=2",
+			wantKeys: []zapscript.Key{"z", "y"},
+			want:     "**launch:game.rom?z=1&y=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			advArgs := script.Cmds[0].AdvArgs
+			if diff := cmp.Diff(tt.wantKeys, advArgs.OrderedKeys()); diff != "" {
+				t.Errorf("OrderedKeys() mismatch (-want +got):\n%s", diff)
+			}
+			if got := script.Cmds[0].String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+
+			var ranged []zapscript.Key
+			advArgs.Range(func(key zapscript.Key, _ string) bool {
+				ranged = append(ranged, key)
+				return true
+			})
+			if diff := cmp.Diff(tt.wantKeys, ranged); diff != "" {
+				t.Errorf("Range() order mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdvArgsWithAppendsNewKeys(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**cmd?b=2&a=1")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	original := script.Cmds[0].AdvArgs
+	advArgs := original.With("c", "3").With("b", "4")
+
+	want := []zapscript.Key{"b", "a", "c"}
+	if diff := cmp.Diff(want, advArgs.OrderedKeys()); diff != "" {
+		t.Errorf("OrderedKeys() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]zapscript.Key{"b", "a"}, original.OrderedKeys()); diff != "" {
+		t.Errorf("With() mutated the receiver (-want +got):\n%s", diff)
+	}
+
+	cmd := zapscript.Command{Name: "cmd", AdvArgs: advArgs}
+	if got, want := cmd.String(), "**cmd?b=4&a=1&c=3"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// keys of NewAdvArgs have no order and are sorted, new keys follow them
+	unordered := zapscript.NewAdvArgs(map[string]string{"y": "1", "x": "2"}).With("a", "3")
+	if diff := cmp.Diff([]zapscript.Key{"x", "y", "a"}, unordered.OrderedKeys()); diff != "" {
+		t.Errorf("OrderedKeys() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdvArgsWithoutKeepsOrder(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**cmd?c=3&when=true&b=2&a=1")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	original := script.Cmds[0].AdvArgs
+	advArgs := original.Without(zapscript.KeyWhen).Without("b")
+
+	want := []zapscript.Key{"c", "a"}
+	if diff := cmp.Diff(want, advArgs.OrderedKeys()); diff != "" {
+		t.Errorf("OrderedKeys() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]zapscript.Key{"c", "when", "b", "a"}, original.OrderedKeys()); diff != "" {
+		t.Errorf("Without() mutated the receiver (-want +got):\n%s", diff)
+	}
+
+	cmd := zapscript.Command{Name: "cmd", AdvArgs: advArgs}
+	if got, want := cmd.String(), "**cmd?c=3&a=1"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestAdvArgsMerge(t *testing.T) {
+	t.Parallel()
+
+	parse := func(input string) zapscript.AdvArgs {
+		script, err := zapscript.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q) unexpected error: %v", input, err)
+		}
+		return script.Cmds[0].AdvArgs
+	}
+
+	tests := []struct {
+		base      zapscript.AdvArgs
+		overrides zapscript.AdvArgs
+		wantRaw   map[string]string
+		name      string
+		wantKeys  []zapscript.Key
+	}{
+		{
+			name:      "disjoint keys",
+			base:      parse("**cmd?b=2&a=1"),
+			overrides: parse("**cmd?d=4&c=3"),
+			wantKeys:  []zapscript.Key{"b", "a", "d", "c"},
+			wantRaw:   map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"},
+		},
+		{
+			name:      "conflicting keys",
+			base:      parse("**cmd?launcher=retroarch&system=snes&slot=1"),
+			overrides: parse("**cmd?mode=x&system=nes"),
+			wantKeys:  []zapscript.Key{"launcher", "system", "slot", "mode"},
+			wantRaw:   map[string]string{"launcher": "retroarch", "system": "nes", "slot": "1", "mode": "x"},
+		},
+		{
+			name:      "empty base",
+			overrides: parse("**cmd?b=2&a=1"),
+			wantKeys:  []zapscript.Key{"b", "a"},
+			wantRaw:   map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name:     "empty overrides",
+			base:     parse("**cmd?b=2&a=1"),
+			wantKeys: []zapscript.Key{"b", "a"},
+			wantRaw:  map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name: "both empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			baseKeys, overrideKeys := tt.base.OrderedKeys(), tt.overrides.OrderedKeys()
+			baseRaw, overrideRaw := maps.Clone(tt.base.Raw()), maps.Clone(tt.overrides.Raw())
+
+			merged := tt.base.Merge(tt.overrides)
+			if diff := cmp.Diff(tt.wantKeys, merged.OrderedKeys()); diff != "" {
+				t.Errorf("OrderedKeys() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRaw, merged.Raw(), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Raw() mismatch (-want +got):\n%s", diff)
+			}
+
+			if !cmp.Equal(baseKeys, tt.base.OrderedKeys()) || !cmp.Equal(baseRaw, tt.base.Raw()) {
+				t.Error("Merge() mutated the receiver")
+			}
+			if !cmp.Equal(overrideKeys, tt.overrides.OrderedKeys()) || !cmp.Equal(overrideRaw, tt.overrides.Raw()) {
+				t.Error("Merge() mutated the overrides")
+			}
+		})
+	}
+}
+
+func TestAdvArgsJSONOrder(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**cmd?b=2&a=1")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	data, err := json.Marshal(script.Cmds[0].AdvArgs)
+	if err != nil {
+		t.Fatalf("Marshal() unexpected error: %v", err)
+	}
+	if got, want := string(data), `{"b":"2","a":"1"}`; got != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+
+	var decoded zapscript.AdvArgs
+	if err := json.Unmarshal([]byte(`{"z":"1","m":"2","z":"3"}`), &decoded); err != nil {
+		t.Fatalf("Unmarshal() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]zapscript.Key{"z", "m"}, decoded.OrderedKeys()); diff != "" {
+		t.Errorf("OrderedKeys() mismatch (-want +got):\n%s", diff)
+	}
+	if got := decoded.Get("z"); got != "3" {
+		t.Errorf(`Get("z") = %q, want "3"`, got)
+	}
+
+	jsonScript, err := zapscript.Parse(`{"cmds":[{"name":"cmd","advArgs":{"B":"2","a":"1"}}]}`)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if got, want := jsonScript.Cmds[0].String(), "**cmd?b=2&a=1"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestAdvArgsEqualIgnoresOrder(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**cmd?b=2&a=1")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	advArgs := script.Cmds[0].AdvArgs
+	if !advArgs.Equal(zapscript.NewAdvArgs(map[string]string{"a": "1", "b": "2"})) {
+		t.Error("Equal() = false for the same values in a different order")
+	}
+	if advArgs.Equal(zapscript.NewAdvArgs(map[string]string{"a": "1", "b": "3"})) {
+		t.Error("Equal() = true for different values")
+	}
+}
+
+func TestAdvArgsEqualWithCmp(t *testing.T) {
+	t.Parallel()
+
+	if !zapscript.NewAdvArgs(nil).Equal(zapscript.NewAdvArgs(map[string]string{})) {
+		t.Error("Equal() = false for nil and empty AdvArgs")
+	}
+
+	script, err := zapscript.Parse("**launch:a?system=snes&slot=1")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	want := zapscript.Command{
+		Name:    "launch",
+		Args:    []string{"a"},
+		AdvArgs: zapscript.NewAdvArgs(map[string]string{"slot": "1", "system": "snes"}),
+	}
+	// no cmp.AllowUnexported: cmp uses AdvArgs.Equal
+	if diff := cmp.Diff(want, script.Cmds[0], cmpopts.IgnoreFields(zapscript.Command{}, "Raw", "Span")); diff != "" {
+		t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+	}
+	changed := want
+	changed.AdvArgs = want.AdvArgs.With(zapscript.KeySlot, "2")
+	if cmp.Equal(want, changed) {
+		t.Error("cmp.Equal() = true for different adv args")
+	}
+}
+
+func TestAdvArgsKeys(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := zapscript.Parse("**cmd?system=snes&action=run&launcher=retroarch")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		advArgs    zapscript.AdvArgs
+		wantKeys   []zapscript.Key
+		wantSorted []zapscript.Key
+	}{
+		{
+			name:       "parsed",
+			advArgs:    parsed.Cmds[0].AdvArgs,
+			wantKeys:   []zapscript.Key{"system", "action", "launcher"},
+			wantSorted: []zapscript.Key{"action", "launcher", "system"},
+		},
+		{
+			name:       "parsed then added",
+			advArgs:    parsed.Cmds[0].AdvArgs.With("mode", "shuffle"),
+			wantKeys:   []zapscript.Key{"system", "action", "launcher", "mode"},
+			wantSorted: []zapscript.Key{"action", "launcher", "mode", "system"},
+		},
+		{
+			name:       "NewAdvArgs",
+			advArgs:    zapscript.NewAdvArgs(map[string]string{"system": "snes", "action": "run", "launcher": "x"}),
+			wantKeys:   []zapscript.Key{"action", "launcher", "system"},
+			wantSorted: []zapscript.Key{"action", "launcher", "system"},
+		},
+		{
+			name:       "empty",
+			advArgs:    zapscript.NewAdvArgs(map[string]string{}),
+			wantKeys:   []zapscript.Key{},
+			wantSorted: []zapscript.Key{},
+		},
+		{
+			name:       "nil",
+			advArgs:    zapscript.NewAdvArgs(nil),
+			wantKeys:   []zapscript.Key{},
+			wantSorted: []zapscript.Key{},
+		},
+		{
+			name:       "zero",
+			wantKeys:   []zapscript.Key{},
+			wantSorted: []zapscript.Key{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			keys, sorted := tt.advArgs.Keys(), tt.advArgs.SortedKeys()
+			if keys == nil || sorted == nil {
+				t.Fatalf("Keys() = %#v, SortedKeys() = %#v, want non-nil slices", keys, sorted)
+			}
+			if diff := cmp.Diff(tt.wantKeys, keys); diff != "" {
+				t.Errorf("Keys() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantSorted, sorted); diff != "" {
+				t.Errorf("SortedKeys() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
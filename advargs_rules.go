@@ -0,0 +1,101 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictRule is a constraint between the adv args of a command, built with
+// MutuallyExclusive or Requires and checked with Command.CheckAdvArgs.
+type ConflictRule struct {
+	check func(c Command) error
+}
+
+// MutuallyExclusive returns a rule that at most one of keys is set.
+func MutuallyExclusive(keys ...Key) ConflictRule {
+	return ConflictRule{check: func(c Command) error {
+		var set []string
+		for _, k := range keys {
+			if c.AdvArgs.Has(k) {
+				set = append(set, fmt.Sprintf("%q", k))
+			}
+		}
+		if len(set) < 2 {
+			return nil
+		}
+		return fmt.Errorf("%w: command %s: %s cannot be used together",
+			ErrAdvArgConflict, c.Name, strings.Join(set, ", "))
+	}}
+}
+
+// Requires returns a rule that required is set whenever key is.
+func Requires(key, required Key) ConflictRule {
+	return ConflictRule{check: func(c Command) error {
+		if !c.AdvArgs.Has(key) || c.AdvArgs.Has(required) {
+			return nil
+		}
+		return fmt.Errorf("%w: command %s: %q requires %q", ErrAdvArgConflict, c.Name, key, required)
+	}}
+}
+
+// shuffleWithIndex rejects shuffling a playlist while going to an explicit
+// index in it, which would point at an unpredictable item.
+var shuffleWithIndex = ConflictRule{check: func(c Command) error {
+	if !IsModeShuffle(c.AdvArgs.GetMode()) || len(c.Args) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: command %s: %q=%s cannot be used with an index arg",
+		ErrAdvArgConflict, c.Name, KeyMode, ModeShuffle)
+}}
+
+var defaultConflictRules = map[string][]ConflictRule{
+	ZapScriptCmdLaunch:       {Requires(KeySetNameSameDir, KeySetName)},
+	ZapScriptCmdLaunchRandom: {Requires(KeySetNameSameDir, KeySetName)},
+	ZapScriptCmdLaunchSearch: {Requires(KeySetNameSameDir, KeySetName)},
+	ZapScriptCmdLaunchTitle:  {Requires(KeySetNameSameDir, KeySetName)},
+	ZapScriptCmdLaunchLast:   {Requires(KeySetNameSameDir, KeySetName)},
+	ZapScriptCmdPlaylistGoto: {shuffleWithIndex},
+}
+
+// DefaultConflictRules returns the rules for a built-in command, such as
+// set_name_same_dir requiring set_name for the launch commands, or nil for
+// commands without any. Hosts can add their own:
+//
+//	rules := append(zapscript.DefaultConflictRules(cmd.Name),
+//		zapscript.MutuallyExclusive(zapscript.KeyLauncher, "core"))
+//	errs := cmd.CheckAdvArgs(rules...)
+func DefaultConflictRules(cmdName string) []ConflictRule {
+	rules := defaultConflictRules[normalizeCmdName(cmdName)]
+	return rules[:len(rules):len(rules)]
+}
+
+// CheckAdvArgs returns an ErrAdvArgConflict error for each rule the
+// command's adv args break, in rule order, or nil if there are none. Values
+// are not evaluated, so a key set to an expression counts as set.
+func (c Command) CheckAdvArgs(rules ...ConflictRule) []error {
+	var errs []error
+	for _, rule := range rules {
+		if rule.check == nil {
+			continue
+		}
+		if err := rule.check(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
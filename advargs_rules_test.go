@@ -0,0 +1,149 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommandCheckAdvArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		rules    []zapscript.ConflictRule
+		defaults bool
+		wantMsgs []string
+	}{
+		{
+			name:  "no rules",
+			input: "**launch:a?launcher=x&set_name=y&set_name_same_dir=yes",
+		},
+		{
+			name:  "exclusive violated",
+			input: "**launch:a?launcher=x&system=snes&set_name=y",
+			rules: []zapscript.ConflictRule{zapscript.MutuallyExclusive(zapscript.KeyLauncher, zapscript.KeySetName)},
+			wantMsgs: []string{
+				`conflicting adv args: command launch: "launcher", "set_name" cannot be used together`,
+			},
+		},
+		{
+			name:  "exclusive satisfied",
+			input: "**launch:a?launcher=x&system=snes",
+			rules: []zapscript.ConflictRule{zapscript.MutuallyExclusive(zapscript.KeyLauncher, zapscript.KeySetName)},
+		},
+		{
+			name:  "requires satisfied",
+			input: "**launch:a?set_name=y&set_name_same_dir=yes",
+			rules: []zapscript.ConflictRule{zapscript.Requires(zapscript.KeySetNameSameDir, zapscript.KeySetName)},
+		},
+		{
+			name:  "requires violated by an empty key",
+			input: "**launch:a?set_name_same_dir",
+			rules: []zapscript.ConflictRule{zapscript.Requires(zapscript.KeySetNameSameDir, zapscript.KeySetName)},
+			wantMsgs: []string{
+				`conflicting adv args: command launch: "set_name_same_dir" requires "set_name"`,
+			},
+		},
+		{
+			name:  "every broken rule in order",
+			input: "**launch:a?launcher=x&set_name_same_dir=yes&system=snes",
+			rules: []zapscript.ConflictRule{
+				zapscript.Requires(zapscript.KeySetNameSameDir, zapscript.KeySetName),
+				zapscript.MutuallyExclusive(zapscript.KeyLauncher, zapscript.KeySystem),
+			},
+			wantMsgs: []string{
+				`conflicting adv args: command launch: "set_name_same_dir" requires "set_name"`,
+				`conflicting adv args: command launch: "launcher", "system" cannot be used together`,
+			},
+		},
+		{
+			name:     "default launch rules",
+			input:    "**launch.random:snes?set_name_same_dir=yes",
+			defaults: true,
+			wantMsgs: []string{
+				`conflicting adv args: command launch.random: "set_name_same_dir" requires "set_name"`,
+			},
+		},
+		{
+			name:     "default playlist rules",
+			input:    "**playlist.goto:3?mode=shuffle",
+			defaults: true,
+			wantMsgs: []string{
+				`conflicting adv args: command playlist.goto: "mode"=shuffle cannot be used with an index arg`,
+			},
+		},
+		{
+			name:     "default playlist rules satisfied",
+			input:    "**playlist.goto:3?repeat=all",
+			defaults: true,
+		},
+		{
+			name:     "command without default rules",
+			input:    "**echo:hi?set_name_same_dir=yes",
+			defaults: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			cmd := script.Cmds[0]
+			rules := tt.rules
+			if tt.defaults {
+				rules = zapscript.DefaultConflictRules(cmd.Name)
+			}
+
+			var msgs []string
+			for _, err := range cmd.CheckAdvArgs(rules...) {
+				if !errors.Is(err, zapscript.ErrAdvArgConflict) {
+					t.Errorf("CheckAdvArgs() error = %v, want ErrAdvArgConflict", err)
+				}
+				msgs = append(msgs, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantMsgs, msgs); diff != "" {
+				t.Errorf("CheckAdvArgs() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultConflictRulesAppend(t *testing.T) {
+	t.Parallel()
+
+	cmd := zapscript.Command{
+		Name:    "launch",
+		AdvArgs: zapscript.NewAdvArgs(map[string]string{"launcher": "x", "core": "y"}),
+	}
+	exclusive := zapscript.MutuallyExclusive(zapscript.KeyLauncher, "core")
+	extended := append(zapscript.DefaultConflictRules(cmd.Name), exclusive)
+	if got := len(cmd.CheckAdvArgs(extended...)); got != 1 {
+		t.Errorf("CheckAdvArgs(extended) returned %d errors, want 1", got)
+	}
+	if got := len(cmd.CheckAdvArgs(zapscript.DefaultConflictRules(cmd.Name)...)); got != 0 {
+		t.Errorf("appending to DefaultConflictRules changed the defaults, got %d errors", got)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewAdvArgsStrict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input   map[string]string
+		wantErr error
+		name    string
+		wantKey string
+	}{
+		{
+			name:  "valid keys",
+			input: map[string]string{"launcher": "x", "set_name": "y", "Mode": "shuffle"},
+		},
+		{
+			name:  "starts with a digit",
+			input: map[string]string{"1up": "x"},
+		},
+		{
+			name:  "starts with an underscore",
+			input: map[string]string{"_private": "x"},
+		},
+		{
+			name:  "nil map",
+			input: nil,
+		},
+		{
+			name:    "dash",
+			input:   map[string]string{"launcher": "x", "pre-notice": "y"},
+			wantErr: zapscript.ErrInvalidAdvArgName,
+			wantKey: "pre-notice",
+		},
+		{
+			name:    "space",
+			input:   map[string]string{"set name": "y"},
+			wantErr: zapscript.ErrInvalidAdvArgName,
+			wantKey: "set name",
+		},
+		{
+			name:    "empty key",
+			input:   map[string]string{"": "x"},
+			wantErr: zapscript.ErrInvalidAdvArgName,
+			wantKey: `""`,
+		},
+		{
+			name:    "non-ASCII letter",
+			input:   map[string]string{"nämé": "x"},
+			wantErr: zapscript.ErrInvalidAdvArgName,
+			wantKey: "nämé",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewAdvArgsStrict(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewAdvArgsStrict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if !strings.Contains(err.Error(), tt.wantKey) {
+					t.Errorf("NewAdvArgsStrict() error = %q, want it to name %s", err, tt.wantKey)
+				}
+				return
+			}
+			if diff := cmp.Diff(zapscript.NewAdvArgs(tt.input), got); diff != "" {
+				t.Errorf("NewAdvArgsStrict() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScriptMarshalTextInvalidAdvArgKey(t *testing.T) {
+	t.Parallel()
+
+	script := zapscript.Script{Cmds: []zapscript.Command{
+		{Name: "launch", Args: []string{"a"}},
+		{Name: "launch", Args: []string{"b"}, AdvArgs: zapscript.NewAdvArgs(map[string]string{"pre-notice": "x"})},
+	}}
+
+	_, err := script.MarshalText()
+	if !errors.Is(err, zapscript.ErrInvalidAdvArgName) {
+		t.Fatalf("MarshalText() error = %v, want %v", err, zapscript.ErrInvalidAdvArgName)
+	}
+	if !strings.Contains(err.Error(), "command 2 (launch)") || !strings.Contains(err.Error(), "pre-notice") {
+		t.Errorf("MarshalText() error = %q, want it to name the command and key", err)
+	}
+
+	_, err = xml.Marshal(mapping{Script: script})
+	if !errors.Is(err, zapscript.ErrInvalidAdvArgName) {
+		t.Errorf("xml.Marshal() error = %v, want %v", err, zapscript.ErrInvalidAdvArgName)
+	}
+
+	script.Cmds[1].AdvArgs = script.Cmds[1].AdvArgs.Without("pre-notice").With(zapscript.KeyPreNotice, "x")
+	text, err := script.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() unexpected error: %v", err)
+	}
+	if got, want := string(text), "**launch:a||**launch:b?pre_notice=x"; got != want {
+		t.Errorf("MarshalText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAdvArgsGetTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []zapscript.TagFilter
+		wantErr bool
+	}{
+		{
+			name:  "absent",
+			input: "**launch.random:snes",
+			want:  []zapscript.TagFilter{},
+		},
+		{
+			name:  "valid filters",
+			input: "**launch.random:snes?tags=Region:USA,-unfinished:demo,~lang:en",
+			want: []zapscript.TagFilter{
+				{Type: "region", Value: "usa", Operator: zapscript.TagOperatorAND},
+				{Type: "unfinished", Value: "demo", Operator: zapscript.TagOperatorNOT},
+				{Type: "lang", Value: "en", Operator: zapscript.TagOperatorOR},
+			},
+		},
+		{
+			name:  "escapes are resolved once",
+			input: "**launch.random:snes?tags=a^^b:c^,region:usa",
+			want: []zapscript.TagFilter{
+				{Type: "ab", Value: "c", Operator: zapscript.TagOperatorAND},
+				{Type: "region", Value: "usa", Operator: zapscript.TagOperatorAND},
+			},
+		},
+		{
+			name:  "quoted value",
+			input: `**launch.random:snes?tags="x:a,y:b"`,
+			want: []zapscript.TagFilter{
+				{Type: "x", Value: "a", Operator: zapscript.TagOperatorAND},
+				{Type: "y", Value: "b", Operator: zapscript.TagOperatorAND},
+			},
+		},
+		{
+			name:    "malformed",
+			input:   "**launch.search:mario?tags=region",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			got, err := script.Cmds[0].AdvArgs.GetTags()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("GetTags() expected an error, got nil")
+				}
+				if !strings.Contains(err.Error(), `"tags" is "region"`) {
+					t.Errorf("GetTags() error = %v, want it to name the key and value", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetTags() unexpected error: %v", err)
+			}
+			if got == nil {
+				t.Error("GetTags() = nil, want a non-nil list")
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GetTags() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdvArgsGetActionAndMode(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**launch:a?action=Details||**playlist.play:b?mode=shuffle||**launch:c")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	if action := script.Cmds[0].AdvArgs.GetAction(); !zapscript.IsActionDetails(action) {
+		t.Errorf("GetAction() = %q, want details", action)
+	}
+	if mode := script.Cmds[1].AdvArgs.GetMode(); !zapscript.IsModeShuffle(mode) {
+		t.Errorf("GetMode() = %q, want shuffle", mode)
+	}
+	unset := script.Cmds[2].AdvArgs
+	if action := unset.GetAction(); action != "" || !zapscript.IsActionRun(action) {
+		t.Errorf("GetAction() = %q, want empty, which runs", action)
+	}
+	if mode := unset.GetMode(); mode != "" || zapscript.IsModeShuffle(mode) {
+		t.Errorf("GetMode() = %q, want empty", mode)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+// typedArgs returns adv args with key set to value, or none if value is
+// "<unset>".
+func typedArgs(key, value string) zapscript.AdvArgs {
+	if value == "<unset>" {
+		return zapscript.NewAdvArgs(nil)
+	}
+	return zapscript.NewAdvArgs(map[string]string{key: value})
+}
+
+// checkTypedErr checks err matches wantErr and names the key and value.
+func checkTypedErr(t *testing.T, err, wantErr error, key, value string) {
+	t.Helper()
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("error = %v, want %v", err, wantErr)
+	}
+	if !strings.Contains(err.Error(), `"`+key+`"`) {
+		t.Errorf("error = %v, want it to name the key", err)
+	}
+	if !errors.Is(err, zapscript.ErrAdvArgMissing) && !strings.Contains(err.Error(), `"`+value+`"`) {
+		t.Errorf("error = %v, want it to name the value", err)
+	}
+}
+
+func TestAdvArgsGetInt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr error
+		name    string
+		value   string
+		want    int
+	}{
+		{name: "positive", value: "100", want: 100},
+		{name: "negative", value: "-3", want: -3},
+		{name: "zero", value: "0"},
+		{name: "whitespace", value: " 42 ", want: 42},
+		{name: "unset", value: "<unset>", wantErr: zapscript.ErrAdvArgMissing},
+		{name: "empty", value: "", wantErr: zapscript.ErrAdvArgMissing},
+		{name: "float", value: "1.5", wantErr: zapscript.ErrInvalidAdvArgNumber},
+		{name: "text", value: "ten", wantErr: zapscript.ErrInvalidAdvArgNumber},
+		{name: "overflow", value: "99999999999999999999", wantErr: zapscript.ErrInvalidAdvArgNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := typedArgs("slot", tt.value).GetInt(zapscript.KeySlot)
+			if tt.wantErr != nil {
+				checkTypedErr(t, err, tt.wantErr, "slot", tt.value)
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetInt() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetInt() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdvArgsGetFloat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr error
+		name    string
+		value   string
+		want    float64
+	}{
+		{name: "decimal", value: "1.5", want: 1.5},
+		{name: "integer", value: "2", want: 2},
+		{name: "negative", value: "-0.25", want: -0.25},
+		{name: "exponent", value: "1e3", want: 1000},
+		{name: "unset", value: "<unset>", wantErr: zapscript.ErrAdvArgMissing},
+		{name: "whitespace only", value: "  ", wantErr: zapscript.ErrAdvArgMissing},
+		{name: "text", value: "fast", wantErr: zapscript.ErrInvalidAdvArgNumber},
+		{name: "NaN", value: "NaN", wantErr: zapscript.ErrInvalidAdvArgNumber},
+		{name: "infinity", value: "+Inf", wantErr: zapscript.ErrInvalidAdvArgNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := typedArgs("speed", tt.value).GetFloat("speed")
+			if tt.wantErr != nil {
+				checkTypedErr(t, err, tt.wantErr, "speed", tt.value)
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetFloat() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetFloat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdvArgsGetDuration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr error
+		name    string
+		value   string
+		want    time.Duration
+	}{
+		{name: "milliseconds", value: "100", want: 100 * time.Millisecond},
+		{name: "zero", value: "0"},
+		{name: "seconds", value: "2s", want: 2 * time.Second},
+		{name: "fractional", value: "1.5s", want: 1500 * time.Millisecond},
+		{name: "compound", value: "1m30s", want: 90 * time.Second},
+		{name: "unset", value: "<unset>", wantErr: zapscript.ErrAdvArgMissing},
+		{name: "empty", value: "", wantErr: zapscript.ErrAdvArgMissing},
+		{name: "no unit", value: "1.5", wantErr: zapscript.ErrInvalidAdvArgDuration},
+		{name: "bad unit", value: "2 weeks", wantErr: zapscript.ErrInvalidAdvArgDuration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := typedArgs("timeout", tt.value).GetDuration("timeout")
+			if tt.wantErr != nil {
+				checkTypedErr(t, err, tt.wantErr, "timeout", tt.value)
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetDuration() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
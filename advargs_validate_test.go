@@ -0,0 +1,156 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateArgs(t *testing.T) {
+	t.Parallel()
+
+	launchers := []string{"retroarch", "mister"}
+	withLaunchers := zapscript.WithArgValidator("launcher", func(value string) bool {
+		return slices.Contains(launchers, value)
+	})
+	withSystems := zapscript.WithArgValidator("system", func(value string) bool {
+		return value == "snes"
+	})
+
+	tests := []struct {
+		in      any
+		name    string
+		opts    []zapscript.ValidateOption
+		wantErr []zapscript.AdvArgError
+	}{
+		{
+			name: "valid action",
+			in:   zapscript.LaunchArgs{Action: zapscript.ActionDetails},
+		},
+		{
+			name: "action in another case",
+			in:   &zapscript.LaunchArgs{Action: "Run"},
+		},
+		{
+			name:    "invalid action",
+			in:      zapscript.LaunchArgs{Action: "play"},
+			wantErr: []zapscript.AdvArgError{{Key: zapscript.KeyAction, Value: "play", Rule: "oneof=run details"}},
+		},
+		{
+			name: "empty optional fields",
+			in:   zapscript.LaunchArgs{},
+			opts: []zapscript.ValidateOption{withLaunchers, withSystems},
+		},
+		{
+			name: "known launcher",
+			in:   zapscript.LaunchArgs{Launcher: "retroarch", System: "snes"},
+			opts: []zapscript.ValidateOption{withLaunchers, withSystems},
+		},
+		{
+			name:    "unknown launcher",
+			in:      zapscript.LaunchArgs{Launcher: "steam"},
+			opts:    []zapscript.ValidateOption{withLaunchers},
+			wantErr: []zapscript.AdvArgError{{Key: zapscript.KeyLauncher, Value: "steam", Rule: "launcher"}},
+		},
+		{
+			name: "launcher without a registered validator",
+			in:   zapscript.LaunchArgs{Launcher: "steam"},
+		},
+		{
+			name: "every failure in field order",
+			in:   zapscript.LaunchArgs{Launcher: "steam", System: "nes", Action: "play"},
+			opts: []zapscript.ValidateOption{withLaunchers, withSystems},
+			wantErr: []zapscript.AdvArgError{
+				{Key: zapscript.KeyLauncher, Value: "steam", Rule: "launcher"},
+				{Key: zapscript.KeySystem, Value: "nes", Rule: "system"},
+				{Key: zapscript.KeyAction, Value: "play", Rule: "oneof=run details"},
+			},
+		},
+		{
+			name: "playlist repeat",
+			in:   zapscript.PlaylistArgs{Mode: "shuffle", Repeat: "twice"},
+			wantErr: []zapscript.AdvArgError{
+				{Key: zapscript.KeyRepeat, Value: "twice", Rule: "oneof=off all one"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := zapscript.ValidateArgs(tt.in, tt.opts...)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateArgs() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, zapscript.ErrInvalidAdvArgValue) {
+				t.Fatalf("ValidateArgs() error = %v, want ErrInvalidAdvArgValue", err)
+			}
+
+			var got []zapscript.AdvArgError
+			joined, ok := err.(interface{ Unwrap() []error })
+			if !ok {
+				t.Fatalf("ValidateArgs() error = %T, want errors joined with errors.Join", err)
+			}
+			for _, e := range joined.Unwrap() {
+				var argErr *zapscript.AdvArgError
+				if !errors.As(e, &argErr) {
+					t.Fatalf("error %v is %T, want *AdvArgError", e, e)
+				}
+				got = append(got, *argErr)
+			}
+			if diff := cmp.Diff(tt.wantErr, got); diff != "" {
+				t.Errorf("ValidateArgs() errors mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateArgsErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	err := zapscript.ValidateArgs(zapscript.LaunchArgs{Action: "play"})
+	if want := `adv arg "action" is "play", want one of run, details`; err == nil || err.Error() != want {
+		t.Errorf("ValidateArgs() error = %v, want %q", err, want)
+	}
+
+	err = zapscript.ValidateArgs(zapscript.LaunchArgs{Launcher: "steam"},
+		zapscript.WithArgValidator("launcher", func(string) bool { return false }))
+	if want := `adv arg "launcher" is "steam", want a valid launcher`; err == nil || err.Error() != want {
+		t.Errorf("ValidateArgs() error = %v, want %q", err, want)
+	}
+}
+
+func TestValidateArgsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	var nilArgs *zapscript.LaunchArgs
+	for _, in := range []any{nil, "action=run", nilArgs, struct {
+		Slot int `advarg:"slot" validate:"omitempty"`
+	}{}} {
+		if err := zapscript.ValidateArgs(in); !errors.Is(err, zapscript.ErrInvalidDecodeTarget) {
+			t.Errorf("ValidateArgs(%#v) error = %v, want ErrInvalidDecodeTarget", in, err)
+		}
+	}
+}
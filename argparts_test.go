@@ -0,0 +1,121 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitArgParts(t *testing.T) {
+	t.Parallel()
+
+	str := func(v string) zapscript.PostArgPart {
+		return zapscript.PostArgPart{Type: zapscript.ArgPartTypeString, Value: v}
+	}
+	exp := func(v string) zapscript.PostArgPart {
+		return zapscript.PostArgPart{Type: zapscript.ArgPartTypeExpression, Value: v}
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []zapscript.PostArgPart
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+		{
+			name:  "all literal",
+			input: "snes/mario.sfc",
+			want:  []zapscript.PostArgPart{str("snes/mario.sfc")},
+		},
+		{
+			name:  "all expression",
+			input: zapscript.TokExpStart + "platform" + zapscript.TokExprEnd,
+			want:  []zapscript.PostArgPart{exp("platform")},
+		},
+		{
+			name: "adjacent expressions",
+			input: zapscript.TokExpStart + "a" + zapscript.TokExprEnd +
+				zapscript.TokExpStart + "b" + zapscript.TokExprEnd,
+			want: []zapscript.PostArgPart{exp("a"), exp("b")},
+		},
+		{
+			name: "interleaved",
+			input: "/media/" + zapscript.TokExpStart + "platform" + zapscript.TokExprEnd +
+				"/" + zapscript.TokExpStart + "device.os" + zapscript.TokExprEnd + ".txt",
+			want: []zapscript.PostArgPart{
+				str("/media/"), exp("platform"), str("/"), exp("device.os"), str(".txt"),
+			},
+		},
+		{
+			name:  "empty expression",
+			input: "a" + zapscript.TokExpStart + zapscript.TokExprEnd + "b",
+			want:  []zapscript.PostArgPart{str("a"), exp(""), str("b")},
+		},
+		{
+			name:  "unterminated expression",
+			input: "a" + zapscript.TokExpStart + "1+1",
+			want:  []zapscript.PostArgPart{str("a"), exp("1+1")},
+		},
+		{
+			name:  "stray end token is literal",
+			input: "a" + zapscript.TokExprEnd + "b",
+			want:  []zapscript.PostArgPart{str("a" + zapscript.TokExprEnd + "b")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := zapscript.SplitArgParts(tt.input)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SplitArgParts() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSplitArgPartsFromParsedCommand(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser("**launch:/roms/[[platform]]/game.bin?system=[[device.os]]").ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	cmd := script.Cmds[0]
+
+	wantArg := []zapscript.PostArgPart{
+		{Type: zapscript.ArgPartTypeString, Value: "/roms/"},
+		{Type: zapscript.ArgPartTypeExpression, Value: "platform"},
+		{Type: zapscript.ArgPartTypeString, Value: "/game.bin"},
+	}
+	if diff := cmp.Diff(wantArg, zapscript.SplitArgParts(cmd.Args[0])); diff != "" {
+		t.Errorf("SplitArgParts(arg) mismatch (-want +got):\n%s", diff)
+	}
+
+	wantAdv := []zapscript.PostArgPart{
+		{Type: zapscript.ArgPartTypeExpression, Value: "device.os"},
+	}
+	if diff := cmp.Diff(wantAdv, zapscript.SplitArgParts(cmd.AdvArgs.Get(zapscript.KeySystem))); diff != "" {
+		t.Errorf("SplitArgParts(adv arg) mismatch (-want +got):\n%s", diff)
+	}
+}
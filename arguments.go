@@ -19,6 +19,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -82,9 +84,8 @@ func (sr *ScriptReader) parseJSONArg() (string, error) {
 	return string(normalizedJSON), nil
 }
 
-func (sr *ScriptReader) parseInputMacroArg() (args []string, advArgs map[string]string, err error) {
+func (sr *ScriptReader) parseInputMacroArg() (args []string, advArgs AdvArgs, err error) {
 	args = make([]string, 0)
-	advArgs = make(map[string]string)
 	totalLen := 0
 
 macroLoop:
@@ -113,6 +114,26 @@ macroLoop:
 			continue
 		}
 
+		// ^| is a literal | key as in other args, so a || key sequence can
+		// be written as ^|^| without ending the command
+		if ch == SymEscapeSeq {
+			next, peekErr := sr.peek()
+			if peekErr != nil {
+				return args, advArgs, peekErr
+			}
+			if next == SymCmdSep {
+				if skipErr := sr.skip(); skipErr != nil {
+					return args, advArgs, skipErr
+				}
+				totalLen++
+				if totalLen > InputMacroMaxKeys {
+					return args, advArgs, ErrInputMacroTooLong
+				}
+				args = append(args, string(SymCmdSep))
+				continue
+			}
+		}
+
 		eoc, err := sr.checkEndOfCmd(ch)
 		if err != nil {
 			return args, advArgs, err
@@ -178,6 +199,9 @@ macroLoop:
 
 // parseInputMacroExtContent reads characters from the reader until the closing
 // SymInputMacroExtEnd ('}') and returns the raw content between the braces.
+// Expressions are converted to expression tokens, so a } inside one does not
+// end the content, except in the "text" and text: forms, which are typed
+// literally.
 func (sr *ScriptReader) parseInputMacroExtContent() (string, error) {
 	var b strings.Builder
 	for {
@@ -191,11 +215,25 @@ func (sr *ScriptReader) parseInputMacroExtContent() (string, error) {
 		if ch == SymInputMacroExtEnd {
 			break
 		}
+		if ch == SymExpressionStart && !isInputMacroLiteral(b.String()) {
+			exprValue, exprErr := sr.parseExpression()
+			if exprErr != nil {
+				return "", exprErr
+			}
+			_, _ = b.WriteString(exprValue)
+			continue
+		}
 		_, _ = b.WriteRune(ch)
 	}
 	return b.String(), nil
 }
 
+// isInputMacroLiteral reports whether braces content starting with prefix is
+// literal text to type, see expandInputMacroExt.
+func isInputMacroLiteral(prefix string) bool {
+	return strings.HasPrefix(prefix, `"`) || strings.HasPrefix(prefix, "text:")
+}
+
 // expandInputMacroExt parses the raw content between '{' and '}' and returns the
 // expanded token slice. totalLen is updated by the number of tokens added so the
 // caller can enforce InputMacroMaxKeys across the whole macro.
@@ -419,27 +457,68 @@ func expandTokenN(token string, n int, totalLen *int) ([]string, error) {
 	return result, nil
 }
 
-func (sr *ScriptReader) parseAdvArgs() (advArgs map[string]string, remainingStr string, err error) {
-	advArgs = make(map[string]string)
+// parseAdvArgs reads the key=value pairs after ?. A key written without =,
+// as in ?verbose, is a flag and is stored with an empty value, the same as
+// ?verbose=; see AdvArgs.IsFlagSet.
+func (sr *ScriptReader) parseAdvArgs() (advArgs AdvArgs, remainingStr string, err error) {
+	raw := make(map[string]string)
+	var keys []string
+	var jsonKeys map[string]bool
+	var styles map[string]QuoteStyle
+	var repeats map[string][]string
+	var duplicates []duplicateKey
+	keyStart := int64(0)
+	isJSON := false
+	style := QuoteStyleNone
 	inValue := false
 	currentArg := ""
 	currentValue := ""
 	valueStart := int64(-1)
+	afterQuote := false
 	buf := make([]rune, 0, 64)
 
 	storeArg := func() {
 		if currentArg != "" {
+			currentArg = sr.normalizeAdvArgName(currentArg)
 			currentValue = strings.TrimSpace(currentValue)
-			advArgs[currentArg] = currentValue
+			if previous, ok := raw[currentArg]; ok {
+				duplicates = addDuplicateKey(duplicates, currentArg, keyStart)
+				if repeats == nil {
+					repeats = make(map[string][]string)
+				}
+				if _, seen := repeats[currentArg]; !seen {
+					repeats[currentArg] = []string{previous}
+				}
+				repeats[currentArg] = append(repeats[currentArg], currentValue)
+			} else {
+				keys = append(keys, currentArg)
+			}
+			raw[currentArg] = currentValue
+			if isJSON {
+				if jsonKeys == nil {
+					jsonKeys = make(map[string]bool)
+				}
+				jsonKeys[currentArg] = true
+			} else {
+				delete(jsonKeys, currentArg)
+			}
+			if sr.opts.KeepStyle {
+				if styles == nil {
+					styles = make(map[string]QuoteStyle)
+				}
+				styles[currentArg] = style
+			}
 		}
 		currentArg = ""
 		currentValue = ""
+		isJSON = false
+		style = QuoteStyleNone
 	}
 
 	for {
 		ch, err := sr.read()
 		if err != nil {
-			return advArgs, string(buf), err
+			return AdvArgs{}, string(buf), err
 		} else if ch == eof {
 			break
 		}
@@ -451,32 +530,35 @@ func (sr *ScriptReader) parseAdvArgs() (advArgs map[string]string, remainingStr
 			case valueStart == sr.pos-1 && (ch == SymArgDoubleQuote || ch == SymArgSingleQuote):
 				quotedValue, parseErr := sr.parseQuotedArg(ch)
 				if parseErr != nil {
-					return advArgs, string(buf), parseErr
+					return AdvArgs{}, string(buf), parseErr
 				}
 				currentValue = quotedValue
+				afterQuote = true
+				style = quoteStyleOf(ch)
 				continue
 			case ch == SymJSONStart && valueStart == sr.pos-1:
 				jsonValue, parseErr := sr.parseJSONArg()
 				if parseErr != nil {
-					return advArgs, string(buf), parseErr
+					return AdvArgs{}, string(buf), parseErr
 				}
 				currentValue = jsonValue
+				isJSON = true
+				style = QuoteStyleJSON
 				continue
 			case ch == SymEscapeSeq:
-				// Peek next char for raw tracking before parseEscapeSeq consumes it
-				nextRaw, peekErr := sr.peek()
-				if peekErr != nil {
-					return advArgs, string(buf), peekErr
+				if quoteErr := sr.checkAfterQuote(afterQuote, ch); quoteErr != nil {
+					return AdvArgs{}, string(buf), quoteErr
 				}
-
-				next, escapeErr := sr.parseEscapeSeq()
+				isJSON = false
+				style = mixedStyle(style)
+				next, nextRaw, escapeErr := sr.parseEscapeSeqRaw()
 				if escapeErr != nil {
-					return advArgs, string(buf), escapeErr
+					return AdvArgs{}, string(buf), escapeErr
 				} else if next == "" {
 					currentValue += string(SymEscapeSeq)
 					continue
 				}
-				buf = append(buf, nextRaw)
+				buf = append(buf, []rune(nextRaw)...)
 				currentValue += next
 				continue
 			}
@@ -484,7 +566,7 @@ func (sr *ScriptReader) parseAdvArgs() (advArgs map[string]string, remainingStr
 
 		eoc, err := sr.checkEndOfCmd(ch)
 		if err != nil {
-			return advArgs, string(buf), err
+			return AdvArgs{}, string(buf), err
 		} else if eoc {
 			break
 		}
@@ -492,6 +574,7 @@ func (sr *ScriptReader) parseAdvArgs() (advArgs map[string]string, remainingStr
 		if ch == SymAdvArgSep {
 			storeArg()
 			inValue = false
+			afterQuote = false
 			continue
 		} else if ch == SymAdvArgEq && !inValue {
 			valueStart = sr.pos
@@ -501,10 +584,18 @@ func (sr *ScriptReader) parseAdvArgs() (advArgs map[string]string, remainingStr
 
 		switch {
 		case inValue:
+			if quoteErr := sr.checkAfterQuote(afterQuote, ch); quoteErr != nil {
+				return AdvArgs{}, string(buf), quoteErr
+			}
+			if !isWhitespace(ch) {
+				// text after the JSON value means it is no longer JSON
+				isJSON = false
+				style = mixedStyle(style)
+			}
 			if ch == SymExpressionStart {
 				exprValue, err := sr.parseExpression()
 				if err != nil {
-					return advArgs, string(buf), err
+					return AdvArgs{}, string(buf), err
 				}
 				currentValue += exprValue
 			} else {
@@ -512,29 +603,209 @@ func (sr *ScriptReader) parseAdvArgs() (advArgs map[string]string, remainingStr
 			}
 			continue
 		case !isAdvArgName(ch):
-			return advArgs, string(buf), ErrInvalidAdvArgName
+			return AdvArgs{}, string(buf), ErrInvalidAdvArgName
 		default:
+			if currentArg == "" {
+				keyStart = sr.pos
+			}
 			currentArg += string(ch)
 		}
 	}
 
 	storeArg()
 
+	dupErr := sr.checkDuplicateKeys(ErrDuplicateAdvArg, WarningDuplicateAdvArg, "adv arg", duplicates)
+	if dupErr != nil {
+		return AdvArgs{}, string(buf), dupErr
+	}
+
+	if sr.opts.DecodePercentEscapes {
+		if decodeErr := decodePercentEscapes(raw, repeats, keys, jsonKeys); decodeErr != nil {
+			return AdvArgs{}, string(buf), decodeErr
+		}
+	}
+
+	if sizeErr := sr.checkAdvArgSizes(raw, repeats, keys); sizeErr != nil {
+		return AdvArgs{}, string(buf), sizeErr
+	}
+
+	advArgs = AdvArgs{raw: raw, repeats: repeats, keys: keys, json: jsonKeys, styles: styles}
 	return advArgs, string(buf), nil
 }
 
+// checkAdvArgSizes returns ErrAdvArgTooLarge for the first value, in keys
+// order, over the configured size limit. Values are checked once decoded, so
+// quoted, escaped and JSON values are all counted the same.
+func (sr *ScriptReader) checkAdvArgSizes(raw map[string]string, repeats map[string][]string, keys []string) error {
+	limit := sr.opts.MaxAdvArgValueBytes
+	if limit <= 0 {
+		return nil
+	}
+	for _, key := range keys {
+		// repeats, if any, end with the value in raw
+		values := repeats[key]
+		if len(values) == 0 {
+			values = []string{raw[key]}
+		}
+		for _, value := range values {
+			if len(value) > limit {
+				return fmt.Errorf("%w: adv arg %q is %d bytes, limit is %d", ErrAdvArgTooLarge, key, len(value), limit)
+			}
+		}
+	}
+	return nil
+}
+
+// decodePercentEscapes replaces the values in raw and repeats, other than
+// JSON values, with their percent-decoded text, see
+// Options.DecodePercentEscapes. keys gives the order errors are checked in.
+func decodePercentEscapes(
+	raw map[string]string, repeats map[string][]string, keys []string, jsonKeys map[string]bool,
+) error {
+	for _, key := range keys {
+		if !jsonKeys[key] {
+			decoded, err := decodePercentValue(key, raw[key])
+			if err != nil {
+				return err
+			}
+			raw[key] = decoded
+		}
+		values := repeats[key]
+		// the last repeat is the value in raw, decoded above unless JSON
+		for i := range len(values) - 1 {
+			decoded, err := decodePercentValue(key, values[i])
+			if err != nil {
+				return err
+			}
+			values[i] = decoded
+		}
+		if len(values) > 0 {
+			values[len(values)-1] = raw[key]
+		}
+	}
+	return nil
+}
+
+// decodePercentValue percent-decodes the text of a parsed value, leaving its
+// expressions as written.
+func decodePercentValue(key, value string) (string, error) {
+	if !strings.Contains(value, "%") {
+		return value, nil
+	}
+	var b strings.Builder
+	parts, complete := splitArgParts(value)
+	for i, part := range parts {
+		if part.Type == ArgPartTypeExpression {
+			_, _ = b.WriteString(TokExpStart + part.Value)
+			if complete || i < len(parts)-1 {
+				_, _ = b.WriteString(TokExprEnd)
+			}
+			continue
+		}
+		decoded, err := url.PathUnescape(part.Value)
+		if err != nil {
+			return "", fmt.Errorf("%w: adv arg %q: %w", ErrInvalidPercentEscape, key, err)
+		}
+		_, _ = b.WriteString(decoded)
+	}
+	return b.String(), nil
+}
+
+// mixedStyle returns the style of a value after unquoted text is appended to
+// it. Text following a quoted or JSON value cannot be reproduced in that
+// style, so the value falls back to automatic quoting.
+func mixedStyle(style QuoteStyle) QuoteStyle {
+	if style == QuoteStyleNone {
+		return QuoteStyleNone
+	}
+	return QuoteStyleAuto
+}
+
+// normalizeAdvArgName lowercases an adv arg name, matching command names and
+// trait keys, and resolves any configured alias to its canonical key.
+func (sr *ScriptReader) normalizeAdvArgName(name string) string {
+	name = strings.ToLower(name)
+	if key, ok := sr.opts.AdvArgAliases[name]; ok {
+		return string(key)
+	}
+	return name
+}
+
+// duplicateKey is a key set more than once in one segment, with the
+// position of its first repeat.
+type duplicateKey struct {
+	key string
+	pos int64
+}
+
+// addDuplicateKey records key as duplicated unless it already is, so a key
+// repeated several times is reported once.
+func addDuplicateKey(dups []duplicateKey, key string, pos int64) []duplicateKey {
+	if slices.ContainsFunc(dups, func(d duplicateKey) bool { return d.key == key }) {
+		return dups
+	}
+	return append(dups, duplicateKey{key: key, pos: pos})
+}
+
+// checkDuplicateKeys reports keys set more than once in a single adv args or
+// traits segment. In strict mode the first one is an error wrapping sentinel,
+// otherwise each is recorded as a warning and the last value is kept.
+func (sr *ScriptReader) checkDuplicateKeys(sentinel error, code WarningCode, kind string, dups []duplicateKey) error {
+	if len(dups) == 0 {
+		return nil
+	}
+	if sr.opts.Strict {
+		return fmt.Errorf("%w: %q", sentinel, dups[0].key)
+	}
+	for _, d := range dups {
+		sr.warnings = append(sr.warnings, Warning{
+			Code:     code,
+			Message:  fmt.Sprintf("%s %q is set more than once, using the last value", kind, d.key),
+			Fragment: d.key,
+			Pos:      d.pos,
+			CmdIndex: sr.cmdIndex,
+		})
+	}
+	return nil
+}
+
+// checkAfterQuote enforces the strict mode rule that only whitespace, a
+// separator or adv args may follow a closing quote. In lenient mode the text
+// is appended to the quoted value.
+func (sr *ScriptReader) checkAfterQuote(afterQuote bool, ch rune) error {
+	if !afterQuote || !sr.opts.Strict || isWhitespace(ch) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q at position %d", ErrTrailingAfterQuote, ch, sr.pos)
+}
+
 func (sr *ScriptReader) parseArgs(
 	prefix string,
 	onlyAdvArgs bool,
 	onlyOneArg bool,
-) (args []string, advArgs map[string]string, err error) {
+) (args []string, advArgs AdvArgs, err error) {
 	args = make([]string, 0)
-	advArgs = make(map[string]string)
+	sr.argStyles = nil
+	style := QuoteStyleNone
+	appendArg := func(arg string) {
+		args = append(args, arg)
+		if sr.opts.KeepStyle {
+			sr.argStyles = append(sr.argStyles, style)
+		}
+		style = QuoteStyleNone
+	}
 	currentArg := prefix
 	argStart := sr.pos
 	// tracks whether content was explicitly written, distinguishing
 	// "**cmd:" (no content, no arg) from "**cmd:''" (explicit empty arg)
 	argWritten := prefix != ""
+	// text after a closing quote is appended to the quoted value, except in
+	// strict mode where it is an error
+	afterQuote := false
+	sep := SymArgSep
+	if sr.splitSep != 0 {
+		sep = sr.splitSep
+	}
 
 argsLoop:
 	for {
@@ -553,6 +824,8 @@ argsLoop:
 			}
 			currentArg = quotedArg
 			argWritten = true
+			afterQuote = true
+			style = quoteStyleOf(ch)
 			continue argsLoop
 		case argStart == sr.pos-1 && ch == SymJSONStart:
 			jsonArg, jsonErr := sr.parseJSONArg()
@@ -561,9 +834,14 @@ argsLoop:
 			}
 			currentArg = jsonArg
 			argWritten = true
+			style = QuoteStyleJSON
 			continue argsLoop
 		case ch == SymEscapeSeq:
+			if quoteErr := sr.checkAfterQuote(afterQuote, ch); quoteErr != nil {
+				return args, advArgs, quoteErr
+			}
 			// escaping next character
+			style = mixedStyle(style)
 			next, escapeErr := sr.parseEscapeSeq()
 			if escapeErr != nil {
 				return args, advArgs, escapeErr
@@ -577,29 +855,36 @@ argsLoop:
 			continue argsLoop
 		}
 
-		eoc, err := sr.checkEndOfCmd(ch)
-		if err != nil {
-			return args, advArgs, err
-		} else if eoc {
-			break argsLoop
+		if sr.splitSep == 0 {
+			eoc, err := sr.checkEndOfCmd(ch)
+			if err != nil {
+				return args, advArgs, err
+			} else if eoc {
+				break argsLoop
+			}
 		}
 
 		switch {
-		case !onlyOneArg && ch == SymArgSep:
+		case !onlyOneArg && ch == sep:
 			// new argument
 			currentArg = strings.TrimSpace(currentArg)
-			args = append(args, currentArg)
+			appendArg(currentArg)
+			if countErr := sr.checkArgCount(len(args)); countErr != nil {
+				return args, advArgs, countErr
+			}
 			currentArg = ""
 			argStart = sr.pos
 			argWritten = false
+			afterQuote = false
 			continue argsLoop
-		case ch == SymAdvArgStart:
+		case ch == SymAdvArgStart && sr.splitSep == 0:
 			newAdvArgs, buf, err := sr.parseAdvArgs()
 			switch {
 			case errors.Is(err, ErrInvalidAdvArgName):
 				// if an adv arg name is invalid, fallback on treating it
 				// as a positional arg with a ? in it
 				currentArg += string(SymAdvArgStart) + buf
+				style = mixedStyle(style)
 				continue argsLoop
 			case err != nil:
 				return args, advArgs, err
@@ -610,17 +895,25 @@ argsLoop:
 			// advanced args are always the last part of a command
 			break argsLoop
 		case ch == SymExpressionStart:
+			if quoteErr := sr.checkAfterQuote(afterQuote, ch); quoteErr != nil {
+				return args, advArgs, quoteErr
+			}
 			exprValue, err := sr.parseExpression()
 			if err != nil {
 				return args, advArgs, err
 			}
 			currentArg += exprValue
 			argWritten = true
+			style = mixedStyle(style)
 			continue argsLoop
 		default:
+			if quoteErr := sr.checkAfterQuote(afterQuote, ch); quoteErr != nil {
+				return args, advArgs, quoteErr
+			}
 			currentArg += string(ch)
 			if !isWhitespace(ch) {
 				argWritten = true
+				style = mixedStyle(style)
 			}
 			continue argsLoop
 		}
@@ -628,11 +921,23 @@ argsLoop:
 
 	currentArg = strings.TrimSpace(currentArg)
 	if !onlyAdvArgs && (currentArg != "" || argWritten) {
-		args = append(args, currentArg)
+		appendArg(currentArg)
 	} else if onlyAdvArgs && currentArg != "" {
 		// fallback content from invalid adv args should still be preserved
-		args = append(args, currentArg)
+		appendArg(currentArg)
+	}
+	if countErr := sr.checkArgCount(len(args)); countErr != nil {
+		return args, advArgs, countErr
 	}
 
 	return args, advArgs, nil
 }
+
+// checkArgCount returns ErrTooManyArgs if n positional args would exceed the
+// configured limit.
+func (sr *ScriptReader) checkArgCount(n int) error {
+	if limit := sr.opts.MaxArgs; limit > 0 && n > limit {
+		return fmt.Errorf("%w: limit is %d", ErrTooManyArgs, limit)
+	}
+	return nil
+}
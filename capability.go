@@ -0,0 +1,240 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+)
+
+// ExprEnvSchemaVersion is the current version of the ArgExprEnv fields and
+// methods available to expressions. It is increased whenever one is added.
+const ExprEnvSchemaVersion = 4
+
+// exprEnvFieldVersions records the schema version that introduced each
+// top-level ArgExprEnv field, and traits, which hosts provide with
+// EvalOptions.Traits. New fields must be added here with the bumped
+// ExprEnvSchemaVersion.
+var exprEnvFieldVersions = map[string]int{
+	"active_media":  1,
+	"device":        1,
+	"hook":          1,
+	"last_scanned":  1,
+	"launching":     1,
+	"media_playing": 1,
+	"media_ready":   1,
+	"now":           2,
+	"platform":      1,
+	"scan_mode":     1,
+	"scanned":       1,
+	"traits":        4,
+	"version":       1,
+}
+
+// exprEnvMethodVersions records the schema version that introduced each
+// ArgExprEnv method expressions can call.
+var exprEnvMethodVersions = map[string]int{
+	"HasLaunching": 3,
+	"HasScanned":   3,
+}
+
+// CapabilityFeatures lists the optional syntax features a host supports.
+type CapabilityFeatures struct {
+	// Expressions allows [[...]] expressions in args and adv args.
+	Expressions bool `json:"expressions"`
+	// Traits allows #key=value and **traits segments.
+	Traits bool `json:"traits"`
+	// MediaTitle allows launch.title commands, which the @System/Title
+	// syntax produces.
+	MediaTitle bool `json:"mediaTitle"`
+}
+
+// CapabilityProfile describes what a host, such as a particular device
+// firmware, can run. It is usually loaded from JSON.
+type CapabilityProfile struct {
+	// AdvArgs lists the adv arg keys accepted per command name. Commands not
+	// in the map accept any keys.
+	AdvArgs map[string][]Key `json:"advArgs,omitempty"`
+	// Name identifies the profile in reports.
+	Name string `json:"name"`
+	// Commands lists the supported command names. A nil list accepts any
+	// command.
+	Commands []string `json:"commands,omitempty"`
+	// Features lists the supported optional syntax.
+	Features CapabilityFeatures `json:"features"`
+	// EnvSchemaVersion is the ExprEnvSchemaVersion the host provides to
+	// expressions.
+	EnvSchemaVersion int `json:"envSchemaVersion"`
+}
+
+// CheckCompatibility reports everything in the script that the profile does
+// not support as warnings: unknown commands, unsupported adv arg keys,
+// optional syntax features and expression env fields and methods newer than
+// the profile's schema. Warnings are sorted with SortWarnings. An empty
+// result means the script is compatible.
+func (s Script) CheckCompatibility(p CapabilityProfile) []Warning {
+	var report []Warning
+
+	if len(s.Traits) > 0 && !p.Features.Traits {
+		report = append(report, Warning{
+			Code:     WarningUnsupportedFeature,
+			Message:  "traits are not supported",
+			CmdIndex: TraitsCmdIndex,
+		})
+	}
+
+	for i, cmd := range s.Cmds {
+		add := func(code WarningCode, fragment, format string, args ...any) {
+			report = append(report, Warning{
+				Code:     code,
+				Message:  fmt.Sprintf(format, args...),
+				Fragment: fragment,
+				CmdIndex: i,
+			})
+		}
+
+		if p.Commands != nil && !slices.Contains(p.Commands, cmd.Name) {
+			add(WarningUnsupportedCommand, cmd.Name, "command %q is not supported", cmd.Name)
+		}
+
+		if cmd.Name == ZapScriptCmdLaunchTitle && !p.Features.MediaTitle {
+			add(WarningUnsupportedFeature, cmd.Name, "media title launching is not supported")
+		}
+
+		if allowed, ok := p.AdvArgs[cmd.Name]; ok {
+			for _, k := range cmd.AdvArgs.SortedKeys() {
+				if !slices.Contains(allowed, k) {
+					add(WarningUnsupportedAdvArg, string(k), "adv arg %q is not supported by %q", k, cmd.Name)
+				}
+			}
+		}
+
+		exprs := commandExpressions(cmd)
+		if len(exprs) == 0 {
+			continue
+		}
+		if !p.Features.Expressions {
+			add(WarningUnsupportedFeature, cmd.Name, "expressions are not supported")
+			continue
+		}
+		for _, field := range exprEnvDependencies(exprs) {
+			if version, ok := exprEnvMethodVersions[field]; ok {
+				if version > p.EnvSchemaVersion {
+					add(WarningUnsupportedEnv, field,
+						"expression env method %s() needs env schema version %d, profile has %d",
+						field, version, p.EnvSchemaVersion)
+				}
+				continue
+			}
+			version, known := exprEnvFieldVersions[field]
+			switch {
+			case !known:
+				add(WarningUnsupportedEnv, field, "expression env field %q does not exist", field)
+			case version > p.EnvSchemaVersion:
+				add(WarningUnsupportedEnv, field,
+					"expression env field %q needs env schema version %d, profile has %d",
+					field, version, p.EnvSchemaVersion)
+			}
+		}
+	}
+
+	SortWarnings(report)
+	return report
+}
+
+// commandExpressions returns the source of every expression in the command's
+// args and adv arg values, in order.
+func commandExpressions(cmd Command) []string {
+	var exprs []string
+	values := slices.Clone(cmd.Args)
+	for _, k := range cmd.AdvArgs.SortedKeys() {
+		values = append(values, cmd.AdvArgs.Get(k))
+	}
+	for _, v := range values {
+		exprs = appendValueExpressions(exprs, v)
+	}
+	return exprs
+}
+
+// appendValueExpressions appends the source of every terminated expression
+// in a parsed value to exprs.
+func appendValueExpressions(exprs []string, v string) []string {
+	parts, complete := splitArgParts(v)
+	if !complete {
+		parts = parts[:len(parts)-1]
+	}
+	for _, part := range parts {
+		if part.Type == ArgPartTypeExpression {
+			exprs = append(exprs, part.Value)
+		}
+	}
+	return exprs
+}
+
+// envIdentCollector gathers the identifiers an expression reads from its
+// env, skipping function names and variables declared with let.
+type envIdentCollector struct {
+	idents   []*ast.IdentifierNode
+	callees  map[ast.Node]bool
+	declared map[string]bool
+}
+
+func (c *envIdentCollector) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		c.idents = append(c.idents, n)
+	case *ast.CallNode:
+		c.callees[n.Callee] = true
+	case *ast.VariableDeclaratorNode:
+		c.declared[n.Name] = true
+	}
+}
+
+// exprEnvDependencies returns the sorted top-level env fields referenced by
+// the expressions and the env methods they call. Expressions that fail to
+// parse are skipped since they fail at evaluation regardless of the env.
+func exprEnvDependencies(exprs []string) []string {
+	fields := make(map[string]bool)
+	for _, src := range exprs {
+		tree, err := parser.Parse(src)
+		if err != nil {
+			continue
+		}
+		c := &envIdentCollector{
+			callees:  make(map[ast.Node]bool),
+			declared: make(map[string]bool),
+		}
+		ast.Walk(&tree.Node, c)
+		for _, id := range c.idents {
+			if c.declared[id.Value] {
+				continue
+			}
+			if _, method := exprEnvMethodVersions[id.Value]; method || !c.callees[id] {
+				fields[id.Value] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for f := range fields {
+		names = append(names, f)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,200 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func loadProfile(t *testing.T, name string) zapscript.CapabilityProfile {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "profiles", name+".json"))
+	if err != nil {
+		t.Fatalf("failed to read profile: %v", err)
+	}
+	var p zapscript.CapabilityProfile
+	if unmarshalErr := json.Unmarshal(data, &p); unmarshalErr != nil {
+		t.Fatalf("failed to unmarshal profile: %v", unmarshalErr)
+	}
+	return p
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	t.Parallel()
+
+	legacy := loadProfile(t, "legacy")
+	current := loadProfile(t, "current")
+	oldEnv := current
+	oldEnv.EnvSchemaVersion = 0
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []zapscript.Warning
+		profile zapscript.CapabilityProfile
+	}{
+		{
+			name:    "legacy compatible script",
+			input:   `**launch:game.rom?launcher=x||**delay:500`,
+			profile: legacy,
+		},
+		{
+			name:    "legacy unsupported command",
+			input:   `**launch:game.rom||**playlist.play:list`,
+			profile: legacy,
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningUnsupportedCommand,
+				Message:  `command "playlist.play" is not supported`,
+				Fragment: "playlist.play",
+				CmdIndex: 1,
+			}},
+		},
+		{
+			name:    "legacy traits and media title",
+			input:   `#favorite||@snes/Super Mario World`,
+			profile: legacy,
+			want: []zapscript.Warning{
+				{
+					Code:     zapscript.WarningUnsupportedFeature,
+					Message:  "traits are not supported",
+					CmdIndex: zapscript.TraitsCmdIndex,
+				},
+				{
+					Code:     zapscript.WarningUnsupportedCommand,
+					Message:  `command "launch.title" is not supported`,
+					Fragment: "launch.title",
+				},
+				{
+					Code:     zapscript.WarningUnsupportedFeature,
+					Message:  "media title launching is not supported",
+					Fragment: "launch.title",
+				},
+			},
+		},
+		{
+			name:    "legacy unsupported adv args",
+			input:   `**launch:game.rom?slot=2&launcher=x||**launch.random:snes?tags=region:us`,
+			profile: legacy,
+			want: []zapscript.Warning{
+				{
+					Code:     zapscript.WarningUnsupportedAdvArg,
+					Message:  `adv arg "slot" is not supported by "launch"`,
+					Fragment: "slot",
+				},
+				{
+					Code:     zapscript.WarningUnsupportedAdvArg,
+					Message:  `adv arg "tags" is not supported by "launch.random"`,
+					Fragment: "tags",
+					CmdIndex: 1,
+				},
+			},
+		},
+		{
+			name:    "legacy adv args of unlisted command",
+			input:   `**mister.ini:1?anything=x`,
+			profile: legacy,
+		},
+		{
+			name:    "legacy expressions",
+			input:   `**launch:[[platform]].rom`,
+			profile: legacy,
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningUnsupportedFeature,
+				Message:  "expressions are not supported",
+				Fragment: "launch",
+			}},
+		},
+		{
+			name:    "current supports newer features",
+			input:   `#favorite||@snes/Super Mario World||**playlist.play:list?mode=shuffle`,
+			profile: current,
+		},
+		{
+			name:    "current expression env fields",
+			input:   `**echo:[[active_media.path]]?when=[[len(platform) > 0]]`,
+			profile: current,
+		},
+		{
+			name:    "let variables are not env fields",
+			input:   `**echo:[[let p = platform; p + "!"]]`,
+			profile: current,
+		},
+		{
+			name:    "unknown env field",
+			input:   `**echo:[[media_playing ? nosuch : platform]]`,
+			profile: current,
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningUnsupportedEnv,
+				Message:  `expression env field "nosuch" does not exist`,
+				Fragment: "nosuch",
+			}},
+		},
+		{
+			name:    "env field newer than profile",
+			input:   `**echo:ok||**echo:[[device.hostname]]`,
+			profile: oldEnv,
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningUnsupportedEnv,
+				Message:  `expression env field "device" needs env schema version 1, profile has 0`,
+				Fragment: "device",
+				CmdIndex: 1,
+			}},
+		},
+		{
+			name:    "current env methods",
+			input:   `**echo:[[HasLaunching() ? launching.path : "none"]]?when=[[HasScanned()]]`,
+			profile: current,
+		},
+		{
+			name:    "env method newer than profile",
+			input:   `**echo:[[HasLaunching() ? launching.path : "none"]]`,
+			profile: oldEnv,
+			want: []zapscript.Warning{
+				{
+					Code:     zapscript.WarningUnsupportedEnv,
+					Message:  `expression env field "launching" needs env schema version 1, profile has 0`,
+					Fragment: "launching",
+				},
+				{
+					Code:     zapscript.WarningUnsupportedEnv,
+					Message:  "expression env method HasLaunching() needs env schema version 3, profile has 0",
+					Fragment: "HasLaunching",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, script.CheckCompatibility(tt.profile)); diff != "" {
+				t.Errorf("CheckCompatibility() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
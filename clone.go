@@ -0,0 +1,101 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a deep copy of the script. Commands, traits, including nested
+// maps and slices from JSON traits, hints and warnings are copied so the copy
+// can be modified without affecting s.
+func (s Script) Clone() Script {
+	var clone Script
+	if s.Traits != nil {
+		clone.Traits = cloneTraits(s.Traits)
+	}
+	if s.Cmds != nil {
+		clone.Cmds = make([]Command, len(s.Cmds))
+		for i, cmd := range s.Cmds {
+			clone.Cmds[i] = cmd.Clone()
+		}
+	}
+	clone.TraitsSpans = slices.Clone(s.TraitsSpans)
+	clone.Warnings = slices.Clone(s.Warnings)
+	return clone
+}
+
+// Clone returns a deep copy of the command, so its args and adv args can be
+// modified without affecting c.
+func (c Command) Clone() Command {
+	return Command{
+		Name:      c.Name,
+		Args:      slices.Clone(c.Args),
+		ArgStyles: slices.Clone(c.ArgStyles),
+		AdvArgs: AdvArgs{
+			raw:     maps.Clone(c.AdvArgs.raw),
+			repeats: cloneRepeats(c.AdvArgs.repeats),
+			keys:    slices.Clone(c.AdvArgs.keys),
+			json:    maps.Clone(c.AdvArgs.json),
+			styles:  maps.Clone(c.AdvArgs.styles),
+		},
+		Raw:  c.Raw,
+		Span: c.Span,
+	}
+}
+
+func cloneRepeats(repeats map[string][]string) map[string][]string {
+	if repeats == nil {
+		return nil
+	}
+	clone := make(map[string][]string, len(repeats))
+	for k, values := range repeats {
+		clone[k] = slices.Clone(values)
+	}
+	return clone
+}
+
+func cloneTraits(traits map[string]any) map[string]any {
+	clone := make(map[string]any, len(traits))
+	for k, v := range traits {
+		clone[k] = cloneTraitValue(v)
+	}
+	return clone
+}
+
+// cloneTraitValue copies the map and slice values produced by the parser and
+// encoding/json. Other values are returned as is.
+func cloneTraitValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		return cloneTraits(v)
+	case []any:
+		if v == nil {
+			return v
+		}
+		clone := make([]any, len(v))
+		for i, elem := range v {
+			clone[i] = cloneTraitValue(elem)
+		}
+		return clone
+	default:
+		return v
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScriptClone(t *testing.T) {
+	t.Parallel()
+
+	parse := func() zapscript.Script {
+		script, err := zapscript.NewParserWithOptions(
+			`**traits:{"data":{"tags":["a",{"x":1}]},"n":1}||**launch:'game',b?system=snes&launcher="x"`,
+			zapscript.WithKeepStyle(),
+		).ParseScript()
+		if err != nil {
+			t.Fatalf("ParseScript() unexpected error: %v", err)
+		}
+		return script
+	}
+	original := parse()
+	want := parse()
+
+	clone := original.Clone()
+	if diff := cmp.Diff(original, clone, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+		t.Fatalf("Clone() mismatch (-original +clone):\n%s", diff)
+	}
+
+	clone.Cmds[0].Args[0] = "other"
+	clone.Cmds[0].ArgStyles[0] = zapscript.QuoteStyleDouble
+	clone.Cmds[0].AdvArgs.Raw()["system"] = "nes"
+	clone.Cmds = append(clone.Cmds, zapscript.Command{Name: "stop"})
+	clone.Traits["n"] = 2.0
+	data, _ := clone.Traits["data"].(map[string]any)
+	data["new"] = true
+	tags, _ := data["tags"].([]any)
+	tags[0] = "changed"
+	nested, _ := tags[1].(map[string]any)
+	nested["x"] = 2.0
+
+	if diff := cmp.Diff(want, original, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+		t.Errorf("modifying the clone changed the original (-want +got):\n%s", diff)
+	}
+	if got := original.Cmds[0].AdvArgs.Style(zapscript.KeyLauncher); got != zapscript.QuoteStyleDouble {
+		t.Errorf("original AdvArgs.Style() = %v, want %v", got, zapscript.QuoteStyleDouble)
+	}
+}
+
+func TestCommandClone(t *testing.T) {
+	t.Parallel()
+
+	original := zapscript.Command{
+		Name:    "launch",
+		Args:    []string{"a", "b"},
+		AdvArgs: zapscript.NewAdvArgs(map[string]string{"system": "snes"}),
+	}
+
+	clone := original.Clone()
+	clone.Args[1] = "c"
+	clone.AdvArgs.Raw()["system"] = "nes"
+
+	if got := original.Args[1]; got != "b" {
+		t.Errorf("original Args[1] = %q, want %q", got, "b")
+	}
+	if got := original.AdvArgs.Get(zapscript.KeySystem); got != "snes" {
+		t.Errorf("original AdvArgs.Get(system) = %q, want %q", got, "snes")
+	}
+}
+
+func TestCloneZeroValues(t *testing.T) {
+	t.Parallel()
+
+	clone := zapscript.Script{}.Clone()
+	if clone.Traits != nil || clone.Cmds != nil || clone.Warnings != nil {
+		t.Errorf("Script{}.Clone() = %+v, want zero value", clone)
+	}
+	cmd := zapscript.Command{Name: "stop"}.Clone()
+	if cmd.Args != nil || !cmd.AdvArgs.IsZero() {
+		t.Errorf("Command{}.Clone() = %+v, want no args", cmd)
+	}
+}
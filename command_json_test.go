@@ -0,0 +1,344 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestCommandMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+		cmd  zapscript.Command
+	}{
+		{
+			name: "name only",
+			cmd:  zapscript.Command{Name: "stop"},
+			want: `{"name":"stop"}`,
+		},
+		{
+			name: "empty adv args omitted",
+			cmd:  zapscript.Command{Name: "stop", AdvArgs: zapscript.NewAdvArgs(map[string]string{})},
+			want: `{"name":"stop"}`,
+		},
+		{
+			name: "args and adv args",
+			cmd: zapscript.Command{
+				Name:    "launch",
+				Args:    []string{"snes/mario.sfc"},
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{"system": "snes"}),
+			},
+			want: `{"advArgs":{"system":"snes"},"name":"launch","args":["snes/mario.sfc"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := json.Marshal(tt.cmd)
+			if err != nil {
+				t.Fatalf("json.Marshal() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("json.Marshal() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScriptJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"**stop",
+		"**launch:snes/mario.sfc?system=snes&launcher=retroarch",
+		"**greet:hi,there||**delay:500||**stop",
+		`**launch:game?tags={"a":[1,2]}`,
+		`**launch:/roms/[[platform]]/game.bin?when=[[media_playing]]`,
+		"#name=mario #favorite||@snes/Super Mario World",
+		"**input.keyboard:ab{enter}",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.NewParser(input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+
+			data, err := json.Marshal(script)
+			if err != nil {
+				t.Fatalf("json.Marshal() unexpected error: %v", err)
+			}
+			var got zapscript.Script
+			if unmarshalErr := json.Unmarshal(data, &got); unmarshalErr != nil {
+				t.Fatalf("json.Unmarshal(%s) unexpected error: %v", data, unmarshalErr)
+			}
+
+			// whether an adv arg was written as JSON and where things were in
+			// the source are parser metadata and are not part of the JSON form
+			opts := cmp.Options{
+				cmp.AllowUnexported(zapscript.AdvArgs{}),
+				cmpopts.IgnoreFields(zapscript.AdvArgs{}, "json"),
+				cmpopts.IgnoreFields(zapscript.Command{}, "Raw", "Span"),
+				cmpopts.IgnoreFields(zapscript.Script{}, "TraitsSpans"),
+			}
+			if diff := cmp.Diff(script, got, opts); diff != "" {
+				t.Errorf("JSON round trip mismatch (-want +got):\n%s\njson=%s", diff, data)
+			}
+		})
+	}
+}
+
+func TestScriptMarshalJSONObject(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("#a=1||**launch:game?system=snes&system=nes")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(struct {
+		Script zapscript.Script `json:"script"`
+	}{script})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"script":{"traits":{"a":1},` +
+		`"cmds":[{"advArgs":{"system":"nes"},"name":"launch","args":["game"]}],` +
+		`"warnings":[{"code":"duplicate_adv_arg",` +
+		`"message":"adv arg \"system\" is set more than once, using the last value",` +
+		`"fragment":"system","pos":33,"cmdIndex":0}]}}`
+	if diff := cmp.Diff(want, string(data)); diff != "" {
+		t.Errorf("json.Marshal() mismatch (-want +got):\n%s", diff)
+	}
+
+	var got struct {
+		Script zapscript.Script `json:"script"`
+	}
+	if unmarshalErr := json.Unmarshal(data, &got); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal(%s) unexpected error: %v", data, unmarshalErr)
+	}
+	opts := cmp.Options{
+		cmp.AllowUnexported(zapscript.AdvArgs{}),
+		cmpopts.IgnoreFields(zapscript.Command{}, "Raw", "Span"),
+		cmpopts.IgnoreFields(zapscript.Script{}, "TraitsSpans"),
+	}
+	// JSON numbers decode as float64
+	script.Traits["a"] = float64(1)
+	if diff := cmp.Diff(script, got.Script, opts); diff != "" {
+		t.Errorf("json.Unmarshal() mismatch (-want +got):\n%s", diff)
+	}
+
+	empty, err := json.Marshal(zapscript.Script{})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if string(empty) != `{"cmds":[]}` {
+		t.Errorf("json.Marshal(Script{}) = %s, want {\"cmds\":[]}", empty)
+	}
+}
+
+func TestCommandJSONExpressions(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**cmd:[[var]],a^[[b]]?when=[[media_playing]]")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(script.Cmds[0])
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"advArgs":{"when":"[[media_playing]]"},"name":"cmd","args":["[[var]]","a^[[b]]"]}`
+	if diff := cmp.Diff(want, string(data)); diff != "" {
+		t.Errorf("json.Marshal() mismatch (-want +got):\n%s", diff)
+	}
+	if strings.ContainsAny(string(data), zapscript.TokExpStart+zapscript.TokExprEnd) {
+		t.Errorf("json.Marshal() = %s, contains expression tokens", data)
+	}
+
+	canonical, err := zapscript.CanonicalJSON(script)
+	if err != nil {
+		t.Fatalf("CanonicalJSON() unexpected error: %v", err)
+	}
+	if !strings.Contains(string(canonical), `"[[var]]"`) {
+		t.Errorf("CanonicalJSON() = %s, want it to contain [[var]]", canonical)
+	}
+
+	var got zapscript.Command
+	if unmarshalErr := json.Unmarshal(data, &got); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", unmarshalErr)
+	}
+	opts := cmp.Options{
+		cmp.AllowUnexported(zapscript.AdvArgs{}),
+		cmpopts.IgnoreFields(zapscript.Command{}, "Raw", "Span"),
+	}
+	if diff := cmp.Diff(script.Cmds[0], got, opts); diff != "" {
+		t.Errorf("JSON round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCommandUnmarshalJSONUnmatchedExpression(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{
+		`{"name":"cmd","args":["[[var"]}`,
+		`{"name":"cmd","advArgs":{"when":"[[var"}}`,
+	} {
+		var cmd zapscript.Command
+		if err := json.Unmarshal([]byte(input), &cmd); !errors.Is(err, zapscript.ErrUnmatchedExpression) {
+			t.Errorf("json.Unmarshal(%s) error = %v, want %v", input, err, zapscript.ErrUnmatchedExpression)
+		}
+	}
+}
+
+func TestAdvArgsUnmarshalJSONValueTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr  error
+		want     map[string]string
+		name     string
+		input    string
+		wantKeys []zapscript.Key
+	}{
+		{
+			name:     "string",
+			input:    `{"system":"snes","when":"[[x]]"}`,
+			want:     map[string]string{"system": "snes", "when": "\uE000x\uE001"},
+			wantKeys: []zapscript.Key{"system", "when"},
+		},
+		{
+			name:     "number",
+			input:    `{"slot":5,"delay":1.50,"big":1e3,"neg":-2}`,
+			want:     map[string]string{"slot": "5", "delay": "1.50", "big": "1e3", "neg": "-2"},
+			wantKeys: []zapscript.Key{"slot", "delay", "big", "neg"},
+		},
+		{
+			name:     "bool",
+			input:    `{"hidden":true,"verbose":false}`,
+			want:     map[string]string{"hidden": "true", "verbose": "false"},
+			wantKeys: []zapscript.Key{"hidden", "verbose"},
+		},
+		{
+			name:     "null value",
+			input:    `{"name":null,"system":"snes"}`,
+			want:     map[string]string{"name": "", "system": "snes"},
+			wantKeys: []zapscript.Key{"name", "system"},
+		},
+		{
+			name:    "object value",
+			input:   `{"system":"snes","data":{"a":1}}`,
+			wantErr: zapscript.ErrInvalidAdvArgValue,
+		},
+		{
+			name:    "array value",
+			input:   `{"tags":["a","b"]}`,
+			wantErr: zapscript.ErrInvalidAdvArgValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got zapscript.AdvArgs
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("json.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got.Raw()); diff != "" {
+				t.Errorf("json.Unmarshal() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantKeys, got.OrderedKeys()); diff != "" {
+				t.Errorf("OrderedKeys() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdvArgsUnmarshalJSONObjectValueError(t *testing.T) {
+	t.Parallel()
+
+	var cmd zapscript.Command
+	err := json.Unmarshal([]byte(`{"name":"launch","advArgs":{"data":{"a":1}}}`), &cmd)
+	if !errors.Is(err, zapscript.ErrInvalidAdvArgValue) {
+		t.Fatalf("json.Unmarshal() error = %v, want %v", err, zapscript.ErrInvalidAdvArgValue)
+	}
+	want := `adv arg "data": invalid adv arg value: got a JSON object, want a string, number or bool`
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("json.Unmarshal() error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestDetokenizeExpressions(t *testing.T) {
+	t.Parallel()
+
+	expr := func(s string) string { return zapscript.TokExpStart + s + zapscript.TokExprEnd }
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "plain", value: "a, b || c", want: "a, b || c"},
+		{name: "expression", value: expr("var"), want: "[[var]]"},
+		{name: "text around", value: "a " + expr("x") + " b", want: "a [[x]] b"},
+		{name: "literal brackets", value: "[[x]]", want: "^[[x]]"},
+		{name: "single bracket", value: "a[b]", want: "a[b]"},
+		{name: "bracket before expression", value: "[" + expr("x"), want: "^[[[x]]"},
+		{name: "caret", value: "2^3", want: "2^3"},
+		{name: "caret before caret", value: "a^^b", want: "a^^^b"},
+		{name: "caret before bracket", value: "a^[b", want: "a^^[b"},
+		{name: "caret before expression", value: "a^" + expr("x"), want: "a^^[[x]]"},
+		{name: "trailing caret", value: "a^", want: "a^"},
+		{name: "close inside string", value: expr(`a + "]]"`), want: `[[a + "]]"]]`},
+		{name: "index", value: expr("a[0]"), want: "[[a[0]]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := zapscript.DetokenizeExpressions(tt.value)
+			if got != tt.want {
+				t.Errorf("DetokenizeExpressions(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+			back, err := zapscript.TokenizeExpressions(got)
+			if err != nil {
+				t.Fatalf("TokenizeExpressions(%q) unexpected error: %v", got, err)
+			}
+			if back != tt.value {
+				t.Errorf("TokenizeExpressions(%q) = %q, want %q", got, back, tt.value)
+			}
+		})
+	}
+}
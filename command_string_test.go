@@ -246,3 +246,83 @@ func TestCommandString_RoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestScriptString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		want   string
+		script zapscript.Script
+	}{
+		{
+			name:   "empty",
+			script: zapscript.Script{},
+			want:   "",
+		},
+		{
+			name: "multiple commands",
+			script: zapscript.Script{Cmds: []zapscript.Command{
+				{Name: "launch", Args: []string{"snes/mario.sfc"}},
+				{Name: "delay", Args: []string{"500"}},
+				{Name: "say", Args: []string{"a|b"}},
+			}},
+			want: `**launch:snes/mario.sfc||**delay:500||**say:"a|b"`,
+		},
+		{
+			name: "traits first",
+			script: zapscript.Script{
+				Traits: map[string]any{"name": "mario", "level": int64(5), "url": "a&b<c>"},
+				Cmds:   []zapscript.Command{{Name: "stop"}},
+			},
+			want: "#level=5 #name=mario #url=a&b<c>||**stop",
+		},
+		{
+			name:   "traits only",
+			script: zapscript.Script{Traits: map[string]any{"tags": []any{"a", "b"}}},
+			want:   "#tags=[a,b]",
+		},
+		{
+			name: "trait values that need quoting",
+			script: zapscript.Script{Traits: map[string]any{
+				"title": "My Game", "code": "5", "flag": "true", "empty": "", "tags": []any{"a b", int64(1)},
+			}},
+			want: `#code="5" #empty="" #flag="true" #tags=["a b",1] #title="My Game"`,
+		},
+		{
+			name: "trait types",
+			script: zapscript.Script{Traits: map[string]any{
+				"on": true, "off": false, "n": int64(-3), "whole": float64(5), "rating": 4.5,
+			}},
+			want: "#n=-3 #off=false #on #rating=4.5 #whole=5.0",
+		},
+		{
+			name: "traits without shorthand",
+			script: zapscript.Script{
+				Traits: map[string]any{"name": "mario", "data": map[string]any{"x": 1.5}, "Caps": "x"},
+				Cmds:   []zapscript.Command{{Name: "stop"}},
+			},
+			want: `#name=mario||**traits:{"Caps":"x","data":{"x":1.5}}||**stop`,
+		},
+		{
+			name: "expressions",
+			script: zapscript.Script{Cmds: []zapscript.Command{{
+				Name: "launch",
+				Args: []string{"/roms/" + zapscript.TokExpStart + "platform" + zapscript.TokExprEnd + "/a, b"},
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{
+					"when": zapscript.TokExpStart + "media_playing" + zapscript.TokExprEnd,
+				}),
+			}}},
+			want: `**launch:"/roms/[[platform]]/a, b"?when=[[media_playing]]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if diff := cmp.Diff(tt.want, tt.script.String()); diff != "" {
+				t.Errorf("Script.String() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+//go:embed conformance/cases.json
+var conformanceCasesJSON []byte
+
+// ConformanceCase is a ZapScript input and the result every parser
+// implementation must produce for it, as generated from this package.
+type ConformanceCase struct {
+	Name  string `json:"name"`
+	Input string `json:"input"`
+	// Script is the expected CanonicalJSON output. It is empty if the input
+	// must fail.
+	Script json.RawMessage `json:"script,omitempty"`
+	// Error is the expected ErrorCode if the input must fail.
+	Error string `json:"error,omitempty"`
+}
+
+// ConformanceFailure is a ConformanceCase an implementation got wrong.
+type ConformanceFailure struct {
+	Reason string          `json:"reason"`
+	Case   ConformanceCase `json:"case"`
+}
+
+// ConformanceCases returns the grammar conformance cases. They cover every
+// syntax feature and documented fallback with the output of this package's
+// parser using default options.
+func ConformanceCases() []ConformanceCase {
+	var cases []ConformanceCase
+	if err := json.Unmarshal(conformanceCasesJSON, &cases); err != nil {
+		// the embedded file is checked by the package tests
+		panic(fmt.Sprintf("invalid embedded conformance cases: %v", err))
+	}
+	return cases
+}
+
+// CanonicalJSON returns the JSON form of a script used by the conformance
+// cases: {"cmds":[...],"traits":{...}} with commands in their JSON form and
+// traits omitted when empty. Warnings are not included.
+// Expressions are written as [[...]], see DetokenizeExpressions.
+func CanonicalJSON(s Script) ([]byte, error) {
+	doc := jsonScript{Cmds: s.Cmds, Traits: detokenizeTraits(s.Traits)}
+	if doc.Cmds == nil {
+		doc.Cmds = []Command{}
+	}
+	if len(doc.Traits) == 0 {
+		doc.Traits = nil
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal script: %w", err)
+	}
+	return b, nil
+}
+
+// VerifyConformance runs every conformance case through parse and returns
+// the cases it got wrong. parse must return the input's CanonicalJSON, or an
+// error whose message is the ErrorCode, so that bindings for other languages
+// can be driven from a Go test through cgo or a subprocess. JSON output is
+// compared by value, so key order and whitespace do not matter.
+func VerifyConformance(parse func(input string) ([]byte, error)) []ConformanceFailure {
+	var failures []ConformanceFailure
+	for _, c := range ConformanceCases() {
+		if reason := checkConformance(c, parse); reason != "" {
+			failures = append(failures, ConformanceFailure{Case: c, Reason: reason})
+		}
+	}
+	return failures
+}
+
+func checkConformance(c ConformanceCase, parse func(string) ([]byte, error)) string {
+	got, err := parse(c.Input)
+	switch {
+	case c.Error != "" && err == nil:
+		return fmt.Sprintf("expected error %q, got %s", c.Error, got)
+	case c.Error != "" && err.Error() != c.Error:
+		return fmt.Sprintf("expected error %q, got %q", c.Error, err.Error())
+	case c.Error != "":
+		return ""
+	case err != nil:
+		return fmt.Sprintf("unexpected error %q", err.Error())
+	}
+
+	var want, have any
+	if unmarshalErr := json.Unmarshal(c.Script, &want); unmarshalErr != nil {
+		return fmt.Sprintf("invalid expected script: %v", unmarshalErr)
+	}
+	if unmarshalErr := json.Unmarshal(got, &have); unmarshalErr != nil {
+		return fmt.Sprintf("output is not valid JSON: %v", unmarshalErr)
+	}
+	if !reflect.DeepEqual(want, have) {
+		return fmt.Sprintf("expected %s, got %s", c.Script, got)
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+var updateConformance = flag.Bool("update-conformance", false, "regenerate conformance/cases.json")
+
+func parseConformance(input string) ([]byte, error) {
+	script, err := zapscript.Parse(input)
+	if err != nil {
+		return nil, errors.New(zapscript.ErrorCode(err))
+	}
+	return zapscript.CanonicalJSON(script)
+}
+
+// TestConformanceCasesUpToDate regenerates the expected results from the
+// parser and fails if the embedded cases are stale. Run with
+// -update-conformance to rewrite them.
+func TestConformanceCasesUpToDate(t *testing.T) {
+	t.Parallel()
+
+	cases := zapscript.ConformanceCases()
+	regenerated := make([]zapscript.ConformanceCase, len(cases))
+	for i, c := range cases {
+		regenerated[i] = zapscript.ConformanceCase{Name: c.Name, Input: c.Input}
+		script, err := parseConformance(c.Input)
+		if err != nil {
+			if err.Error() == "" {
+				t.Fatalf("case %q failed with an error that has no code", c.Name)
+			}
+			regenerated[i].Error = err.Error()
+			continue
+		}
+		regenerated[i].Script = script
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(regenerated); err != nil {
+		t.Fatalf("encode cases: %v", err)
+	}
+
+	if *updateConformance {
+		if err := os.WriteFile("conformance/cases.json", buf.Bytes(), 0o600); err != nil {
+			t.Fatalf("write cases: %v", err)
+		}
+		return
+	}
+
+	current, err := os.ReadFile("conformance/cases.json")
+	if err != nil {
+		t.Fatalf("read cases: %v", err)
+	}
+	if diff := cmp.Diff(string(current), buf.String()); diff != "" {
+		t.Errorf("conformance/cases.json is stale, run go test -run TestConformanceCasesUpToDate "+
+			"-update-conformance (-current +regenerated):\n%s", diff)
+	}
+}
+
+func TestVerifyConformance(t *testing.T) {
+	t.Parallel()
+
+	if failures := zapscript.VerifyConformance(parseConformance); len(failures) > 0 {
+		for _, f := range failures {
+			t.Errorf("case %q: %s", f.Case.Name, f.Reason)
+		}
+	}
+}
+
+func TestVerifyConformanceReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	// a parser that ignores adv args must fail the cases that use them
+	broken := func(input string) ([]byte, error) {
+		script, err := zapscript.Parse(input)
+		if err != nil {
+			return nil, errors.New(zapscript.ErrorCode(err))
+		}
+		for i := range script.Cmds {
+			script.Cmds[i].AdvArgs = zapscript.AdvArgs{}
+		}
+		return zapscript.CanonicalJSON(script)
+	}
+	if failures := zapscript.VerifyConformance(broken); len(failures) == 0 {
+		t.Error("VerifyConformance() found no failures for a parser that drops adv args")
+	}
+
+	alwaysFails := func(string) ([]byte, error) { return nil, errors.New("nope") }
+	failures := zapscript.VerifyConformance(alwaysFails)
+	if len(failures) != len(zapscript.ConformanceCases()) {
+		t.Errorf("VerifyConformance() = %d failures, want one per case (%d)",
+			len(failures), len(zapscript.ConformanceCases()))
+	}
+}
+
+func TestConformanceCasesCoverFeatures(t *testing.T) {
+	t.Parallel()
+
+	var hasError, hasScript bool
+	names := make(map[string]bool)
+	for _, c := range zapscript.ConformanceCases() {
+		if names[c.Name] {
+			t.Errorf("duplicate case name %q", c.Name)
+		}
+		names[c.Name] = true
+		hasError = hasError || c.Error != ""
+		hasScript = hasScript || len(c.Script) > 0
+	}
+	if !hasError || !hasScript {
+		t.Errorf("cases must include both successful and failing inputs")
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: nil, want: ""},
+		{err: errors.New("other"), want: ""},
+		{err: zapscript.ErrUnmatchedQuote, want: "unmatched_quote"},
+		{err: zapscript.ErrWhitespaceOnlyZapScript, want: "whitespace_only_script"},
+		{err: zapscript.ErrEmptyZapScript, want: "empty_script"},
+		{
+			err:  &zapscript.ParseError{Err: zapscript.ErrInvalidJSON, CmdIndex: 1},
+			want: "invalid_json",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := zapscript.ErrorCode(tt.err); got != tt.want {
+			t.Errorf("ErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DisplayEllipsis is appended by TruncateDisplay when text is shortened.
+const DisplayEllipsis = "…"
+
+// TruncateDisplay shortens s to at most maxLen runes, including a trailing
+// DisplayEllipsis, for display surfaces with a character budget (see
+// KeyMaxLen). It never cuts inside an unresolved expression token pair: if
+// the cut would land inside one, the whole expression is dropped instead.
+// A maxLen of zero or less returns an empty string.
+func TruncateDisplay(s string, maxLen int) string {
+	if maxLen <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+
+	runes := []rune(s)
+	exprStart, _ := utf8.DecodeRuneInString(TokExpStart)
+	exprEnd, _ := utf8.DecodeRuneInString(TokExprEnd)
+
+	cut := maxLen - 1
+	open := -1
+	for i := range cut {
+		switch runes[i] {
+		case exprStart:
+			open = i
+		case exprEnd:
+			open = -1
+		}
+	}
+	if open != -1 {
+		cut = open
+	}
+
+	return string(runes[:cut]) + DisplayEllipsis
+}
+
+// Dump returns a readable multi-line rendering of the script for debugging
+// and support, one numbered command per line followed by its indexed args
+// and its adv args as aligned key = value pairs, with traits at the end:
+//
+//  1. launch.title
+//     arg[0]: snes/Mario
+//     launcher = retroarch
+//     traits:
+//     favorite = true
+//
+// Expressions are shown as [[...]], adv args and traits are sorted by key,
+// arg values that are empty or contain unprintable characters are shown
+// quoted, and trait values are shown as JSON. The output is deterministic
+// but is not ZapScript and cannot be parsed.
+func (s Script) Dump() string {
+	var lines []string
+
+	for i, cmd := range s.Cmds {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, cmd.Name))
+		for j, arg := range cmd.Args {
+			lines = append(lines, fmt.Sprintf("   arg[%d]: %s", j, dumpValue(arg)))
+		}
+
+		keys := make([]string, 0)
+		values := make(map[string]string)
+		cmd.AdvArgs.Range(func(key Key, value string) bool {
+			keys = append(keys, string(key))
+			values[string(key)] = dumpValue(value)
+			return true
+		})
+		lines = appendAligned(lines, keys, values)
+	}
+
+	if len(s.Traits) > 0 {
+		lines = append(lines, "traits:")
+		keys := make([]string, 0, len(s.Traits))
+		values := make(map[string]string, len(s.Traits))
+		for k, v := range s.Traits {
+			keys = append(keys, k)
+			values[k] = dumpTrait(detokenizeTraitValue(v))
+		}
+		lines = appendAligned(lines, keys, values)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// appendAligned appends an indented key = value line for each key in sorted
+// order, padding the keys to the same width.
+func appendAligned(lines, keys []string, values map[string]string) []string {
+	sort.Strings(keys)
+	width := 0
+	for _, k := range keys {
+		width = max(width, utf8.RuneCountInString(k))
+	}
+	for _, k := range keys {
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(k))
+		lines = append(lines, fmt.Sprintf("   %s%s = %s", k, pad, values[k]))
+	}
+	return lines
+}
+
+// dumpValue renders an arg or adv arg value with expressions as [[...]].
+func dumpValue(value string) string {
+	var b strings.Builder
+	for _, part := range SplitArgParts(value) {
+		if part.Type == ArgPartTypeExpression {
+			writeExpression(&b, part.Value)
+		} else {
+			_, _ = b.WriteString(part.Value)
+		}
+	}
+	rendered := b.String()
+
+	if rendered == "" || strings.TrimSpace(rendered) != rendered ||
+		strings.IndexFunc(rendered, func(r rune) bool { return !unicode.IsPrint(r) }) != -1 {
+		return strconv.Quote(rendered)
+	}
+	return rendered
+}
+
+func dumpTrait(v any) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
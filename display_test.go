@@ -0,0 +1,160 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTruncateDisplay(t *testing.T) {
+	t.Parallel()
+
+	expr := zapscript.TokExpStart + "active_media.name" + zapscript.TokExprEnd
+
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		maxLen int
+	}{
+		{name: "fits", input: "hello", maxLen: 5, want: "hello"},
+		{name: "truncated", input: "hello world", maxLen: 6, want: "hello…"},
+		{name: "zero budget", input: "hello", maxLen: 0, want: ""},
+		{name: "negative budget", input: "hello", maxLen: -1, want: ""},
+		{name: "budget of one", input: "hello", maxLen: 1, want: "…"},
+		{name: "empty input", input: "", maxLen: 3, want: ""},
+		{name: "multi-byte runes fit", input: "héllo", maxLen: 5, want: "héllo"},
+		{name: "multi-byte rune at boundary", input: "ab日本語", maxLen: 4, want: "ab日…"},
+		{name: "emoji at boundary", input: "go🎮🎮🎮", maxLen: 4, want: "go🎮…"},
+		{
+			name:   "expression before cut kept",
+			input:  "Now: " + expr + " and more text",
+			maxLen: 26,
+			want:   "Now: " + expr + " …",
+		},
+		{
+			name:   "expression straddling cut dropped",
+			input:  "Now: " + expr + " and more text",
+			maxLen: 12,
+			want:   "Now: …",
+		},
+		{
+			name:   "cut on closing token drops expression",
+			input:  "a" + expr + "b",
+			maxLen: 20,
+			want:   "a…",
+		},
+		{
+			name:   "cut right after closing token keeps expression",
+			input:  "a" + expr + "bc",
+			maxLen: 21,
+			want:   "a" + expr + "…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := zapscript.TruncateDisplay(tt.input, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("TruncateDisplay(%q, %d) = %q, want %q", tt.input, tt.maxLen, got, tt.want)
+			}
+			if tt.maxLen > 0 && utf8.RuneCountInString(got) > tt.maxLen {
+				t.Errorf("TruncateDisplay(%q, %d) = %q exceeds budget", tt.input, tt.maxLen, got)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("TruncateDisplay(%q, %d) = %q is not valid UTF-8", tt.input, tt.maxLen, got)
+			}
+		})
+	}
+}
+
+func TestScriptDump(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "media title with adv args",
+			input: "@snes/Mario?system=snes&launcher=retroarch",
+			want: "1. launch.title\n" +
+				"   arg[0]: snes/Mario\n" +
+				"   launcher = retroarch\n" +
+				"   system   = snes",
+		},
+		{
+			name:  "several commands and traits",
+			input: `#name="My Game" #level=5 #tags=[a,b]||**greet:hi,"",there||**delay:500`,
+			want: "1. greet\n" +
+				"   arg[0]: hi\n" +
+				"   arg[1]: \"\"\n" +
+				"   arg[2]: there\n" +
+				"2. delay\n" +
+				"   arg[0]: 500\n" +
+				"traits:\n" +
+				"   level = 5\n" +
+				"   name  = \"My Game\"\n" +
+				"   tags  = [\"a\",\"b\"]",
+		},
+		{
+			name:  "expressions",
+			input: "**launch:/roms/[[platform]]/game?when=[[media_playing]]",
+			want: "1. launch\n" +
+				"   arg[0]: /roms/[[platform]]/game\n" +
+				"   when = [[media_playing]]",
+		},
+		{
+			name:  "trait expressions",
+			input: "#d=[[x]] #e=[a,[[y]]]||**stop",
+			want: "1. stop\n" +
+				"traits:\n" +
+				"   d = \"[[x]]\"\n" +
+				"   e = [\"a\",\"[[y]]\"]",
+		},
+		{
+			name:  "unprintable values are quoted",
+			input: "**echo:a^nb",
+			want: "1. echo\n" +
+				"   arg[0]: \"a\\nb\"",
+		},
+		{
+			name:  "no args",
+			input: "**stop",
+			want:  "1. stop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, script.Dump()); diff != "" {
+				t.Errorf("Dump() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TraitsCmdIndex is the ParseError.CmdIndex reported for errors in a traits
+// segment, since traits are merged into Script.Traits rather than producing
+// a command.
+const TraitsCmdIndex = -1
+
+// ParseError describes where ParseScript failed. It wraps the underlying
+// error, so errors.Is still matches the sentinel errors.
+type ParseError struct {
+	Err error
+	// Pos is the rune position in the input where the error was detected.
+	Pos int64
+	// CmdName is the name of the failing command as far as it was parsed.
+	// Auto-launch and media title commands report the command they produce.
+	// It is empty for traits or if no name was read.
+	CmdName string
+	// CmdIndex is the index in Script.Cmds the failing command would have
+	// had. Every ||-separated command counts, including auto-launch and media
+	// title commands; traits segments report TraitsCmdIndex.
+	CmdIndex int
+}
+
+// Error formats the error with a 1-based command number, e.g.
+// "parse error at 17: command 2 (bad): unmatched quote".
+func (e *ParseError) Error() string {
+	switch {
+	case e.CmdIndex == TraitsCmdIndex:
+		return fmt.Sprintf("parse error at %d: traits: %v", e.Pos, e.Err)
+	case e.CmdName == "":
+		return fmt.Sprintf("parse error at %d: command %d: %v", e.Pos, e.CmdIndex+1, e.Err)
+	default:
+		return fmt.Sprintf("parse error at %d: command %d (%s): %v", e.Pos, e.CmdIndex+1, e.CmdName, e.Err)
+	}
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ExpressionError is an invalid expression in a parsed script, reported by
+// ValidateExpressions.
+type ExpressionError struct {
+	Err error
+	// Expression is the expression text, without the [[ ]] brackets.
+	Expression string
+	// CmdName is the name of the command the expression is in.
+	CmdName string
+	// AdvArg is the adv arg the expression is in, or empty if it is in the
+	// positional arg Args[ArgIndex].
+	AdvArg Key
+	// CmdIndex is the index in Script.Cmds of the command.
+	CmdIndex int
+	// ArgIndex is the index in Command.Args of the arg, if AdvArg is empty.
+	ArgIndex int
+}
+
+// Error formats the error with 1-based command and arg numbers, e.g.
+// "expression [[platfrom]] in command 1 (launch) arg 1: unknown name platfrom".
+func (e *ExpressionError) Error() string {
+	where := fmt.Sprintf("arg %d", e.ArgIndex+1)
+	if e.AdvArg != "" {
+		where = fmt.Sprintf("adv arg %s", e.AdvArg)
+	}
+	return fmt.Sprintf("expression [[%s]] in command %d (%s) %s: %v",
+		e.Expression, e.CmdIndex+1, e.CmdName, where, e.Err)
+}
+
+func (e *ExpressionError) Unwrap() error {
+	return e.Err
+}
+
+// EvalError is an expression that failed to evaluate, returned by the
+// EvalExpressions functions and Command.ShouldRun. It wraps the underlying
+// error, so errors.Is still matches the sentinel errors.
+type EvalError struct {
+	Err error
+	// Expr is the expression text, without the [[ ]] brackets, or empty if
+	// the value failed before any expression was evaluated.
+	Expr string
+	// CmdName is the name of the command the expression is in, if known.
+	CmdName string
+	// AdvArgKey is the adv arg the expression is in, or empty if it is in
+	// the positional arg Args[ArgIndex] or not in a command.
+	AdvArgKey Key
+	// CmdIndex is the index in Script.Cmds of the command, or -1 if the
+	// value was not evaluated as part of a script.
+	CmdIndex int
+	// ArgIndex is the index in Command.Args of the arg, or -1 if the
+	// expression is not in a positional arg.
+	ArgIndex int
+}
+
+// Error formats the error with 1-based command and arg numbers, e.g.
+// "command 1 (launch) arg 2: failed to evaluate expression "x": unknown name x".
+func (e *EvalError) Error() string {
+	msg := fmt.Sprint(e.Err)
+	if e.Expr != "" {
+		msg = fmt.Sprintf("failed to evaluate expression %q: %v", e.Expr, e.Err)
+	}
+
+	var where []string
+	switch {
+	case e.CmdIndex >= 0:
+		where = append(where, fmt.Sprintf("command %d (%s)", e.CmdIndex+1, e.CmdName))
+	case e.CmdName != "":
+		where = append(where, fmt.Sprintf("command %s", e.CmdName))
+	}
+	switch {
+	case e.AdvArgKey != "":
+		where = append(where, fmt.Sprintf("adv arg %s", e.AdvArgKey))
+	case e.ArgIndex >= 0:
+		where = append(where, fmt.Sprintf("arg %d", e.ArgIndex+1))
+	}
+	if len(where) == 0 {
+		return msg
+	}
+	return strings.Join(where, " ") + ": " + msg
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownKeysError lists the adv args DecodeAdvArgs found no field for. It
+// wraps ErrUnknownAdvArg.
+type UnknownKeysError struct {
+	// CmdName is the name of the decoded command.
+	CmdName string
+	// Keys are the unknown keys in OrderedKeys order.
+	Keys []Key
+}
+
+// Error formats the error, e.g. `command launch: unknown adv arg "foo", "bar"`.
+func (e *UnknownKeysError) Error() string {
+	quoted := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		quoted[i] = strconv.Quote(string(k))
+	}
+	return fmt.Sprintf("command %s: %v %s", e.CmdName, ErrUnknownAdvArg, strings.Join(quoted, ", "))
+}
+
+func (e *UnknownKeysError) Unwrap() error {
+	return ErrUnknownAdvArg
+}
+
+// AdvArgError is an adv arg value that fails a validate tag rule, reported
+// by ValidateArgs. It wraps ErrInvalidAdvArgValue.
+type AdvArgError struct {
+	// Key is the adv arg key from the field's advarg tag.
+	Key Key
+	// Value is the rejected value.
+	Value string
+	// Rule is the failed rule, such as "oneof=run details" or "launcher".
+	Rule string
+}
+
+// Error formats the error, e.g.
+// `adv arg "action" is "play", want one of run, details`.
+func (e *AdvArgError) Error() string {
+	if options, ok := strings.CutPrefix(e.Rule, "oneof="); ok {
+		return fmt.Sprintf("adv arg %q is %q, want one of %s",
+			e.Key, e.Value, strings.Join(strings.Fields(options), ", "))
+	}
+	return fmt.Sprintf("adv arg %q is %q, want a valid %s", e.Key, e.Value, e.Rule)
+}
+
+func (e *AdvArgError) Unwrap() error {
+	return ErrInvalidAdvArgValue
+}
+
+// locateEvalError returns err as an *EvalError in the given command and arg
+// or adv arg, keeping the expression of an *EvalError it wraps.
+func locateEvalError(err error, cmdIndex int, cmdName string, argIndex int, advArg Key) *EvalError {
+	located := &EvalError{
+		Err:       err,
+		CmdName:   cmdName,
+		AdvArgKey: advArg,
+		CmdIndex:  cmdIndex,
+		ArgIndex:  argIndex,
+	}
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) {
+		located.Err, located.Expr = evalErr.Err, evalErr.Expr
+	}
+	return located
+}
+
+// errorCodes maps sentinel errors to stable codes. Wrapping errors are listed
+// before the errors they wrap so the most specific code wins.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrWhitespaceOnlyZapScript, "whitespace_only_script"},
+	{ErrEmptyZapScript, "empty_script"},
+	{ErrUnexpectedEOF, "unexpected_eof"},
+	{ErrInvalidCmdName, "invalid_cmd_name"},
+	{ErrInvalidAdvArgName, "invalid_adv_arg_name"},
+	{ErrEmptyCmdName, "empty_cmd_name"},
+	{ErrReservedCommandName, "reserved_cmd_name"},
+	{ErrUnmatchedQuote, "unmatched_quote"},
+	{ErrInvalidJSON, "invalid_json"},
+	{ErrUnmatchedInputMacroExt, "unmatched_input_macro_ext"},
+	{ErrUnmatchedExpression, "unmatched_expression"},
+	{ErrBadExpressionReturn, "bad_expression_return"},
+	{ErrPathEscapesSandbox, "path_escapes_sandbox"},
+	{ErrInvalidExprFunction, "invalid_expr_function"},
+	{ErrInvalidTraitKey, "invalid_trait_key"},
+	{ErrUnmatchedArrayBracket, "unmatched_array_bracket"},
+	{ErrTrailingAfterQuote, "trailing_after_quote"},
+	{ErrInvalidEncoding, "invalid_encoding"},
+	{ErrDuplicateAdvArg, "duplicate_adv_arg"},
+	{ErrDuplicateTraitKey, "duplicate_trait_key"},
+	{ErrExpressionsDisabled, "expressions_disabled"},
+	{ErrFieldNotAllowed, "field_not_allowed"},
+	{ErrUnknownVariable, "unknown_variable"},
+	{ErrInvalidAdvArgBool, "invalid_adv_arg_bool"},
+	{ErrAdvArgMissing, "adv_arg_missing"},
+	{ErrInvalidAdvArgNumber, "invalid_adv_arg_number"},
+	{ErrInvalidAdvArgDuration, "invalid_adv_arg_duration"},
+	{ErrUnknownAdvArg, "unknown_adv_arg"},
+	{ErrInvalidDecodeTarget, "invalid_decode_target"},
+	{ErrInvalidEncodeSource, "invalid_encode_source"},
+	{ErrInvalidAdvArgValue, "invalid_adv_arg_value"},
+	{ErrAdvArgConflict, "adv_arg_conflict"},
+	{ErrInvalidPercentEscape, "invalid_percent_escape"},
+	{ErrAutoLaunchDisabled, "auto_launch_disabled"},
+	{ErrInvalidUnicodeEscape, "invalid_unicode_escape"},
+	{ErrScriptTooLarge, "script_too_large"},
+	{ErrTooManyArgs, "too_many_args"},
+	{ErrTooManyCommands, "too_many_commands"},
+	{ErrAdvArgTooLarge, "adv_arg_too_large"},
+	{ErrInputMacroRepeatTooLarge, "input_macro_repeat_too_large"},
+	{ErrInputMacroTooLong, "input_macro_too_long"},
+	{ErrInputMacroEmptyKey, "input_macro_empty_key"},
+}
+
+// ErrorCode returns a stable, language-neutral code for the package error
+// err wraps, such as "unmatched_quote", or an empty string if it wraps none.
+func ErrorCode(err error) string {
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"strings"
+)
+
+// SplitEscaped splits s on sep with parseArgs, the code the parser reads
+// positional args with, so the rules are exactly those of args: ^ escape
+// sequences are resolved, a part starting with a single or double quote is
+// read up to the matching quote, a part starting with { is read as a JSON
+// object, and [[...]] expression regions are kept intact even if they
+// contain sep. Parts are trimmed of surrounding whitespace and a trailing
+// empty part is dropped unless it was explicitly quoted. ? and || are plain
+// text. An empty string yields no parts.
+//
+// opts are parser options. WithDisableExpressions reads [[ as plain text,
+// so a sep inside it splits. Input and arg count limits do not apply.
+// Expressions are returned as [[...]] text, not as expression tokens.
+func SplitEscaped(s string, sep rune, opts ...Option) ([]string, error) {
+	opts = append(opts, WithMaxInputRunes(0), WithMaxArgs(0))
+	sr := NewParserWithOptions(s, opts...)
+	sr.splitSep = sep
+	args, _, err := sr.parseArgs("", false, false)
+	if err != nil {
+		return nil, err
+	}
+	for i, arg := range args {
+		args[i] = exprTokenText.Replace(arg)
+	}
+	return args, nil
+}
+
+// exprTokenText writes expression tokens back as the [[...]] source text.
+var exprTokenText = strings.NewReplacer(
+	TokExpStart, string([]rune{SymExpressionStart, SymExpressionStart}),
+	TokExprEnd, string([]rune{SymExpressionEnd, SymExpressionEnd}),
+)
+
+// QuoteStyle is how an arg or adv arg value was written in the source, see
+// Options.KeepStyle.
+type QuoteStyle int
+
+const (
+	// QuoteStyleAuto quotes the value only if needed. It is used for values
+	// with no recorded style, such as ones added after parsing.
+	QuoteStyleAuto QuoteStyle = iota
+	// QuoteStyleNone is an unquoted value.
+	QuoteStyleNone
+	// QuoteStyleSingle is a value in single quotes.
+	QuoteStyleSingle
+	// QuoteStyleDouble is a value in double quotes.
+	QuoteStyleDouble
+	// QuoteStyleJSON is a JSON object value.
+	QuoteStyleJSON
+)
+
+// QuotePreference is how Command.StringWithOptions and
+// Script.StringWithOptions write values that contain special characters.
+// It applies to values without a recorded QuoteStyle; recorded styles are
+// always reproduced.
+type QuotePreference int
+
+const (
+	// QuotePreferQuotes double-quotes values that need quoting, as String
+	// does, e.g. "https://x.org/?a=1&b=2".
+	QuotePreferQuotes QuotePreference = iota
+	// QuotePreferEscapes writes values unquoted with ^-escapes, e.g.
+	// C:\games\a^,b.rom. Empty values are still written as "".
+	QuotePreferEscapes
+	// QuotePreferAuto writes each value in whichever of the two forms is
+	// shorter, preferring escapes on a tie.
+	QuotePreferAuto
+)
+
+// quoteStyleOf returns the style of a value opened with the quote character.
+func quoteStyleOf(quote rune) QuoteStyle {
+	if quote == SymArgSingleQuote {
+		return QuoteStyleSingle
+	}
+	return QuoteStyleDouble
+}
+
+// QuoteArg returns s as a double-quoted ZapScript value that is always safe as
+// a single positional arg, e.g. "**launch:" + QuoteArg(path). Quotes, carets,
+// control characters and expression brackets are ^-escaped. The parser trims
+// surrounding whitespace from args, so it is not preserved.
+func QuoteArg(s string) string {
+	var b strings.Builder
+	_, _ = b.WriteRune(SymArgDoubleQuote)
+	writeEscaped(&b, s, SymArgDoubleQuote)
+	_, _ = b.WriteRune(SymArgDoubleQuote)
+	return b.String()
+}
+
+// EscapeAdvArgValue returns s in a form that is safe as an adv arg value,
+// e.g. "?name=" + EscapeAdvArgValue(name). Values containing separators such
+// as & or | are quoted with QuoteArg; other values are returned unchanged.
+func EscapeAdvArgValue(s string) string {
+	if s == "" || argNeedsQuoting(s) {
+		return QuoteArg(s)
+	}
+	return s
+}
+
+// UnescapeOnce removes one level of escaping from a value that was escaped
+// twice, such as an arg read from a tag written by a buggy encoder. If s is
+// wrapped in double quotes they are removed, then each ^ escape sequence is
+// resolved. A trailing lone ^ is kept.
+func UnescapeOnce(s string) string {
+	runes := []rune(s)
+	if len(runes) >= 2 && runes[0] == SymArgDoubleQuote && runes[len(runes)-1] == SymArgDoubleQuote {
+		// the closing quote only counts if it is not itself escaped
+		carets := 0
+		for i := len(runes) - 2; i > 0 && runes[i] == SymEscapeSeq; i-- {
+			carets++
+		}
+		if carets%2 == 0 {
+			runes = runes[1 : len(runes)-1]
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == SymEscapeSeq && i+1 < len(runes) {
+			i++
+			_, _ = b.WriteString(resolveEscape(runes[i]))
+			continue
+		}
+		_, _ = b.WriteRune(runes[i])
+	}
+	return b.String()
+}
+
+// resolveEscape returns the text produced by the escape sequence ^ch.
+func resolveEscape(ch rune) string {
+	switch ch {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	default:
+		return string(ch)
+	}
+}
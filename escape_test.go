@@ -0,0 +1,282 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitEscaped(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		want    []string
+		opts    []zapscript.Option
+		sep     rune
+	}{
+		{name: "empty", input: "", sep: ',', want: []string{}},
+		{name: "plain", input: "a,b,c", sep: ',', want: []string{"a", "b", "c"}},
+		{name: "trims parts", input: " a , b ", sep: ',', want: []string{"a", "b"}},
+		{name: "empty middle part", input: "a,,b", sep: ',', want: []string{"a", "", "b"}},
+		{name: "trailing separator dropped", input: "a,b,", sep: ',', want: []string{"a", "b"}},
+		{name: "double quoted separator", input: `"a,b",c`, sep: ',', want: []string{"a,b", "c"}},
+		{name: "single quoted separator", input: `'a,b',c`, sep: ',', want: []string{"a,b", "c"}},
+		{name: "quoted empty part kept", input: `a,""`, sep: ',', want: []string{"a", ""}},
+		{name: "quote not at part start is literal", input: `a"b,c"`, sep: ',', want: []string{`a"b`, `c"`}},
+		{name: "escaped separator", input: "a^,b,c", sep: ',', want: []string{"a,b", "c"}},
+		{name: "escaped quote in quotes", input: `"a^"b",c`, sep: ',', want: []string{`a"b`, "c"}},
+		{name: "escape sequences", input: "a^nb^^", sep: ',', want: []string{"a\nb^"}},
+		{name: "trailing caret", input: "a^", sep: ',', want: []string{"a^"}},
+		{
+			name:  "expression containing separator",
+			input: `[[join(x, ",")]],b`,
+			sep:   ',',
+			want:  []string{`[[join(x, ",")]]`, "b"},
+		},
+		{
+			name:  "expression inside quotes",
+			input: `"a [[x,y]]",b`,
+			sep:   ',',
+			want:  []string{"a [[x,y]]", "b"},
+		},
+		{
+			name:  "expression containing close in string",
+			input: `[[replace(x, "]],", "")]],b`,
+			sep:   ',',
+			want:  []string{`[[replace(x, "]],", "")]]`, "b"},
+		},
+		{name: "single bracket is literal", input: "[a,b]", sep: ',', want: []string{"[a", "b]"}},
+		{
+			name:  "expressions disabled",
+			input: "[[a,b]],c",
+			sep:   ',',
+			opts:  []zapscript.Option{zapscript.WithDisableExpressions()},
+			want:  []string{"[[a", "b]]", "c"},
+		},
+		{name: "JSON part", input: `{"a":1,"b":[2,3]},c`, sep: ',', want: []string{`{"a":1,"b":[2,3]}`, "c"}},
+		{name: "adv args and separators are text", input: "a?b=1,c||d", sep: ',', want: []string{"a?b=1", "c||d"}},
+		{name: "unicode escape", input: "^u{41},b", sep: ',', want: []string{"A", "b"}},
+		{name: "other separator", input: "a|b^|c", sep: '|', want: []string{"a", "b|c"}},
+		{name: "unmatched quote", input: `"a,b`, sep: ',', wantErr: zapscript.ErrUnmatchedQuote},
+		{name: "unmatched expression", input: "[[a,b", sep: ',', wantErr: zapscript.ErrUnmatchedExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.SplitEscaped(tt.input, tt.sep, tt.opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SplitEscaped() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SplitEscaped() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SplitEscaped() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestSplitEscapedMatchesParseArgs checks SplitEscaped against the args the
+// parser reads, with expression tokens written back as [[...]].
+func TestSplitEscapedMatchesParseArgs(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"a,b,c",
+		" a , b ",
+		"a,,b",
+		"a,b,",
+		`"a,b",c`,
+		`'a,b', c`,
+		`a,""`,
+		"a^,b,c",
+		`"a^"b",c`,
+		"a^nb^^",
+		`a"b,c"`,
+		`[[join(x, ",")]],b`,
+		`"a [[x,y]]",b`,
+		`x[[1]]^[[y,z`,
+		`{"a":1,"b":[2,3]},c`,
+		"^u{2192},b",
+		`"a"b,c`,
+	}
+	tokens := strings.NewReplacer(zapscript.TokExpStart, "[[", zapscript.TokExprEnd, "]]")
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser("**cmd:" + input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			want := []string{}
+			for _, arg := range script.Cmds[0].Args {
+				want = append(want, tokens.Replace(arg))
+			}
+
+			got, err := zapscript.SplitEscaped(input, ',')
+			if err != nil {
+				t.Fatalf("SplitEscaped() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("SplitEscaped() differs from parser (-parser +split):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: "mario.sfc", want: `"mario.sfc"`},
+		{name: "empty", input: "", want: `""`},
+		{name: "comma", input: "a,b", want: `"a,b"`},
+		{name: "quotes", input: `say "hi"`, want: `"say ^"hi^""`},
+		{name: "caret", input: "2^3", want: `"2^^3"`},
+		{name: "expression brackets", input: "[[x]]", want: `"^[^[x]]"`},
+		{name: "newline", input: "a\nb", want: `"a^nb"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := zapscript.QuoteArg(tt.input); got != tt.want {
+				t.Errorf("QuoteArg(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeAdvArgValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain unchanged", input: "snes", want: "snes"},
+		{name: "empty", input: "", want: `""`},
+		{name: "ampersand", input: "a&b=c", want: `"a&b=c"`},
+		{name: "pipes", input: "x||y", want: `"x||y"`},
+		{name: "caret", input: "^", want: `"^^"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := zapscript.EscapeAdvArgValue(tt.input); got != tt.want {
+				t.Errorf("EscapeAdvArgValue(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestQuoteHelpersRoundTrip builds scripts by concatenation and checks the
+// parser returns exactly the original strings.
+func TestQuoteHelpersRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	values := []string{
+		"/games/snes/Super Mario World (USA).sfc",
+		"C:\\Games\\Doom, Ultimate.wad",
+		"a||**stop",
+		"x|y&z=1?q",
+		`it's "quoted"`,
+		"^^^",
+		"[[platform]]",
+		"[[unclosed",
+		"{\"json\":true}",
+		"#trait=1",
+		"**launch:other",
+		"@snes/Title",
+		"line1\nline2\ttab\rcr",
+		"日本語 🎮", //nolint:gosmopolitan // unicode test case
+		"",
+	}
+
+	for _, value := range values {
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+
+			input := "**launch:" + zapscript.QuoteArg(value) + "," + zapscript.QuoteArg("second") +
+				"?name=" + zapscript.EscapeAdvArgValue(value) + "&system=" + zapscript.EscapeAdvArgValue("snes")
+			script, err := zapscript.NewParser(input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript(%q) unexpected error: %v", input, err)
+			}
+			if len(script.Cmds) != 1 {
+				t.Fatalf("ParseScript(%q) got %d commands, want 1", input, len(script.Cmds))
+			}
+
+			cmd := script.Cmds[0]
+			if diff := cmp.Diff([]string{value, "second"}, cmd.Args); diff != "" {
+				t.Errorf("args mismatch (-want +got):\n%s\ninput=%q", diff, input)
+			}
+			want := map[string]string{"name": value, "system": "snes"}
+			if diff := cmp.Diff(want, cmd.AdvArgs.Raw()); diff != "" {
+				t.Errorf("adv args mismatch (-want +got):\n%s\ninput=%q", diff, input)
+			}
+		})
+	}
+}
+
+func TestUnescapeOnce(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: "mario", want: "mario"},
+		{name: "escapes", input: "a^,b^^c^n", want: "a,b^c\n"},
+		{name: "quoted", input: `"a^"b"`, want: `a"b`},
+		{name: "escaped closing quote kept", input: `"a^"`, want: `"a"`},
+		{name: "escaped caret before closing quote", input: `"a^^"`, want: "a^"},
+		{name: "trailing caret", input: "a^", want: "a^"},
+		{name: "lone quote", input: `"`, want: `"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := zapscript.UnescapeOnce(tt.input); got != tt.want {
+				t.Errorf("UnescapeOnce(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
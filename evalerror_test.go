@@ -0,0 +1,155 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	zapscript "github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestEvalError(t *testing.T) {
+	t.Parallel()
+	env := map[string]any{"name": "mario", "items": []any{1, 2}}
+
+	scriptErr := func(input string) func() error {
+		return func() error {
+			script, err := zapscript.NewParser(input).ParseScript()
+			if err != nil {
+				return err
+			}
+			_, err = script.EvalExpressions(env)
+			return err
+		}
+	}
+	whenErr := func(input string) func() error {
+		return func() error {
+			script, err := zapscript.NewParser(input).ParseScript()
+			if err != nil {
+				return err
+			}
+			_, err = script.Cmds[0].ShouldRun(env)
+			return err
+		}
+	}
+
+	tests := []struct {
+		wantIs  error
+		eval    func() error
+		name    string
+		wantMsg string
+		want    zapscript.EvalError
+	}{
+		{
+			name:   "arg",
+			eval:   scriptErr("**echo:ok||**echo:a,[[platform]]"),
+			wantIs: zapscript.ErrUnknownVariable,
+			want: zapscript.EvalError{
+				CmdIndex: 1,
+				CmdName:  "echo",
+				ArgIndex: 1,
+				Expr:     "platform",
+			},
+			wantMsg: `command 2 (echo) arg 2: failed to evaluate expression "platform": unknown variable "platform"`,
+		},
+		{
+			name:   "adv arg",
+			eval:   scriptErr("**launch:game?system=[[lower(system)]]"),
+			wantIs: zapscript.ErrUnknownVariable,
+			want: zapscript.EvalError{
+				CmdIndex:  0,
+				CmdName:   "launch",
+				ArgIndex:  -1,
+				AdvArgKey: zapscript.KeySystem,
+				Expr:      "lower(system)",
+			},
+		},
+		{
+			name:   "when clause",
+			eval:   whenErr("**launch:game?when=[[items]]"),
+			wantIs: zapscript.ErrBadExpressionReturn,
+			want: zapscript.EvalError{
+				CmdIndex:  -1,
+				CmdName:   "launch",
+				ArgIndex:  -1,
+				AdvArgKey: zapscript.KeyWhen,
+				Expr:      "items",
+			},
+		},
+		{
+			name:   "when clause with text",
+			eval:   whenErr("**launch:game?when=is-[[platform]]"),
+			wantIs: zapscript.ErrUnknownVariable,
+			want: zapscript.EvalError{
+				CmdIndex:  -1,
+				CmdName:   "launch",
+				ArgIndex:  -1,
+				AdvArgKey: zapscript.KeyWhen,
+				Expr:      "platform",
+			},
+			wantMsg: `command launch adv arg when: failed to evaluate expression "platform": ` +
+				`unknown variable "platform"`,
+		},
+		{
+			name: "single value",
+			eval: func() error {
+				_, err := zapscript.NewParser(tokExpr("upper(name) +")).EvalExpressions(env)
+				return err
+			},
+			want: zapscript.EvalError{
+				CmdIndex: -1,
+				ArgIndex: -1,
+				Expr:     "upper(name) +",
+			},
+		},
+		{
+			name: "single value unmatched",
+			eval: func() error {
+				_, err := zapscript.NewParser(zapscript.TokExpStart + "name").EvalExpressions(env)
+				return err
+			},
+			wantIs: zapscript.ErrUnmatchedExpression,
+			want: zapscript.EvalError{
+				CmdIndex: -1,
+				ArgIndex: -1,
+			},
+			wantMsg: "unmatched expression",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.eval()
+			var got *zapscript.EvalError
+			if !errors.As(err, &got) {
+				t.Fatalf("error = %v (%T), want *EvalError", err, err)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantIs)
+			}
+			if diff := cmp.Diff(tt.want, *got, cmpopts.IgnoreFields(zapscript.EvalError{}, "Err")); diff != "" {
+				t.Errorf("EvalError mismatch (-want +got):\n%s", diff)
+			}
+			if tt.wantMsg != "" && err.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
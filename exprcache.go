@@ -0,0 +1,152 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"container/list"
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// DefaultExpressionCacheSize is the ExpressionCache size used when
+// NewExpressionCache is given a size below 1.
+const DefaultExpressionCacheSize = 256
+
+// ExpressionCache holds compiled expressions for EvalExpressionsCached, so
+// scripts evaluated repeatedly, such as mappings run on every scan, only
+// compile each expression once. It keeps up to its size of the most recently
+// used expressions and is safe for concurrent use.
+type ExpressionCache struct {
+	entries map[exprCacheKey]*list.Element
+	order   *list.List
+	// err is from checking opts.Functions, returned by every evaluation
+	err    error
+	custom []expr.Option
+	opts   EvalOptions
+	size   int
+	mu     sync.Mutex
+}
+
+// exprCacheKey identifies a compiled expression. Programs compiled for envs
+// that replace different helpers differ, see envHasName.
+type exprCacheKey struct {
+	envType    reflect.Type
+	expression string
+	shadowed   uint
+}
+
+type exprCacheEntry struct {
+	program *vm.Program
+	key     exprCacheKey
+}
+
+// NewExpressionCache returns a cache holding up to size compiled
+// expressions, or DefaultExpressionCacheSize if size is below 1.
+// Expressions are compiled and evaluated with opts, which are fixed for the
+// life of the cache because compiled programs include the functions they
+// configure.
+func NewExpressionCache(size int, opts ...EvalOption) *ExpressionCache {
+	if size < 1 {
+		size = DefaultExpressionCacheSize
+	}
+	c := &ExpressionCache{
+		entries: make(map[exprCacheKey]*list.Element, size),
+		order:   list.New(),
+		size:    size,
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	c.custom, c.err = customFunctions(c.opts.Functions)
+	return c
+}
+
+// Len returns the number of compiled expressions in the cache.
+func (c *ExpressionCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// program returns key's expression compiled for exprEnv, compiling and
+// caching it if needed. Compile errors are not cached.
+func (c *ExpressionCache) program(key exprCacheKey, exprEnv any) (*vm.Program, error) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+		entry, _ := elem.Value.(*exprCacheEntry)
+		return entry.program, nil
+	}
+	c.mu.Unlock()
+
+	// compile without the lock, a concurrent miss on the same key compiles
+	// twice and the later result is kept
+	program, err := compileExpression(key.expression, exprEnv, c.opts, compileOptions(c.opts, c.custom, exprEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		elem.Value = &exprCacheEntry{program: program, key: key}
+		return program, nil
+	}
+	c.entries[key] = c.order.PushFront(&exprCacheEntry{program: program, key: key})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		entry, _ := oldest.Value.(*exprCacheEntry)
+		delete(c.entries, entry.key)
+	}
+	return program, nil
+}
+
+// shadowableNames are the built-ins, helpers and injected names an env field
+// can replace.
+var shadowableNames = slices.Concat(shadowableBuiltins, replaceableHelpers, []string{traitsVariable})
+
+// shadowedHelpers returns a bit set of the built-ins and helper functions
+// exprEnv replaces with its own fields.
+func shadowedHelpers(exprEnv any) uint {
+	var shadowed uint
+	for i, name := range shadowableNames {
+		if envHasName(exprEnv, name) {
+			shadowed |= 1 << i
+		}
+	}
+	return shadowed
+}
+
+// EvalExpressionsCached is EvalExpressions using the options and compiled
+// expressions of cache.
+func (sr *ScriptReader) EvalExpressionsCached(cache *ExpressionCache, exprEnv any) (string, error) {
+	if cache.err != nil {
+		return "", newEvalError("", cache.err)
+	}
+	envType, shadowed := reflect.TypeOf(exprEnv), shadowedHelpers(exprEnv)
+	return sr.evalExpressions(context.Background(), exprEnv, cache.opts, func(expression string) (*vm.Program, error) {
+		key := exprCacheKey{envType: envType, expression: expression, shadowed: shadowed}
+		return cache.program(key, exprEnv)
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+// fiveExprValue is a mapping value with five expressions.
+var fiveExprValue = func() string {
+	expr := func(s string) string {
+		return zapscript.TokExpStart + s + zapscript.TokExprEnd
+	}
+	return expr(`pathjoin("/media", platform)`) + "/" + expr(`upper(device.os)`) + "/" +
+		expr(`basename(active_media.path)`) + "?" + expr(`media_playing ? "1" : "0"`) + "&" +
+		expr(`replace(last_scanned.value, ",", "_")`)
+}()
+
+var cacheTestEnv = zapscript.ArgExprEnv{
+	Platform:     "mister",
+	Device:       zapscript.ExprEnvDevice{OS: "linux"},
+	ActiveMedia:  zapscript.ExprEnvActiveMedia{Path: "/games/snes/mario.sfc"},
+	LastScanned:  zapscript.ExprEnvLastScanned{Value: "a,b"},
+	MediaPlaying: true,
+}
+
+func TestEvalExpressionsCached(t *testing.T) {
+	t.Parallel()
+
+	want, err := zapscript.NewParser(fiveExprValue).EvalExpressions(cacheTestEnv)
+	if err != nil {
+		t.Fatalf("EvalExpressions() unexpected error: %v", err)
+	}
+	if want != "/media/mister/LINUX/mario.sfc?1&a_b" {
+		t.Fatalf("EvalExpressions() = %q", want)
+	}
+
+	cache := zapscript.NewExpressionCache(0)
+	for range 3 {
+		got, err := zapscript.NewParser(fiveExprValue).EvalExpressionsCached(cache, cacheTestEnv)
+		if err != nil {
+			t.Fatalf("EvalExpressionsCached() unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("EvalExpressionsCached() = %q, want %q", got, want)
+		}
+	}
+	if cache.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", cache.Len())
+	}
+}
+
+func TestExpressionCacheBounded(t *testing.T) {
+	t.Parallel()
+
+	cache := zapscript.NewExpressionCache(3)
+	for i := range 10 {
+		value := zapscript.TokExpStart + fmt.Sprint(i) + zapscript.TokExprEnd
+		got, err := zapscript.NewParser(value).EvalExpressionsCached(cache, cacheTestEnv)
+		if err != nil {
+			t.Fatalf("EvalExpressionsCached() unexpected error: %v", err)
+		}
+		if got != fmt.Sprint(i) {
+			t.Errorf("EvalExpressionsCached() = %q, want %d", got, i)
+		}
+	}
+	if cache.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", cache.Len())
+	}
+}
+
+func TestExpressionCacheOptions(t *testing.T) {
+	t.Parallel()
+
+	cache := zapscript.NewExpressionCache(8, zapscript.WithSandboxRoot("/media"))
+	value := zapscript.TokExpStart + `pathclean("/etc/passwd")` + zapscript.TokExprEnd
+	_, err := zapscript.NewParser(value).EvalExpressionsCached(cache, cacheTestEnv)
+	if !errors.Is(err, zapscript.ErrPathEscapesSandbox) {
+		t.Errorf("EvalExpressionsCached() error = %v, want %v", err, zapscript.ErrPathEscapesSandbox)
+	}
+
+	invalid := zapscript.NewExpressionCache(8, zapscript.WithFunctions(map[string]any{"x": 1}))
+	_, err = zapscript.NewParser("plain").EvalExpressionsCached(invalid, cacheTestEnv)
+	if !errors.Is(err, zapscript.ErrInvalidExprFunction) {
+		t.Errorf("EvalExpressionsCached() error = %v, want %v", err, zapscript.ErrInvalidExprFunction)
+	}
+}
+
+func TestExpressionCacheEnvShadowing(t *testing.T) {
+	t.Parallel()
+
+	// the same expression compiles differently for envs that replace a helper
+	cache := zapscript.NewExpressionCache(8)
+	value := zapscript.TokExpStart + `lower("A")` + zapscript.TokExprEnd
+	envs := []struct {
+		env  map[string]any
+		want string
+	}{
+		{env: map[string]any{}, want: "a"},
+		{env: map[string]any{"lower": func(s string) string { return s + "!" }}, want: "A!"},
+		{env: map[string]any{}, want: "a"},
+	}
+	for _, tt := range envs {
+		got, err := zapscript.NewParser(value).EvalExpressionsCached(cache, tt.env)
+		if err != nil {
+			t.Fatalf("EvalExpressionsCached() unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("EvalExpressionsCached() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestExpressionCacheConcurrent(t *testing.T) {
+	t.Parallel()
+
+	cache := zapscript.NewExpressionCache(4)
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for g := range 16 {
+		wg.Go(func() {
+			for i := range 50 {
+				value := zapscript.TokExpStart + fmt.Sprintf("%d + %d", g%6, i%6) + zapscript.TokExprEnd
+				got, err := zapscript.NewParser(value).EvalExpressionsCached(cache, cacheTestEnv)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if want := fmt.Sprint(g%6 + i%6); got != want {
+					errs <- fmt.Errorf("got %q, want %q", got, want)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if cache.Len() > 4 {
+		t.Errorf("Len() = %d, want at most 4", cache.Len())
+	}
+}
+
+func BenchmarkEvalExpressions(b *testing.B) {
+	b.Run("Uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := zapscript.NewParser(fiveExprValue).EvalExpressions(cacheTestEnv); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Cached", func(b *testing.B) {
+		cache := zapscript.NewExpressionCache(0)
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := zapscript.NewParser(fiveExprValue).EvalExpressionsCached(cache, cacheTestEnv); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+// ArgExprEnvOption sets part of the ArgExprEnv built by NewArgExprEnv.
+type ArgExprEnvOption func(*ArgExprEnv)
+
+// NewArgExprEnv returns an ArgExprEnv with the given data set. Anything not
+// set is left at its zero value, which expressions read as empty strings and
+// false; use ArgExprEnv.HasLaunching and ArgExprEnv.HasScanned to tell
+// missing data apart from empty values.
+func NewArgExprEnv(opts ...ArgExprEnvOption) ArgExprEnv {
+	var env ArgExprEnv
+	for _, opt := range opts {
+		opt(&env)
+	}
+	return env
+}
+
+// WithActiveMedia sets ArgExprEnv.ActiveMedia and marks media as playing.
+func WithActiveMedia(media ExprEnvActiveMedia) ArgExprEnvOption {
+	return func(e *ArgExprEnv) {
+		e.ActiveMedia = media
+		e.MediaPlaying = true
+	}
+}
+
+// WithScanned sets ArgExprEnv.Scanned.
+func WithScanned(scanned ExprEnvScanned) ArgExprEnvOption {
+	return func(e *ArgExprEnv) {
+		e.Scanned = scanned
+	}
+}
+
+// WithLaunching sets ArgExprEnv.Launching.
+func WithLaunching(launching ExprEnvLaunching) ArgExprEnvOption {
+	return func(e *ArgExprEnv) {
+		e.Launching = launching
+	}
+}
+
+// WithDevice sets ArgExprEnv.Device.
+func WithDevice(device ExprEnvDevice) ArgExprEnvOption {
+	return func(e *ArgExprEnv) {
+		e.Device = device
+	}
+}
+
+// HasLaunching reports whether the env holds media about to launch.
+// Expressions call it as [[HasLaunching()]], e.g. to fall back to another
+// value when no launch is in progress.
+func (e ArgExprEnv) HasLaunching() bool {
+	return e.Launching != ExprEnvLaunching{}
+}
+
+// HasScanned reports whether the env holds a token being processed.
+// Expressions call it as [[HasScanned()]].
+func (e ArgExprEnv) HasScanned() bool {
+	return e.Scanned != ExprEnvScanned{}
+}
@@ -214,3 +214,98 @@ func TestArgExprEnv_EmptyFieldsSerialization(t *testing.T) {
 	assert.Contains(t, jsonStr, `"platform":"test"`, "platform should have correct value")
 	assert.Contains(t, jsonStr, `"version":"1.0.0"`, "version should have correct value")
 }
+
+// TestNewArgExprEnv verifies that the constructor options fill their part of
+// the env and leave everything else at its zero value.
+func TestNewArgExprEnv(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, zapscript.ArgExprEnv{}, zapscript.NewArgExprEnv())
+
+	media := zapscript.ExprEnvActiveMedia{SystemID: "snes", Name: "Super Mario World"}
+	scanned := zapscript.ExprEnvScanned{ID: "abc", Value: "**launch:snes/mario"}
+	launching := zapscript.ExprEnvLaunching{Path: "/games/snes/mario.sfc", SystemID: "snes"}
+	device := zapscript.ExprEnvDevice{Hostname: "mister", OS: "linux"}
+
+	env := zapscript.NewArgExprEnv(
+		zapscript.WithActiveMedia(media),
+		zapscript.WithScanned(scanned),
+		zapscript.WithLaunching(launching),
+		zapscript.WithDevice(device),
+	)
+	assert.Equal(t, zapscript.ArgExprEnv{
+		ActiveMedia:  media,
+		Scanned:      scanned,
+		Launching:    launching,
+		Device:       device,
+		MediaPlaying: true,
+	}, env)
+	assert.True(t, env.HasLaunching())
+	assert.True(t, env.HasScanned())
+
+	empty := zapscript.NewArgExprEnv(zapscript.WithDevice(device))
+	assert.False(t, empty.HasLaunching())
+	assert.False(t, empty.HasScanned())
+}
+
+// TestArgExprEnv_DataAvailability verifies that scripts can fall back to other
+// fields when launching or scanned data is missing.
+func TestArgExprEnv_DataAvailability(t *testing.T) {
+	t.Parallel()
+
+	populated := zapscript.NewArgExprEnv(
+		zapscript.WithLaunching(zapscript.ExprEnvLaunching{Path: "/games/snes/mario.sfc"}),
+		zapscript.WithScanned(zapscript.ExprEnvScanned{ID: "abc"}),
+	)
+	populated.LastScanned.Value = "last"
+	empty := zapscript.NewArgExprEnv()
+	empty.LastScanned.Value = "last"
+
+	tests := []struct {
+		name          string
+		expression    string
+		wantPopulated string
+		wantEmpty     string
+	}{
+		{
+			name:          "ternary on field",
+			expression:    `launching.path != "" ? launching.path : last_scanned.value`,
+			wantPopulated: "/games/snes/mario.sfc",
+			wantEmpty:     "last",
+		},
+		{
+			name:          "default helper",
+			expression:    `default(launching.path, last_scanned.value)`,
+			wantPopulated: "/games/snes/mario.sfc",
+			wantEmpty:     "last",
+		},
+		{
+			name:          "has launching",
+			expression:    `HasLaunching() ? launching.path : "not launching"`,
+			wantPopulated: "/games/snes/mario.sfc",
+			wantEmpty:     "not launching",
+		},
+		{
+			name:          "has scanned",
+			expression:    `HasScanned()`,
+			wantPopulated: "true",
+			wantEmpty:     "false",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			input := zapscript.TokExpStart + tt.expression + zapscript.TokExprEnd
+
+			got, err := zapscript.NewParser(input).EvalExpressions(populated)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPopulated, got, "populated env")
+
+			got, err = zapscript.NewParser(input).EvalExpressions(empty)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantEmpty, got, "empty env")
+		})
+	}
+}
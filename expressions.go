@@ -16,12 +16,18 @@
 package zapscript
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
 	"strconv"
 	"strings"
-	"unicode/utf8"
+	"time"
 
 	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
 )
 
 type ExprEnvDevice struct {
@@ -71,6 +77,10 @@ type ExprEnvHook struct {
 
 //nolint:tagliatelle // JSON uses snake_case to match expression env naming
 type ArgExprEnv struct {
+	// Now is the host's current local time, for conditions such as
+	// [[hour(now) < 20]]. It is zero unless the host sets it, and replaces
+	// expr's now() built-in.
+	Now          time.Time          `expr:"now" json:"now,omitzero"`
 	ActiveMedia  ExprEnvActiveMedia `expr:"active_media" json:"active_media"`
 	Device       ExprEnvDevice      `expr:"device" json:"device"`
 	LastScanned  ExprEnvLastScanned `expr:"last_scanned" json:"last_scanned"`
@@ -98,6 +108,9 @@ type CustomLauncherExprEnv struct {
 }
 
 func (sr *ScriptReader) parseExpression() (string, error) {
+	if sr.opts.DisableExpressions {
+		return string(SymExpressionStart), nil
+	}
 	rawExpr := TokExpStart
 
 	next, err := sr.read()
@@ -111,6 +124,7 @@ func (sr *ScriptReader) parseExpression() (string, error) {
 		return string(SymExpressionStart), nil
 	}
 
+	var scan exprScanner
 	for {
 		ch, err := sr.read()
 		if err != nil {
@@ -119,7 +133,7 @@ func (sr *ScriptReader) parseExpression() (string, error) {
 			return rawExpr, ErrUnmatchedExpression
 		}
 
-		if ch == SymExpressionEnd {
+		if scan.atClose(ch) {
 			next, err := sr.peek()
 			if err != nil {
 				return rawExpr, err
@@ -139,26 +153,181 @@ func (sr *ScriptReader) parseExpression() (string, error) {
 	return rawExpr, nil
 }
 
-func (sr *ScriptReader) parsePostExpression() (string, error) {
-	rawExpr := ""
-	exprEndToken, _ := utf8.DecodeRuneInString(TokExprEnd)
+// exprScanner follows the string literals and brackets in an expression's
+// source, so that a ]] inside a string such as "]]" or closing an index such
+// as a[0] does not end the expression.
+type exprScanner struct {
+	// quote is the quote of the string literal being read, or 0.
+	quote rune
+	// depth is the number of [ open outside string literals.
+	depth int
+	// escaped is set after a backslash in a quoted string.
+	escaped bool
+}
 
-	for {
-		ch, err := sr.read()
-		if err != nil {
-			return rawExpr, err
-		} else if ch == eof {
-			return rawExpr, ErrUnmatchedExpression
+// atClose records the next rune of the source and reports whether it is a ]
+// outside any string literal or bracket, which ends the expression if
+// another ] follows it. Strings in double or single quotes may contain
+// backslash escapes; strings in backticks are raw.
+func (s *exprScanner) atClose(ch rune) bool {
+	switch {
+	case s.quote != 0:
+		switch {
+		case s.escaped:
+			s.escaped = false
+		case ch == '\\' && s.quote != '`':
+			s.escaped = true
+		case ch == s.quote:
+			s.quote = 0
 		}
+	case ch == '"' || ch == '\'' || ch == '`':
+		s.quote = ch
+	case ch == SymExpressionStart:
+		s.depth++
+	case ch == SymExpressionEnd:
+		if s.depth == 0 {
+			return true
+		}
+		s.depth--
+	}
+	return false
+}
 
-		if ch == exprEndToken {
-			break
+// exprEnd returns the index of the first ] of the ]] that ends the
+// expression whose source starts at runes[start], or -1 if it is not
+// closed.
+func exprEnd(runes []rune, start int) int {
+	var scan exprScanner
+	for i := start; i+1 < len(runes); i++ {
+		if scan.atClose(runes[i]) && runes[i+1] == SymExpressionEnd {
+			return i
 		}
+	}
+	return -1
+}
 
-		rawExpr += string(ch)
+// SplitArgParts splits a parsed value, such as an entry in Command.Args or an
+// adv arg value, into its literal string and expression segments in order.
+// Expression segments hold the expression source without the TokExpStart and
+// TokExprEnd delimiters. An unterminated expression runs to the end of s.
+func SplitArgParts(s string) []PostArgPart {
+	parts, _ := splitArgParts(s)
+	return parts
+}
+
+// splitArgParts is SplitArgParts that also reports whether every expression
+// was terminated.
+func splitArgParts(s string) (parts []PostArgPart, complete bool) {
+	for s != "" {
+		start := strings.Index(s, TokExpStart)
+		if start == -1 {
+			return append(parts, PostArgPart{Type: ArgPartTypeString, Value: s}), true
+		}
+		if start > 0 {
+			parts = append(parts, PostArgPart{Type: ArgPartTypeString, Value: s[:start]})
+		}
+
+		s = s[start+len(TokExpStart):]
+		end := strings.Index(s, TokExprEnd)
+		if end == -1 {
+			return append(parts, PostArgPart{Type: ArgPartTypeExpression, Value: s}), false
+		}
+		parts = append(parts, PostArgPart{Type: ArgPartTypeExpression, Value: s[:end]})
+		s = s[end+len(TokExprEnd):]
 	}
+	return parts, true
+}
 
-	return rawExpr, nil
+// RenderExpressions is the original name of DetokenizeExpressions.
+//
+// Deprecated: use DetokenizeExpressions.
+func RenderExpressions(s string) string {
+	return DetokenizeExpressions(s)
+}
+
+// DetokenizeExpressions converts the expression tokens in a parsed value back
+// to [[...]] syntax, for output such as JSON or a UI where the private-use
+// token runes would confuse readers. A literal [ that would start [[ and a
+// literal ^ before ^ or [ are escaped with ^ so that TokenizeExpressions
+// restores the value exactly and a re-parse creates no new expressions;
+// other text is unchanged.
+func DetokenizeExpressions(s string) string {
+	if !strings.ContainsAny(s, TokExpStart+TokExprEnd+string([]rune{SymEscapeSeq, SymExpressionStart})) {
+		return s
+	}
+
+	var b strings.Builder
+	parts := SplitArgParts(s)
+	for i, part := range parts {
+		if part.Type == ArgPartTypeExpression {
+			writeExpression(&b, part.Value)
+			continue
+		}
+
+		// decide escapes from the end, since each depends on the character
+		// written after it
+		runes := []rune(part.Value)
+		escape := make([]bool, len(runes))
+		next := eof
+		if i+1 < len(parts) {
+			next = SymExpressionStart
+		}
+		for j := len(runes) - 1; j >= 0; j-- {
+			switch runes[j] {
+			case SymEscapeSeq:
+				escape[j] = next == SymEscapeSeq || next == SymExpressionStart
+			case SymExpressionStart:
+				escape[j] = next == SymExpressionStart
+			}
+			next = runes[j]
+			if escape[j] {
+				next = SymEscapeSeq
+			}
+		}
+
+		for j, ch := range runes {
+			if escape[j] {
+				_, _ = b.WriteRune(SymEscapeSeq)
+			}
+			_, _ = b.WriteRune(ch)
+		}
+	}
+	return b.String()
+}
+
+// TokenizeExpressions is the inverse of DetokenizeExpressions. It converts
+// [[...]] expressions to expression tokens and resolves the ^^ and ^[
+// escapes; any other ^ is kept as is. It returns ErrUnmatchedExpression if an
+// expression is not closed. For any value whose expressions are closed,
+// TokenizeExpressions(DetokenizeExpressions(v)) returns v.
+func TokenizeExpressions(s string) (string, error) {
+	if !strings.ContainsAny(s, string([]rune{SymEscapeSeq, SymExpressionStart})) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case ch == SymEscapeSeq && i+1 < len(runes) &&
+			(runes[i+1] == SymEscapeSeq || runes[i+1] == SymExpressionStart):
+			i++
+			_, _ = b.WriteRune(runes[i])
+		case ch == SymExpressionStart && i+1 < len(runes) && runes[i+1] == SymExpressionStart:
+			end := exprEnd(runes, i+2)
+			if end == -1 {
+				return "", ErrUnmatchedExpression
+			}
+			_, _ = b.WriteString(TokExpStart)
+			_, _ = b.WriteString(string(runes[i+2 : end]))
+			_, _ = b.WriteString(TokExprEnd)
+			i = end + 1
+		default:
+			_, _ = b.WriteRune(ch)
+		}
+	}
+	return b.String(), nil
 }
 
 // ParseExpressions parses and converts expressions in the input string from
@@ -200,67 +369,305 @@ func (sr *ScriptReader) ParseExpressions() (string, error) {
 	return result, nil
 }
 
-func (sr *ScriptReader) EvalExpressions(exprEnv any) (string, error) {
-	parts := make([]PostArgPart, 0)
-	currentPart := PostArgPart{}
+// formatExprResult returns the text substituted for an expression result.
+// Strings, bools and integers are formatted as Go does, floats by
+// formatFloat with the given precision and nil as an empty string. Slices,
+// arrays and maps with string keys are written as compact JSON, which
+// commands accept as args. Anything else, or a value that cannot be encoded
+// as JSON such as a slice holding a func, is an ErrBadExpressionReturn
+// error.
+func formatExprResult(output any, precision int) (string, error) {
+	if output == nil {
+		return "", nil
+	}
+	v := reflect.ValueOf(output)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return formatFloat(v.Float(), 32, precision), nil
+	case reflect.Float64:
+		return formatFloat(v.Float(), 64, precision), nil
+	case reflect.Slice, reflect.Array:
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return "", fmt.Errorf("%w: %v (%T)", ErrBadExpressionReturn, output, output)
+		}
+	default:
+		return "", fmt.Errorf("%w: %v (%T)", ErrBadExpressionReturn, output, output)
+	}
 
-	exprStartToken, _ := utf8.DecodeRuneInString(TokExpStart)
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(output); err != nil {
+		return "", fmt.Errorf("%w: %T: %w", ErrBadExpressionReturn, output, err)
+	}
+	return strings.TrimSuffix(data.String(), "\n"), nil
+}
 
-	for {
-		ch, err := sr.read()
-		if err != nil {
-			return "", err
-		} else if ch == eof {
-			break
+// floatExponentThreshold is the magnitude from which floats are written in
+// exponent notation, since their integral digits would be mostly noise.
+const floatExponentThreshold = 1e21
+
+// formatFloat writes f, a float of the given bit size, rounded to precision
+// decimal places with trailing zeros removed, so 10/3 is "3.333333" and 4.0
+// is "4". A precision of zero uses DefaultFloatPrecision and a negative one
+// the fewest digits that read back as f. Magnitudes of
+// floatExponentThreshold or more are written in exponent notation, as
+// "1e+21"; NaN and infinities are written as "NaN", "+Inf" and "-Inf".
+func formatFloat(f float64, bitSize, precision int) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) >= floatExponentThreshold {
+		return strconv.FormatFloat(f, 'g', -1, bitSize)
+	}
+	if precision == 0 {
+		precision = DefaultFloatPrecision
+	} else if precision < 0 {
+		precision = -1
+	}
+	s := strconv.FormatFloat(f, 'f', precision, bitSize)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// EvalExpressions evaluates the expressions in a parsed value against
+// exprEnv and returns the value with their results substituted.
+//
+// Whatever the env, expressions can use these functions:
+//
+//   - upper(s), lower(s) and trim(s) change case and trim whitespace
+//   - replace(s, old, new) replaces every old in s with new
+//   - basename(p) returns the last element of a path
+//   - pathjoin(parts...) and pathclean(p), see EvalOptions
+//   - default(value, fallback) returns fallback if value is nil, "", false,
+//     0 or an empty list or map, and value otherwise
+//   - weekday(t) returns the English day name of a time, such as "Monday"
+//   - hour(t) returns the hour of a time, 0 to 23
+//   - format_time(t, layout) formats a time with a Go layout such as
+//     "2006-01-02"
+//
+// and the contains operator, as in [[active_media.name contains "Mario"]];
+// contains is an expr keyword so it cannot be called as a function. expr's
+// own value ?? fallback only replaces nil, which the fields of ArgExprEnv
+// never are, and cond ? a : b covers any other test; default is shorthand
+// for the common case, as in [[default(active_media.name, "nothing
+// playing")]]. A field of exprEnv with the same name as one of the string
+// helpers, basename, default or a time function replaces it.
+// EvalOptions.Functions are also available, and an invalid one returns an
+// ErrInvalidExprFunction error.
+//
+// exprEnv is usually an ArgExprEnv, but a map with string keys such as a
+// map[string]any works too. Reading a variable the map has no key for is an
+// ErrUnknownVariable error; a key missing from a nested map reads as nil,
+// and a nil result substitutes an empty string.
+//
+// Errors are *EvalError values with CmdIndex -1.
+func (sr *ScriptReader) EvalExpressions(exprEnv any, opts ...EvalOption) (string, error) {
+	return sr.EvalExpressionsContext(context.Background(), exprEnv, opts...)
+}
+
+// EvalExpressionsContext is EvalExpressions that stops when ctx is done, so
+// a slow expression such as a huge range cannot stall the caller. The error
+// wraps ctx.Err() and names the expression being evaluated. expr programs
+// cannot be interrupted, so an abandoned expression keeps running in the
+// background until it finishes; its result is discarded.
+func (sr *ScriptReader) EvalExpressionsContext(ctx context.Context, exprEnv any, opts ...EvalOption) (string, error) {
+	var evalOpts EvalOptions
+	for _, opt := range opts {
+		opt(&evalOpts)
+	}
+	custom, err := customFunctions(evalOpts.Functions)
+	if err != nil {
+		return "", newEvalError("", err)
+	}
+	funcs := compileOptions(evalOpts, custom, exprEnv)
+
+	return sr.evalExpressions(ctx, exprEnv, evalOpts, func(expression string) (*vm.Program, error) {
+		return compileExpression(expression, exprEnv, evalOpts, funcs)
+	})
+}
+
+// Eval evaluates the expressions in a parsed value, such as an arg of a
+// parsed command, against exprEnv as NewParser(input).EvalExpressions does.
+// It is safe for concurrent use and reuses parsers internally.
+func Eval(input string, exprEnv any, opts ...EvalOption) (string, error) {
+	sr, _ := parserPool.Get().(*ScriptReader)
+	sr.reset(input)
+	result, err := sr.EvalExpressions(exprEnv, opts...)
+
+	// drop references to the input before pooling
+	sr.reset("")
+	parserPool.Put(sr)
+	return result, err
+}
+
+// EvalTraits returns a copy of the script's traits with the expressions in
+// string values, including array elements, evaluated against exprEnv as
+// EvalExpressions does. The type of an evaluated value is inferred from the
+// result as for an unquoted value, so #count=[[1 + 1]] becomes int64(2),
+// even if the value was quoted. Values without expressions are unchanged.
+func (s Script) EvalTraits(exprEnv any, opts ...EvalOption) (map[string]any, error) {
+	if s.Traits == nil {
+		return nil, nil
+	}
+	var evalOpts EvalOptions
+	for _, opt := range opts {
+		opt(&evalOpts)
+	}
+	custom, err := customFunctions(evalOpts.Functions)
+	if err != nil {
+		return nil, err
+	}
+	funcs := compileOptions(evalOpts, custom, exprEnv)
+	compile := func(expression string) (*vm.Program, error) {
+		return compileExpression(expression, exprEnv, evalOpts, funcs)
+	}
+
+	traits := make(map[string]any, len(s.Traits))
+	for k, v := range s.Traits {
+		evaluated, evalErr := evalTraitValue(v, exprEnv, evalOpts, compile)
+		if evalErr != nil {
+			return nil, fmt.Errorf("trait %q: %w", k, evalErr)
 		}
+		traits[k] = evaluated
+	}
+	return traits, nil
+}
 
-		if ch == exprStartToken {
-			if currentPart.Type != ArgPartTypeUnknown {
-				parts = append(parts, currentPart)
-				currentPart = PostArgPart{}
+// evalTraitValue returns a copy of a trait value with its expressions
+// evaluated, descending into arrays and objects.
+func evalTraitValue(
+	v any, exprEnv any, opts EvalOptions, compile func(expression string) (*vm.Program, error),
+) (any, error) {
+	switch tv := v.(type) {
+	case string:
+		if !strings.Contains(tv, TokExpStart) {
+			return tv, nil
+		}
+		result, err := NewParser(tv).evalExpressions(context.Background(), exprEnv, opts, compile)
+		if err != nil {
+			return nil, err
+		}
+		return inferType(result, false), nil
+	case []any:
+		if tv == nil {
+			return tv, nil
+		}
+		elems := make([]any, len(tv))
+		for i, elem := range tv {
+			evaluated, err := evalTraitValue(elem, exprEnv, opts, compile)
+			if err != nil {
+				return nil, err
 			}
-
-			currentPart.Type = ArgPartTypeExpression
-			exprValue, err := sr.parsePostExpression()
+			elems[i] = evaluated
+		}
+		return elems, nil
+	case map[string]any:
+		if tv == nil {
+			return tv, nil
+		}
+		obj := make(map[string]any, len(tv))
+		for k, elem := range tv {
+			evaluated, err := evalTraitValue(elem, exprEnv, opts, compile)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			currentPart.Value = exprValue
+			obj[k] = evaluated
+		}
+		return obj, nil
+	default:
+		return v, nil
+	}
+}
 
-			parts = append(parts, currentPart)
-			currentPart = PostArgPart{}
+// compileOptions returns the expr options for compiling expressions against
+// exprEnv: the path, default and time functions, the string helpers that
+// exprEnv does not replace, the injected traits and the custom functions.
+func compileOptions(opts EvalOptions, custom []expr.Option, exprEnv any) []expr.Option {
+	funcs := append(pathFunctions(opts, exprEnv), defaultFunction(exprEnv)...)
+	funcs = append(funcs, timeFunctions(exprEnv)...)
+	for _, name := range shadowableBuiltins {
+		if envHasName(exprEnv, name) {
+			funcs = append(funcs, expr.DisableBuiltin(name))
+		}
+	}
+	if injectsTraits(opts, exprEnv) {
+		funcs = append(funcs, expr.Patch(traitsPatcher{traits: opts.Traits}))
+	}
+	return append(funcs, custom...)
+}
 
-			continue
+// compileExpression compiles expression with compileOpts after checking it
+// against opts.AllowedFields. Injected traits are always allowed.
+func compileExpression(
+	expression string, exprEnv any, opts EvalOptions, compileOpts []expr.Option,
+) (*vm.Program, error) {
+	if opts.AllowedFields != nil {
+		allowed := opts.AllowedFields
+		if injectsTraits(opts, exprEnv) {
+			allowed = append(allowed[:len(allowed):len(allowed)], traitsVariable)
+		}
+		if err := checkAllowedFields(expression, allowed); err != nil {
+			return nil, err
 		}
-		currentPart.Type = ArgPartTypeString
-		currentPart.Value += string(ch)
-		continue
 	}
+	return expr.Compile(expression, compileOpts...)
+}
 
-	if currentPart.Type != ArgPartTypeUnknown {
-		parts = append(parts, currentPart)
+// evalExpressions reads the rest of the input as a parsed value and
+// substitutes its expressions, compiled with compile, evaluated against
+// exprEnv and formatted as opts set. Errors are *EvalError values outside of
+// any script.
+func (sr *ScriptReader) evalExpressions(
+	ctx context.Context, exprEnv any, opts EvalOptions, compile func(expression string) (*vm.Program, error),
+) (string, error) {
+	var value strings.Builder
+	for {
+		ch, err := sr.read()
+		if err != nil {
+			return "", newEvalError("", err)
+		} else if ch == eof {
+			break
+		}
+		_, _ = value.WriteRune(ch)
+	}
+
+	parts, complete := splitArgParts(value.String())
+	if !complete {
+		return "", newEvalError("", ErrUnmatchedExpression)
 	}
 
 	var result strings.Builder
 	for _, part := range parts {
 		if part.Type == ArgPartTypeExpression {
-			output, err := expr.Eval(part.Value, exprEnv)
+			program, err := compile(part.Value)
 			if err != nil {
-				return "", fmt.Errorf("failed to evaluate expression %q: %w", part.Value, err)
+				return "", newEvalError(part.Value, err)
+			}
+			if envErr := checkMapEnv(part.Value, exprEnv, opts); envErr != nil {
+				return "", newEvalError(part.Value, envErr)
+			}
+			output, err := runProgram(ctx, program, exprEnv)
+			if err != nil {
+				return "", newEvalError(part.Value, err)
 			}
 
-			switch v := output.(type) {
-			case string:
-				_, _ = result.WriteString(v)
-			case bool:
-				_, _ = result.WriteString(strconv.FormatBool(v))
-			case int:
-				_, _ = result.WriteString(strconv.Itoa(v))
-			case float64:
-				_, _ = result.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
-			default:
-				return "", fmt.Errorf("%w: %v (%T)", ErrBadExpressionReturn, v, v)
+			formatted, err := formatExprResult(output, opts.FloatPrecision)
+			if err != nil {
+				return "", newEvalError(part.Value, err)
 			}
+			_, _ = result.WriteString(formatted)
 		} else {
 			_, _ = result.WriteString(part.Value)
 		}
@@ -268,3 +675,58 @@ func (sr *ScriptReader) EvalExpressions(exprEnv any) (string, error) {
 
 	return result.String(), nil
 }
+
+// newEvalError returns an *EvalError for expression outside of any script.
+// expression is empty if the error is not from a particular expression.
+func newEvalError(expression string, err error) *EvalError {
+	return &EvalError{Err: err, Expr: expression, CmdIndex: -1, ArgIndex: -1}
+}
+
+// checkMapEnv returns an ErrUnknownVariable error naming the first variable
+// the expression reads that a map exprEnv, such as a map[string]any, has no
+// key for and opts do not inject. Without it a missing key reads as nil.
+// Keys missing from nested maps still read as nil. Other envs are not
+// checked.
+func checkMapEnv(expression string, exprEnv any, opts EvalOptions) error {
+	env := reflect.ValueOf(exprEnv)
+	if env.Kind() != reflect.Map || env.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+	for _, name := range exprEnvDependencies([]string{expression}) {
+		if name == envVariable || (name == traitsVariable && opts.Traits != nil) {
+			continue
+		}
+		if !env.MapIndex(reflect.ValueOf(name).Convert(env.Type().Key())).IsValid() {
+			return fmt.Errorf("%w %q", ErrUnknownVariable, name)
+		}
+	}
+	return nil
+}
+
+// runProgram runs program against exprEnv, returning early with ctx.Err()
+// if ctx is done first. Contexts that are never done run it directly.
+func runProgram(ctx context.Context, program *vm.Program, exprEnv any) (any, error) {
+	if ctx.Done() == nil {
+		return expr.Run(program, exprEnv)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		err    error
+		output any
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := expr.Run(program, exprEnv)
+		done <- result{err: err, output: output}
+	}()
+
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestEvalExpressionsAllowedFields(t *testing.T) {
+	t.Parallel()
+
+	env := zapscript.ArgExprEnv{
+		Platform: "mister",
+		Device:   zapscript.ExprEnvDevice{Hostname: "den", OS: "linux"},
+	}
+
+	tests := []struct {
+		wantErr   error
+		name      string
+		input     string
+		want      string
+		wantField string
+		allowed   []string
+	}{
+		{
+			name:    "allowed field",
+			input:   tokExpr("platform"),
+			allowed: []string{"platform"},
+			want:    "mister",
+		},
+		{
+			name:    "nested field allowed by prefix",
+			input:   tokExpr("device.os"),
+			allowed: []string{"device"},
+			want:    "linux",
+		},
+		{
+			name:    "nested field allowed exactly",
+			input:   tokExpr(`device.os + "/" + platform`),
+			allowed: []string{"device.os", "platform"},
+			want:    "linux/mister",
+		},
+		{
+			name:    "arithmetic only",
+			input:   tokExpr("1 + 2"),
+			allowed: []string{},
+			want:    "3",
+		},
+		{
+			name:    "helpers and let variables",
+			input:   tokExpr(`let p = upper(platform); p + "!"`),
+			allowed: []string{"platform"},
+			want:    "MISTER!",
+		},
+		{
+			name:      "disallowed field",
+			input:     tokExpr("device.hostname"),
+			allowed:   []string{"device.os", "platform"},
+			wantErr:   zapscript.ErrFieldNotAllowed,
+			wantField: "device.hostname",
+		},
+		{
+			name:      "prefix is not a parent",
+			input:     tokExpr("platform_name"),
+			allowed:   []string{"platform"},
+			wantErr:   zapscript.ErrFieldNotAllowed,
+			wantField: "platform_name",
+		},
+		{
+			name:      "parent of allowed field",
+			input:     tokExpr("device"),
+			allowed:   []string{"device.os"},
+			wantErr:   zapscript.ErrFieldNotAllowed,
+			wantField: "device",
+		},
+		{
+			name:      "no fields allowed",
+			input:     "a" + tokExpr("platform"),
+			allowed:   []string{},
+			wantErr:   zapscript.ErrFieldNotAllowed,
+			wantField: "platform",
+		},
+		{
+			name:      "field through env variable",
+			input:     tokExpr(`$env.device.hostname`),
+			allowed:   []string{"platform"},
+			wantErr:   zapscript.ErrFieldNotAllowed,
+			wantField: "device.hostname",
+		},
+		{
+			name:    "allowed field through env variable",
+			input:   tokExpr(`$env["platform"]`),
+			allowed: []string{"platform"},
+			want:    "mister",
+		},
+		{
+			name:      "whole env",
+			input:     tokExpr(`$env[platform]`),
+			allowed:   []string{"platform"},
+			wantErr:   zapscript.ErrFieldNotAllowed,
+			wantField: "$env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(tt.input).
+				EvalExpressions(env, zapscript.WithAllowedFields(tt.allowed))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("EvalExpressions() error = %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantField) {
+					t.Errorf("EvalExpressions() error = %v, want it to name %q", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowedFieldsOtherEvaluators(t *testing.T) {
+	t.Parallel()
+
+	env := zapscript.ArgExprEnv{Device: zapscript.ExprEnvDevice{Hostname: "den"}}
+	allow := zapscript.WithAllowedFields([]string{"platform"})
+
+	cmd := zapscript.Command{
+		Name:    "cmd",
+		Args:    []string{tokExpr("device.hostname")},
+		AdvArgs: zapscript.NewAdvArgs(map[string]string{"when": tokExpr(`device.hostname == "den"`)}),
+	}
+	if _, err := cmd.ShouldRun(env, allow); !errors.Is(err, zapscript.ErrFieldNotAllowed) {
+		t.Errorf("ShouldRun() error = %v, want ErrFieldNotAllowed", err)
+	}
+
+	script := zapscript.Script{
+		Cmds:   []zapscript.Command{cmd},
+		Traits: map[string]any{"host": tokExpr("device.hostname")},
+	}
+	if _, err := script.EvalTraits(env, allow); !errors.Is(err, zapscript.ErrFieldNotAllowed) {
+		t.Errorf("EvalTraits() error = %v, want ErrFieldNotAllowed", err)
+	}
+
+	errs := zapscript.ValidateExpressions(script, env, allow)
+	if len(errs) != 2 {
+		t.Fatalf("ValidateExpressions() = %v, want 2 errors", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, zapscript.ErrFieldNotAllowed) {
+			t.Errorf("ValidateExpressions() error = %v, want ErrFieldNotAllowed", err)
+		}
+	}
+
+	cache := zapscript.NewExpressionCache(8, allow)
+	_, err := zapscript.NewParser(tokExpr("device.hostname")).EvalExpressionsCached(cache, env)
+	if !errors.Is(err, zapscript.ErrFieldNotAllowed) {
+		t.Errorf("EvalExpressionsCached() error = %v, want ErrFieldNotAllowed", err)
+	}
+	if code := zapscript.ErrorCode(err); code != "field_not_allowed" {
+		t.Errorf("ErrorCode() = %q, want %q", code, "field_not_allowed")
+	}
+}
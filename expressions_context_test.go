@@ -0,0 +1,91 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestEvalExpressionsContextTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+	slow := zapscript.WithFunctions(map[string]any{
+		"slow": func() string {
+			<-release
+			return "late"
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	value := "a" + zapscript.TokExpStart + `slow()` + zapscript.TokExprEnd
+	start := time.Now()
+	_, err := zapscript.NewParser(value).EvalExpressionsContext(ctx, zapscript.ArgExprEnv{}, slow)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("EvalExpressionsContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if !strings.Contains(err.Error(), "slow()") {
+		t.Errorf("EvalExpressionsContext() error = %v, want it to name the expression", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("EvalExpressionsContext() took %v after a 20ms timeout", elapsed)
+	}
+}
+
+func TestEvalExpressionsContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	value := zapscript.TokExpStart + `1 + 1` + zapscript.TokExprEnd
+	_, err := zapscript.NewParser(value).EvalExpressionsContext(ctx, zapscript.ArgExprEnv{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("EvalExpressionsContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	// values without expressions never evaluate anything
+	got, err := zapscript.NewParser("plain").EvalExpressionsContext(ctx, zapscript.ArgExprEnv{})
+	if err != nil || got != "plain" {
+		t.Errorf("EvalExpressionsContext() = %q, %v, want %q, nil", got, err, "plain")
+	}
+}
+
+func TestEvalExpressionsContextFast(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	value := zapscript.TokExpStart + `platform` + zapscript.TokExprEnd + "/" +
+		zapscript.TokExpStart + `upper("x")` + zapscript.TokExprEnd
+	got, err := zapscript.NewParser(value).EvalExpressionsContext(ctx, zapscript.ArgExprEnv{Platform: "mister"})
+	if err != nil {
+		t.Fatalf("EvalExpressionsContext() unexpected error: %v", err)
+	}
+	if got != "mister/X" {
+		t.Errorf("EvalExpressionsContext() = %q, want %q", got, "mister/X")
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+var errNegativeWidth = errors.New("negative width")
+
+func TestEvalExpressionsFunctions(t *testing.T) {
+	t.Parallel()
+
+	expr := func(s string) string {
+		return zapscript.TokExpStart + s + zapscript.TokExprEnd
+	}
+	funcs := map[string]any{
+		"basename": path.Base,
+		"pad": func(n, width int) (string, error) {
+			if width < 0 {
+				return "", errNegativeWidth
+			}
+			return fmt.Sprintf("%0*d", width, n), nil
+		},
+		"scale": func(f float64) float64 { return f * 2 },
+		"concat": func(parts ...string) string {
+			return strings.Join(parts, "+")
+		},
+		"channel": func() chan int { return make(chan int) },
+	}
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		want    string
+	}{
+		{
+			name:  "two args",
+			input: "slot" + expr(`pad(7, 3)`),
+			want:  "slot007",
+		},
+		{
+			name:  "with env field",
+			input: expr(`basename(active_media.path)`),
+			want:  "mario.sfc",
+		},
+		{
+			name:  "nested with path function",
+			input: expr(`basename(pathjoin("a", "b.rom"))`),
+			want:  "b.rom",
+		},
+		{
+			name:  "int converted to float param",
+			input: expr(`scale(2)`),
+			want:  "4",
+		},
+		{
+			name:  "variadic",
+			input: expr(`concat("a", "b", "c")`),
+			want:  "a+b+c",
+		},
+		{
+			name:    "function error",
+			input:   expr(`pad(1, -1)`),
+			wantErr: errNegativeWidth,
+		},
+		{
+			name:    "unsupported return type",
+			input:   expr(`channel()`),
+			wantErr: zapscript.ErrBadExpressionReturn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			env := zapscript.ArgExprEnv{ActiveMedia: zapscript.ExprEnvActiveMedia{Path: "/games/snes/mario.sfc"}}
+
+			got, err := zapscript.NewParser(tt.input).EvalExpressions(env, zapscript.WithFunctions(funcs))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("EvalExpressions() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalExpressionsUnknownFunction(t *testing.T) {
+	t.Parallel()
+
+	p := zapscript.NewParser(zapscript.TokExpStart + `nosuch(1)` + zapscript.TokExprEnd)
+	_, err := p.EvalExpressions(zapscript.ArgExprEnv{}, zapscript.WithFunctions(map[string]any{
+		"other": func() string { return "" },
+	}))
+	if err == nil || !strings.Contains(err.Error(), "nosuch") {
+		t.Errorf("EvalExpressions() error = %v, want an error naming nosuch", err)
+	}
+}
+
+func TestEvalExpressionsInvalidFunctions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fn   any
+		name string
+		key  string
+	}{
+		{name: "not a function", key: "x", fn: "nope"},
+		{name: "nil function", key: "x", fn: (func() string)(nil)},
+		{name: "no results", key: "x", fn: func() {}},
+		{name: "second result not error", key: "x", fn: func() (string, string) { return "", "" }},
+		{name: "replaces built-in", key: zapscript.ExprFuncPathJoin, fn: func() string { return "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := zapscript.NewParser("plain")
+			_, err := p.EvalExpressions(zapscript.ArgExprEnv{}, zapscript.WithFunctions(map[string]any{tt.key: tt.fn}))
+			if !errors.Is(err, zapscript.ErrInvalidExprFunction) {
+				t.Errorf("EvalExpressions() error = %v, want %v", err, zapscript.ErrInvalidExprFunction)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+// helperEnv has fields named like the built-in helpers, which replace them.
+type helperEnv struct {
+	Basename func(string) string `expr:"basename"`
+	Upper    string              `expr:"upper"`
+}
+
+func TestEvalExpressionsHelpers(t *testing.T) {
+	t.Parallel()
+
+	expr := func(s string) string {
+		return zapscript.TokExpStart + s + zapscript.TokExprEnd
+	}
+	env := zapscript.ArgExprEnv{
+		ActiveMedia: zapscript.ExprEnvActiveMedia{Path: "/games/snes/Super Mario.sfc", Name: "Super Mario"},
+		Scanned:     zapscript.ExprEnvScanned{Value: "  abc  "},
+		Device:      zapscript.ExprEnvDevice{OS: "Linux"},
+	}
+
+	tests := []struct {
+		env   any
+		name  string
+		input string
+		want  string
+		opts  []zapscript.EvalOption
+	}{
+		{name: "upper", input: expr(`upper(device.os)`), want: "LINUX"},
+		{name: "lower", input: expr(`lower(device.os)`), want: "linux"},
+		{name: "trim", input: "[" + expr(`trim(scanned.value)`) + "]", want: "[abc]"},
+		{name: "replace", input: expr(`replace(active_media.name, " ", "_")`), want: "Super_Mario"},
+		{name: "basename", input: expr(`basename(active_media.path)`), want: "Super Mario.sfc"},
+		{name: "basename trailing slash", input: expr(`basename("/games/snes/")`), want: "snes"},
+		{name: "basename root", input: expr(`basename("/")`), want: ""},
+		{name: "basename empty", input: expr(`basename("")`), want: ""},
+		{
+			name:  "basename windows",
+			input: expr(`basename("C:\\games\\zelda.nes")`),
+			opts:  []zapscript.EvalOption{zapscript.WithWindowsPaths()},
+			want:  "zelda.nes",
+		},
+		{name: "contains operator", input: expr(`active_media.name contains "Mario"`), want: "true"},
+		{name: "composition", input: expr(`upper(basename(active_media.path))`), want: "SUPER MARIO.SFC"},
+		{
+			name:  "map env",
+			env:   map[string]any{"path": "/a/b.rom"},
+			input: expr(`upper(basename(path))`),
+			want:  "B.ROM",
+		},
+		{
+			name:  "env fields win",
+			env:   helperEnv{Upper: "field", Basename: func(string) string { return "env" }},
+			input: expr(`upper`) + "," + expr(`basename("/a/b")`),
+			want:  "field,env",
+		},
+		{
+			name:  "map env fields win",
+			env:   map[string]any{"lower": func(s string) string { return s + "!" }},
+			input: expr(`lower("A")`),
+			want:  "A!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			exprEnv := tt.env
+			if exprEnv == nil {
+				exprEnv = env
+			}
+			got, err := zapscript.NewParser(tt.input).EvalExpressions(exprEnv, tt.opts...)
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalExpressionsDefault(t *testing.T) {
+	t.Parallel()
+
+	expr := func(s string) string {
+		return zapscript.TokExpStart + s + zapscript.TokExprEnd
+	}
+	argEnv := zapscript.ArgExprEnv{
+		ActiveMedia: zapscript.ExprEnvActiveMedia{Name: "Super Mario"},
+		Platform:    "mister",
+	}
+	mapEnv := map[string]any{
+		"name":  "",
+		"count": 0,
+		"tags":  []string{},
+		"nope":  nil,
+		"title": "Zelda",
+	}
+
+	tests := []struct {
+		env   any
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty string",
+			env:   argEnv,
+			input: expr(`default(active_media.system_name, "nothing")`),
+			want:  "nothing",
+		},
+		{
+			name:  "non-empty string",
+			env:   argEnv,
+			input: expr(`default(active_media.name, "nothing")`),
+			want:  "Super Mario",
+		},
+		{name: "numeric zero", env: argEnv, input: expr(`default(0, 5) + 1`), want: "6"},
+		{name: "non-zero number", env: argEnv, input: expr(`default(2, 5)`), want: "2"},
+		{name: "false", env: argEnv, input: expr(`default(media_playing, "idle")`), want: "idle"},
+		{name: "nested", env: argEnv, input: expr(`default(scan_mode, default(platform, "?"))`), want: "mister"},
+		{name: "map env empty string", env: mapEnv, input: expr(`default(name, "x")`), want: "x"},
+		{name: "map env zero", env: mapEnv, input: expr(`default(count, 1)`), want: "1"},
+		{name: "map env empty list", env: mapEnv, input: expr(`default(tags, "none")`), want: "none"},
+		{name: "map env nil", env: mapEnv, input: expr(`default(nope, "x")`), want: "x"},
+		{name: "map env set", env: mapEnv, input: expr(`default(title, "x")`), want: "Zelda"},
+		{name: "nil coalescing", env: mapEnv, input: expr(`nope ?? "x"`), want: "x"},
+		{name: "string map env", env: map[string]string{"v": ""}, input: expr(`default(v, "x")`), want: "x"},
+		{
+			name:  "env field wins",
+			env:   map[string]any{"default": func(a, b any) any { return "env" }},
+			input: expr(`default("a", "b")`),
+			want:  "env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := zapscript.NewParser(tt.input).EvalExpressions(tt.env)
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestEvalExpressionsMapEnv(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"username": "sam",
+		"total":    3,
+		"missing":  nil,
+		"user": map[string]any{
+			"name":  "Sam",
+			"prefs": map[string]any{"theme": "dark"},
+		},
+	}
+
+	tests := []struct {
+		wantErr  error
+		name     string
+		input    string
+		want     string
+		wantName string
+	}{
+		{name: "present key", input: "hi " + tokExpr("username"), want: "hi sam"},
+		{name: "arithmetic", input: tokExpr("total * 2"), want: "6"},
+		{name: "nested map", input: tokExpr("user.name"), want: "Sam"},
+		{name: "deeply nested map", input: tokExpr("user.prefs.theme"), want: "dark"},
+		{name: "index syntax", input: tokExpr(`user["name"]`), want: "Sam"},
+		{name: "nil value", input: "[" + tokExpr("missing") + "]", want: "[]"},
+		{name: "missing nested key", input: "[" + tokExpr("user.age") + "]", want: "[]"},
+		{name: "let variable", input: tokExpr(`let n = upper(username); n`), want: "SAM"},
+		{name: "helper call", input: tokExpr(`default(missing, "none")`), want: "none"},
+		{
+			name:     "absent key",
+			input:    tokExpr(`"hi " + nickname`),
+			wantErr:  zapscript.ErrUnknownVariable,
+			wantName: `unknown variable "nickname"`,
+		},
+		{
+			name:     "absent key in nested access",
+			input:    tokExpr("account.name"),
+			wantErr:  zapscript.ErrUnknownVariable,
+			wantName: `unknown variable "account"`,
+		},
+		{
+			name:     "absent key in condition",
+			input:    tokExpr(`total > 1 ? username : fallback`),
+			wantErr:  zapscript.ErrUnknownVariable,
+			wantName: `unknown variable "fallback"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(tt.input).EvalExpressions(env)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("EvalExpressions() error = %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantName) {
+					t.Errorf("EvalExpressions() error = %v, want it to contain %s", err, tt.wantName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalExpressionsStringMapEnv(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{"name": "sam"}
+
+	got, err := zapscript.NewParser(tokExpr("name")).EvalExpressions(env)
+	if err != nil {
+		t.Fatalf("EvalExpressions() unexpected error: %v", err)
+	}
+	if got != "sam" {
+		t.Errorf("EvalExpressions() = %q, want %q", got, "sam")
+	}
+
+	_, err = zapscript.NewParser(tokExpr("other")).EvalExpressions(env)
+	if !errors.Is(err, zapscript.ErrUnknownVariable) {
+		t.Errorf("EvalExpressions() error = %v, want ErrUnknownVariable", err)
+	}
+
+	cmd := zapscript.Command{
+		Name:    "cmd",
+		AdvArgs: zapscript.NewAdvArgs(map[string]string{"when": tokExpr(`other == "x"`)}),
+	}
+	if _, err := cmd.ShouldRun(env); !errors.Is(err, zapscript.ErrUnknownVariable) {
+		t.Errorf("ShouldRun() error = %v, want ErrUnknownVariable", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestEvalExpressionsPathFunctions(t *testing.T) {
+	t.Parallel()
+
+	expr := func(s string) string {
+		return zapscript.TokExpStart + s + zapscript.TokExprEnd
+	}
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		want    string
+		opts    []zapscript.EvalOption
+	}{
+		{
+			name:  "join parts",
+			input: expr(`pathjoin("games", "snes", "mario.sfc")`),
+			want:  "games/snes/mario.sfc",
+		},
+		{
+			name:  "join with env field",
+			input: expr(`pathjoin("/media", platform, "save.dat")`),
+			want:  "/media/mister/save.dat",
+		},
+		{
+			name:  "join collapses duplicate separators",
+			input: expr(`pathjoin("/games//", "/snes", "mario.sfc")`),
+			want:  "/games/snes/mario.sfc",
+		},
+		{
+			name:  "join no parts",
+			input: expr(`pathjoin()`),
+			want:  "",
+		},
+		{
+			name:  "clean dot segments",
+			input: expr(`pathclean("/games/./snes/../nes//zelda.nes")`),
+			want:  "/games/nes/zelda.nes",
+		},
+		{
+			name:  "clean traversal without sandbox",
+			input: expr(`pathclean("/games/../../etc/passwd")`),
+			want:  "/etc/passwd",
+		},
+		{
+			name:  "backslashes are literal by default",
+			input: expr(`pathclean("games\\snes")`),
+			want:  `games\snes`,
+		},
+		{
+			name:  "embedded in text",
+			input: "file:" + expr(`pathjoin("a", "b")`) + "!",
+			want:  "file:a/b!",
+		},
+		{
+			name:  "windows separators",
+			input: expr(`pathjoin("C:\\Games\\", "snes//mario.sfc")`),
+			opts:  []zapscript.EvalOption{zapscript.WithWindowsPaths()},
+			want:  `C:\Games\snes\mario.sfc`,
+		},
+		{
+			name:  "windows clean",
+			input: expr(`pathclean("C:/Games/../Roms\\\\nes")`),
+			opts:  []zapscript.EvalOption{zapscript.WithWindowsPaths()},
+			want:  `C:\Roms\nes`,
+		},
+		{
+			name:  "sandbox allows path inside root",
+			input: expr(`pathjoin("/media/games", "snes", "../nes/zelda.nes")`),
+			opts:  []zapscript.EvalOption{zapscript.WithSandboxRoot("/media/games")},
+			want:  "/media/games/nes/zelda.nes",
+		},
+		{
+			name:  "sandbox allows root itself",
+			input: expr(`pathclean("/media/games/")`),
+			opts:  []zapscript.EvalOption{zapscript.WithSandboxRoot("/media/games")},
+			want:  "/media/games",
+		},
+		{
+			name:  "sandbox resolves relative paths against root",
+			input: expr(`pathjoin("snes", "mario.sfc")`),
+			opts:  []zapscript.EvalOption{zapscript.WithSandboxRoot("/media/games")},
+			want:  "snes/mario.sfc",
+		},
+		{
+			name:    "sandbox rejects absolute traversal",
+			input:   expr(`pathjoin("/media/games", "..", "..", "etc/passwd")`),
+			opts:    []zapscript.EvalOption{zapscript.WithSandboxRoot("/media/games")},
+			wantErr: zapscript.ErrPathEscapesSandbox,
+		},
+		{
+			name:    "sandbox rejects relative traversal",
+			input:   expr(`pathclean("snes/../../secret")`),
+			opts:    []zapscript.EvalOption{zapscript.WithSandboxRoot("/media/games")},
+			wantErr: zapscript.ErrPathEscapesSandbox,
+		},
+		{
+			name:    "sandbox rejects sibling with shared prefix",
+			input:   expr(`pathclean("/media/games2/rom.bin")`),
+			opts:    []zapscript.EvalOption{zapscript.WithSandboxRoot("/media/games")},
+			wantErr: zapscript.ErrPathEscapesSandbox,
+		},
+		{
+			name:    "sandbox rejects unrelated absolute path",
+			input:   expr(`pathclean("/etc/passwd")`),
+			opts:    []zapscript.EvalOption{zapscript.WithSandboxRoot("/media/games")},
+			wantErr: zapscript.ErrPathEscapesSandbox,
+		},
+		{
+			name:  "windows sandbox is case insensitive",
+			input: expr(`pathjoin("c:\\games", "SNES")`),
+			opts: []zapscript.EvalOption{
+				zapscript.WithWindowsPaths(),
+				zapscript.WithSandboxRoot(`C:\Games`),
+			},
+			want: `c:\games\SNES`,
+		},
+		{
+			name:  "windows sandbox rejects traversal",
+			input: expr(`pathclean("C:\\Games\\..\\Windows\\system.ini")`),
+			opts: []zapscript.EvalOption{
+				zapscript.WithWindowsPaths(),
+				zapscript.WithSandboxRoot(`C:\Games`),
+			},
+			wantErr: zapscript.ErrPathEscapesSandbox,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			env := zapscript.ArgExprEnv{Platform: "mister"}
+
+			got, err := zapscript.NewParser(tt.input).EvalExpressions(env, tt.opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("EvalExpressions() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalExpressionsPathFunctionsBadArgs(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		`pathjoin("a", 1)`,
+		`pathclean(5)`,
+		`pathclean()`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			p := zapscript.NewParser(zapscript.TokExpStart + input + zapscript.TokExprEnd)
+			if _, err := p.EvalExpressions(zapscript.ArgExprEnv{}); err == nil {
+				t.Errorf("EvalExpressions() expected error for %s", input)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestEvalExpressionsResultTypes(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"value":   "a,b,c",
+		"i64":     int64(-9007199254740993),
+		"u":       uint(42),
+		"u8":      uint8(7),
+		"f32":     float32(1.5),
+		"strs":    []string{"x", "y"},
+		"arr":     [2]int{1, 2},
+		"obj":     map[string]any{"k": []any{1, "&"}},
+		"ints":    map[string]int{"b": 2, "a": 1},
+		"intkeys": map[int]string{1: "a"},
+		"funcs":   []any{func() {}},
+		"ch":      make(chan int),
+	}
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		want    string
+	}{
+		{name: "split result", input: `split(value, ",")`, want: `["a","b","c"]`},
+		{name: "any slice literal", input: `[1, "two", true, nil]`, want: `[1,"two",true,null]`},
+		{name: "string slice", input: `strs`, want: `["x","y"]`},
+		{name: "array", input: `arr`, want: `[1,2]`},
+		{name: "map literal", input: `{"a": 1}`, want: `{"a":1}`},
+		{name: "nested map without HTML escaping", input: `obj`, want: `{"k":[1,"&"]}`},
+		{name: "typed map sorted keys", input: `ints`, want: `{"a":1,"b":2}`},
+		{name: "empty slice", input: `filter(strs, # == "z")`, want: `[]`},
+		{name: "int64", input: `i64`, want: "-9007199254740993"},
+		{name: "uint", input: `u`, want: "42"},
+		{name: "uint8", input: `u8`, want: "7"},
+		{name: "float32", input: `f32`, want: "1.5"},
+		{name: "map with int keys", input: `intkeys`, wantErr: zapscript.ErrBadExpressionReturn},
+		{name: "slice of funcs", input: `funcs`, wantErr: zapscript.ErrBadExpressionReturn},
+		{name: "channel", input: `ch`, wantErr: zapscript.ErrBadExpressionReturn},
+		{name: "nil", input: `nil`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := zapscript.NewParser(zapscript.TokExpStart + tt.input + zapscript.TokExprEnd)
+			got, err := p.EvalExpressions(env)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("EvalExpressions() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalExpressionsFloatFormatting(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"f32":  float32(0.1),
+		"tiny": 1e-7,
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		opts  []zapscript.EvalOption
+	}{
+		{name: "one third", input: `1 / 3`, want: "0.333333"},
+		{name: "ten thirds", input: `10 / 3`, want: "3.333333"},
+		{name: "two and a half", input: `5 / 2`, want: "2.5"},
+		{name: "integral float", input: `4.0`, want: "4"},
+		{name: "integral division", input: `8 / 2`, want: "4"},
+		{name: "negative", input: `-7 / 4`, want: "-1.75"},
+		{name: "rounds up", input: `2 / 3`, want: "0.666667"},
+		{name: "float32", input: `f32`, want: "0.1"},
+		{name: "large without exponent", input: `1e20 / 2`, want: "50000000000000000000"},
+		{name: "huge uses exponent", input: `1e21 * 3`, want: "3e+21"},
+		{name: "small rounds to zero", input: `tiny`, want: "0"},
+		{name: "negative small rounds to zero", input: `-tiny`, want: "0"},
+		{name: "small without exponent", input: `tiny * 10`, want: "0.000001"},
+		{
+			name:  "custom precision",
+			input: `1 / 3`,
+			want:  "0.33",
+			opts:  []zapscript.EvalOption{zapscript.WithFloatPrecision(2)},
+		},
+		{
+			name:  "custom precision trims zeros",
+			input: `1 / 4`,
+			want:  "0.25",
+			opts:  []zapscript.EvalOption{zapscript.WithFloatPrecision(4)},
+		},
+		{
+			name:  "shortest representation",
+			input: `10 / 3`,
+			want:  "3.3333333333333335",
+			opts:  []zapscript.EvalOption{zapscript.WithFloatPrecision(-1)},
+		},
+		{
+			name:  "shortest representation of small value",
+			input: `tiny`,
+			want:  "0.0000001",
+			opts:  []zapscript.EvalOption{zapscript.WithFloatPrecision(-1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := zapscript.NewParser(zapscript.TokExpStart + tt.input + zapscript.TokExprEnd)
+			got, err := p.EvalExpressions(env, tt.opts...)
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestEvalExpressionsTime(t *testing.T) {
+	t.Parallel()
+
+	expr := func(s string) string {
+		return zapscript.TokExpStart + s + zapscript.TokExprEnd
+	}
+	evening := zapscript.ArgExprEnv{Now: time.Date(2026, 3, 14, 19, 45, 0, 0, time.UTC)}
+	night := zapscript.ArgExprEnv{Now: time.Date(2026, 3, 15, 20, 5, 0, 0, time.UTC)}
+
+	tests := []struct {
+		env     any
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "before 8pm", env: evening, input: expr(`hour(now) < 20`), want: "true"},
+		{name: "after 8pm", env: night, input: expr(`hour(now) < 20`), want: "false"},
+		{name: "hour", env: evening, input: expr(`hour(now)`), want: "19"},
+		{name: "weekday", env: evening, input: expr(`weekday(now)`), want: "Saturday"},
+		{
+			name:  "weekend",
+			env:   night,
+			input: expr(`weekday(now) in ["Saturday", "Sunday"]`),
+			want:  "true",
+		},
+		{name: "format", env: evening, input: expr(`format_time(now, "2006-01-02 15:04")`), want: "2026-03-14 19:45"},
+		{name: "time methods", env: evening, input: expr(`now.Minute()`), want: "45"},
+		{
+			name:  "built-in now without env field",
+			env:   zapscript.CustomLauncherExprEnv{},
+			input: expr(`hour(now()) >= 0`),
+			want:  "true",
+		},
+		{name: "zero time", env: zapscript.ArgExprEnv{}, input: expr(`hour(now)`), want: "0"},
+		{
+			name:  "map env",
+			env:   map[string]any{"t": time.Date(2026, 1, 1, 7, 0, 0, 0, time.UTC)},
+			input: expr(`hour(t)`),
+			want:  "7",
+		},
+		{
+			name:  "env field wins",
+			env:   map[string]any{"hour": 3},
+			input: expr(`hour`),
+			want:  "3",
+		},
+		{name: "not a time", env: evening, input: expr(`hour("19:00")`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := zapscript.NewParser(tt.input).EvalExpressions(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("EvalExpressions() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgExprEnvNowJSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(zapscript.ArgExprEnv{})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), `"now"`) {
+		t.Errorf("json.Marshal() = %s, want now omitted when zero", data)
+	}
+
+	now := time.Date(2026, 3, 14, 19, 45, 0, 0, time.UTC)
+	data, err = json.Marshal(zapscript.ArgExprEnv{Now: now})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), `"now":"2026-03-14T19:45:00Z"`) {
+		t.Errorf("json.Marshal() = %s, want now set", data)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+)
+
+var errorType = reflect.TypeFor[error]()
+
+// ExprFuncDefault is the name of the default(value, fallback) expression
+// function, see EvalExpressions.
+const ExprFuncDefault = "default"
+
+// shadowableBuiltins are the expr built-in functions documented on
+// EvalExpressions, and now, which ArgExprEnv.Now replaces. They are disabled
+// for an env with a field of the same name so the field is used instead.
+var shadowableBuiltins = []string{"upper", "lower", "trim", "replace", "now"}
+
+// replaceableHelpers are the functions this package adds to expressions that
+// are left out for an env with a field of the same name.
+var replaceableHelpers = []string{
+	ExprFuncBasename, ExprFuncDefault, ExprFuncWeekday, ExprFuncHour, ExprFuncFormatTime,
+}
+
+// defaultFunction returns the default expression function, or nothing if
+// exprEnv has a field of that name, see envHasName.
+func defaultFunction(exprEnv any) []expr.Option {
+	if envHasName(exprEnv, ExprFuncDefault) {
+		return nil
+	}
+	return []expr.Option{
+		expr.Function(ExprFuncDefault, func(params ...any) (any, error) {
+			if isEmptyValue(params[0]) {
+				return params[1], nil
+			}
+			return params[0], nil
+		}, new(func(any, any) any)),
+	}
+}
+
+// isEmptyValue reports whether v is nil, the zero value of its type, such as
+// "", false or 0, or an empty slice or map.
+func isEmptyValue(v any) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	default:
+		return rv.IsZero()
+	}
+}
+
+// envHasName reports whether name is a field, method or map key of an
+// expression env, using the same names as expr: the expr tag if set,
+// otherwise the Go name.
+func envHasName(env any, name string) bool {
+	v := reflect.ValueOf(env)
+	if !v.IsValid() {
+		return false
+	}
+	if _, ok := v.Type().MethodByName(name); ok {
+		return true
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		return v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())).IsValid()
+	case reflect.Struct:
+		_, ok := structFieldNames(v.Type())[name]
+		return ok
+	}
+	return false
+}
+
+// envFieldNames caches structFieldNames by type, as envs are usually the
+// same struct for every evaluation.
+var envFieldNames sync.Map
+
+// structFieldNames returns the expr names of the exported fields of the
+// struct type t, including promoted ones.
+func structFieldNames(t reflect.Type) map[string]struct{} {
+	if names, ok := envFieldNames.Load(t); ok {
+		cached, _ := names.(map[string]struct{})
+		return cached
+	}
+
+	names := make(map[string]struct{})
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		fieldName := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("expr"), ","); tag != "" {
+			fieldName = tag
+		}
+		names[fieldName] = struct{}{}
+	}
+	envFieldNames.Store(t, names)
+	return names
+}
+
+// customFunctions returns expression functions for EvalOptions.Functions,
+// in name order so compile errors are deterministic.
+func customFunctions(funcs map[string]any) ([]expr.Option, error) {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	opts := make([]expr.Option, 0, len(names))
+	for _, name := range names {
+		if name == ExprFuncPathJoin || name == ExprFuncPathClean {
+			return nil, fmt.Errorf("%w: %q is a built-in function", ErrInvalidExprFunction, name)
+		}
+		fn := reflect.ValueOf(funcs[name])
+		if fn.Kind() != reflect.Func || fn.IsNil() {
+			return nil, fmt.Errorf("%w: %q is %T, not a function", ErrInvalidExprFunction, name, funcs[name])
+		}
+		fnType := fn.Type()
+		switch {
+		case fnType.NumOut() == 1:
+		case fnType.NumOut() == 2 && fnType.Out(1) == errorType:
+		default:
+			return nil, fmt.Errorf("%w: %q must return a value or a value and an error",
+				ErrInvalidExprFunction, name)
+		}
+		opts = append(opts, expr.Function(name, callFunction(name, fn), funcs[name]))
+	}
+	return opts, nil
+}
+
+// callFunction adapts fn to expr's function signature. The expression has
+// already been type checked against fn, so params only need converting
+// between compatible types, such as an int literal for a float64 param.
+func callFunction(name string, fn reflect.Value) func(params ...any) (any, error) {
+	fnType := fn.Type()
+	return func(params ...any) (any, error) {
+		in := make([]reflect.Value, len(params))
+		for i, p := range params {
+			var want reflect.Type
+			switch {
+			case fnType.IsVariadic() && i >= fnType.NumIn()-1:
+				want = fnType.In(fnType.NumIn() - 1).Elem()
+			default:
+				want = fnType.In(i)
+			}
+			if p == nil {
+				in[i] = reflect.Zero(want)
+				continue
+			}
+			v := reflect.ValueOf(p)
+			if !v.Type().AssignableTo(want) {
+				if !v.CanConvert(want) {
+					return nil, fmt.Errorf("%s: argument %d: cannot use %T as %s", name, i+1, p, want)
+				}
+				v = v.Convert(want)
+			}
+			in[i] = v
+		}
+
+		out := fn.Call(in)
+		if len(out) == 2 && !out[1].IsNil() {
+			err, _ := out[1].Interface().(error)
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return out[0].Interface(), nil
+	}
+}
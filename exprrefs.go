@@ -0,0 +1,209 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+)
+
+// Expressions returns the source of every expression in the script, without
+// the [[ ]] delimiters, in document order: each command's args, then its adv
+// args, then string trait values sorted by key.
+func (s Script) Expressions() []string {
+	var exprs []string
+	for _, cmd := range s.Cmds {
+		for _, arg := range cmd.Args {
+			exprs = appendValueExpressions(exprs, arg)
+		}
+		for _, key := range cmd.AdvArgs.OrderedKeys() {
+			exprs = appendValueExpressions(exprs, cmd.AdvArgs.Get(key))
+		}
+	}
+	return appendTraitExpressions(exprs, s.Traits)
+}
+
+// appendTraitExpressions appends the expressions found in string trait
+// values, descending into arrays and objects.
+func appendTraitExpressions(exprs []string, v any) []string {
+	switch tv := v.(type) {
+	case string:
+		return appendValueExpressions(exprs, tv)
+	case []any:
+		for _, elem := range tv {
+			exprs = appendTraitExpressions(exprs, elem)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(tv))
+		for k := range tv {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			exprs = appendTraitExpressions(exprs, tv[k])
+		}
+	}
+	return exprs
+}
+
+// ReferencedEnvFields returns the sorted, deduplicated env fields read by the
+// script's expressions, as dotted paths such as "active_media.launcher_id".
+// A path stops at the first index or method call, so scanned.ids is
+// reported for [[scanned.ids[0].value]]. Hosts can use it to skip building env data no
+// expression needs. Expressions that fail to parse are skipped.
+func ReferencedEnvFields(script Script) []string {
+	fields := make(map[string]bool)
+	for _, src := range script.Expressions() {
+		exprFields, err := expressionEnvFields(src)
+		if err != nil {
+			continue
+		}
+		for _, field := range exprFields {
+			fields[field] = true
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for f := range fields {
+		names = append(names, f)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// expressionEnvFields returns the env field paths read by the expression
+// src, in no particular order, or an error if it does not parse.
+func expressionEnvFields(src string) ([]string, error) {
+	tree, err := parser.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &envFieldCollector{
+		envIdentCollector: envIdentCollector{
+			callees:  make(map[ast.Node]bool),
+			declared: make(map[string]bool),
+		},
+	}
+	ast.Walk(&tree.Node, c)
+	return c.fields(), nil
+}
+
+// checkAllowedFields returns an ErrFieldNotAllowed error naming the first
+// env field read by the expression src that is not in allowed, see
+// EvalOptions.AllowedFields. Expressions that fail to parse are left for the
+// compiler to report.
+func checkAllowedFields(src string, allowed []string) error {
+	fields, err := expressionEnvFields(src)
+	if err != nil {
+		return nil
+	}
+	slices.Sort(fields)
+	for _, field := range fields {
+		// $env.name reads the same field as name, $env alone reads them all
+		field = strings.TrimPrefix(field, envVariable+".")
+		if !fieldAllowed(field, allowed) {
+			return fmt.Errorf("%w: %s", ErrFieldNotAllowed, field)
+		}
+	}
+	return nil
+}
+
+// envVariable is the expr variable holding the whole env.
+const envVariable = "$env"
+
+// fieldAllowed reports whether field is an entry of allowed or a field
+// nested under one.
+func fieldAllowed(field string, allowed []string) bool {
+	for _, a := range allowed {
+		if field == a || strings.HasPrefix(field, a+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// envFieldCollector extends envIdentCollector with the member accesses
+// needed to report dotted field paths.
+type envFieldCollector struct {
+	envIdentCollector
+	members []*ast.MemberNode
+}
+
+func (c *envFieldCollector) Visit(node *ast.Node) {
+	if n, ok := (*node).(*ast.MemberNode); ok {
+		c.members = append(c.members, n)
+	}
+	c.envIdentCollector.Visit(node)
+}
+
+// fields returns the env field paths found by the walk. ast.Walk visits a
+// member access after its operand, so the outermost access of each chain
+// is handled first when iterating backwards.
+func (c *envFieldCollector) fields() []string {
+	var fields []string
+	inChain := make(map[ast.Node]bool)
+	for i := len(c.members) - 1; i >= 0; i-- {
+		m := c.members[i]
+		if inChain[m] {
+			continue
+		}
+		path, root, ok := memberPath(m, inChain)
+		if ok && !c.callees[root] && !c.declared[root.Value] {
+			fields = append(fields, path)
+		}
+	}
+	for _, id := range c.idents {
+		if !inChain[id] && !c.callees[id] && !c.declared[id.Value] {
+			fields = append(fields, id.Value)
+		}
+	}
+	return fields
+}
+
+// memberPath returns the dotted path of a chain of member accesses on an
+// identifier, ending at the first access that is not a plain field, and
+// marks the chain's nodes in inChain. ok is false if the chain does not
+// start at an identifier.
+func memberPath(node ast.Node, inChain map[ast.Node]bool) (path string, root *ast.IdentifierNode, ok bool) {
+	var names []string
+	for {
+		inChain[node] = true
+		switch n := node.(type) {
+		case *ast.IdentifierNode:
+			path = n.Value
+			for i := len(names) - 1; i >= 0; i-- {
+				path += "." + names[i]
+			}
+			return path, n, true
+		case *ast.ChainNode:
+			node = n.Node
+		case *ast.MemberNode:
+			prop, isField := n.Property.(*ast.StringNode)
+			if !isField || n.Method {
+				names = names[:0]
+			} else {
+				names = append(names, prop.Value)
+			}
+			node = n.Node
+		default:
+			return "", nil, false
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScriptExpressions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "none",
+			input: "**launch:/roms/game.bin",
+		},
+		{
+			name:  "args then adv args",
+			input: "**echo:[[a]]x[[b]],[[c]]?when=[[d]]&launcher=[[e]]||**echo:[[f]]",
+			want:  []string{"a", "b", "c", "d", "e", "f"},
+		},
+		{
+			name:  "traits",
+			input: `**launch:x||#b=["[[device.os]]",1] #a=[[y]] #c=^[[x]]`,
+			want:  []string{"y", "device.os"},
+		},
+		{
+			name:  "json traits are literal",
+			input: `**traits:{"a":"[[x]]"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, script.Expressions()); diff != "" {
+				t.Errorf("Expressions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReferencedEnvFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "none",
+			input: "**launch:[[1 + 2]]",
+			want:  []string{},
+		},
+		{
+			name:  "arg",
+			input: "**launch:[[active_media.launcher_id]]",
+			want:  []string{"active_media.launcher_id"},
+		},
+		{
+			name:  "adv arg",
+			input: "**launch:x?when=[[media_playing && device.os == 'linux']]",
+			want:  []string{"device.os", "media_playing"},
+		},
+		{
+			name:  "trait",
+			input: "#id=[[last_scanned.uid]]",
+			want:  []string{"last_scanned.uid"},
+		},
+		{
+			name:  "deduplicated",
+			input: "**echo:[[platform]],[[platform + platform]]?when=[[platform != '']]||**echo:[[platform]]",
+			want:  []string{"platform"},
+		},
+		{
+			name:  "whole object and field",
+			input: "**echo:[[active_media != nil ? active_media.path : '']]",
+			want:  []string{"active_media", "active_media.path"},
+		},
+		{
+			name:  "path stops at index",
+			input: "**echo:[[device['os'].x]],[[scanned.ids[0].y]],[[launching[key] ]]",
+			want:  []string{"device.os.x", "key", "launching", "scanned.ids"},
+		},
+		{
+			name:  "optional chaining",
+			input: "**echo:[[active_media?.system_id]]",
+			want:  []string{"active_media.system_id"},
+		},
+		{
+			name:  "functions and variables skipped",
+			input: "**echo:[[basename(active_media.path)]],[[let p = device; p.os]],[[upper(platform)]]",
+			want:  []string{"active_media.path", "device", "platform"},
+		},
+		{
+			name:  "invalid expression skipped",
+			input: "**echo:[[platform +]],[[version]]",
+			want:  []string{"version"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, zapscript.ReferencedEnvFields(script)); diff != "" {
+				t.Errorf("ReferencedEnvFields() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/expr-lang/expr/ast"
+)
+
+// traitsVariable is the name expressions read injected traits from, see
+// EvalOptions.Traits.
+const traitsVariable = "traits"
+
+// injectsTraits reports whether expressions evaluated against exprEnv read
+// traitsVariable from opts.Traits rather than from the env.
+func injectsTraits(opts EvalOptions, exprEnv any) bool {
+	return opts.Traits != nil && !envHasName(exprEnv, traitsVariable)
+}
+
+// traitsPatcher replaces reads of traitsVariable with a constant holding the
+// traits, except where a let declaration of the same name is in scope.
+type traitsPatcher struct {
+	traits map[string]any
+}
+
+func (p traitsPatcher) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		if n.Value == traitsVariable {
+			ast.Patch(node, &ast.ConstantNode{Value: p.traits})
+		}
+	case *ast.VariableDeclaratorNode:
+		// the walk visits the declaration after its body, which has already
+		// been patched
+		if n.Name == traitsVariable {
+			ast.Walk(&n.Expr, traitsReverter{traits: p.traits})
+		}
+	}
+}
+
+// traitsReverter undoes traitsPatcher in the body of a let declaration.
+type traitsReverter struct {
+	traits map[string]any
+}
+
+func (r traitsReverter) Visit(node *ast.Node) {
+	c, ok := (*node).(*ast.ConstantNode)
+	if !ok {
+		return
+	}
+	// compare by identity, a map literal in the expression is not traits
+	m, isMap := c.Value.(map[string]any)
+	if isMap && reflect.ValueOf(m).Pointer() == reflect.ValueOf(r.traits).Pointer() {
+		ast.Patch(node, &ast.IdentifierNode{Value: traitsVariable})
+	}
+}
+
+// EvalExpressions returns a copy of the script with the expressions in its
+// args, adv args and traits evaluated against exprEnv, as
+// ScriptReader.EvalExpressions and EvalTraits do. Expressions in commands
+// can read the evaluated traits as traits, e.g. [[traits.difficulty]], see
+// EvalOptions.Traits; a field of exprEnv named traits takes precedence.
+// Errors in commands are *EvalError values naming the failing command and
+// arg or adv arg.
+func (s Script) EvalExpressions(exprEnv any, opts ...EvalOption) (Script, error) {
+	traits, err := s.EvalTraits(exprEnv, opts...)
+	if err != nil {
+		return Script{}, err
+	}
+	if traits == nil {
+		traits = map[string]any{}
+	}
+	opts = append(opts[:len(opts):len(opts)], WithTraits(traits))
+
+	eval := func(value string) (string, error) {
+		if !strings.Contains(value, TokExpStart) {
+			return value, nil
+		}
+		return NewParser(value).EvalExpressions(exprEnv, opts...)
+	}
+
+	out := s.Clone()
+	if s.Traits != nil {
+		out.Traits = traits
+	}
+	for i := range out.Cmds {
+		cmd := &out.Cmds[i]
+		for j, arg := range cmd.Args {
+			evaluated, evalErr := eval(arg)
+			if evalErr != nil {
+				return Script{}, locateEvalError(evalErr, i, cmd.Name, j, "")
+			}
+			cmd.Args[j] = evaluated
+		}
+		for k, v := range cmd.AdvArgs.raw {
+			evaluated, evalErr := eval(v)
+			if evalErr != nil {
+				return Script{}, locateEvalError(evalErr, i, cmd.Name, -1, Key(k))
+			}
+			cmd.AdvArgs.raw[k] = evaluated
+		}
+		for k, values := range cmd.AdvArgs.repeats {
+			for j, v := range values {
+				evaluated, evalErr := eval(v)
+				if evalErr != nil {
+					return Script{}, locateEvalError(evalErr, i, cmd.Name, -1, Key(k))
+				}
+				values[j] = evaluated
+			}
+		}
+	}
+	return out, nil
+}
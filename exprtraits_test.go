@@ -0,0 +1,242 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScriptEvalExpressionsTraits(t *testing.T) {
+	t.Parallel()
+
+	env := zapscript.ArgExprEnv{Platform: "mister"}
+
+	tests := []struct {
+		name     string
+		input    string
+		wantArgs []string
+	}{
+		{
+			name:     "string trait",
+			input:    `#difficulty=hard||**launch:[[traits.difficulty == "hard" ? "hard.rom" : "easy.rom"]]`,
+			wantArgs: []string{"hard.rom"},
+		},
+		{
+			name:     "int64 trait",
+			input:    `#lives=3||**echo:[[traits.lives + 1]]`,
+			wantArgs: []string{"4"},
+		},
+		{
+			name:     "array trait indexed",
+			input:    `#games=[a.rom,b.rom]||**launch:[[traits.games[1]]]`,
+			wantArgs: []string{"b.rom"},
+		},
+		{
+			name:     "array trait length",
+			input:    `#games=[a.rom,b.rom]||**echo:[[len(traits.games)]]`,
+			wantArgs: []string{"2"},
+		},
+		{
+			name:     "absent trait key",
+			input:    `#difficulty=hard||**echo:x[[traits.speed]]y`,
+			wantArgs: []string{"xy"},
+		},
+		{
+			name:     "absent trait key with default",
+			input:    `**echo:[[default(traits.speed, "normal")]]`,
+			wantArgs: []string{"normal"},
+		},
+		{
+			name:     "trait with expression",
+			input:    `#dir=[["/media/" + platform]]||**launch:[[traits.dir + "/game.rom"]]`,
+			wantArgs: []string{"/media/mister/game.rom"},
+		},
+		{
+			name:     "env fields alongside traits",
+			input:    `#name=mario||**echo:[[platform + "/" + traits.name]]`,
+			wantArgs: []string{"mister/mario"},
+		},
+		{
+			name:     "let shadows traits",
+			input:    `#a=1||**echo:[[let traits = {"a": 2}; traits.a]]`,
+			wantArgs: []string{"2"},
+		},
+		{
+			name:     "traits before let",
+			input:    `#a=1||**echo:[[let x = traits.a; let traits = {"a": 2}; x + traits.a]]`,
+			wantArgs: []string{"3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			got, err := script.EvalExpressions(env)
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantArgs, got.Cmds[0].Args); diff != "" {
+				t.Errorf("EvalExpressions() args mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScriptEvalExpressionsResult(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser(
+		`#dir=[[platform]] #n=2||**launch:[[traits.dir]]/game.rom?when=[[traits.n > 1]]&system=snes`,
+	).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	original := script.Clone()
+
+	got, err := script.EvalExpressions(zapscript.ArgExprEnv{Platform: "mister"})
+	if err != nil {
+		t.Fatalf("EvalExpressions() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"mister/game.rom"}, got.Cmds[0].Args); diff != "" {
+		t.Errorf("args mismatch (-want +got):\n%s", diff)
+	}
+	wantAdv := zapscript.NewAdvArgs(map[string]string{"when": "true", "system": "snes"})
+	if !got.Cmds[0].AdvArgs.Equal(wantAdv) {
+		t.Errorf("adv args = %v, want %v", got.Cmds[0].AdvArgs.Raw(), wantAdv.Raw())
+	}
+	if diff := cmp.Diff(map[string]any{"dir": "mister", "n": int64(2)}, got.Traits); diff != "" {
+		t.Errorf("traits mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(original, script, diffOpts); diff != "" {
+		t.Errorf("EvalExpressions() modified the script (-want +got):\n%s", diff)
+	}
+}
+
+func TestScriptEvalExpressionsRepeatedKeys(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse(`**launch.random:snes?tag=[[platform]]&tag=b[[1+1]]`)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	before := script.Cmds[0].AdvArgs.GetAll("tag")
+
+	got, err := script.EvalExpressions(zapscript.ArgExprEnv{Platform: "mister"})
+	if err != nil {
+		t.Fatalf("EvalExpressions() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"mister", "b2"}, got.Cmds[0].AdvArgs.GetAll("tag")); diff != "" {
+		t.Errorf("GetAll() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(before, script.Cmds[0].AdvArgs.GetAll("tag")); diff != "" {
+		t.Errorf("EvalExpressions() modified the script (-want +got):\n%s", diff)
+	}
+}
+
+func TestScriptEvalExpressionsError(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser(`**echo:ok||**echo:[[nosuch]]`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	_, err = script.EvalExpressions(map[string]any{})
+	if !errors.Is(err, zapscript.ErrUnknownVariable) {
+		t.Fatalf("EvalExpressions() error = %v, want ErrUnknownVariable", err)
+	}
+	if want := "command 2 (echo) arg 1: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("EvalExpressions() error = %q, want prefix %q", err, want)
+	}
+}
+
+func TestEvalExpressionsWithTraits(t *testing.T) {
+	t.Parallel()
+
+	traits := map[string]any{"difficulty": "hard"}
+	input := tokExpr("traits.difficulty")
+
+	tests := []struct {
+		env  any
+		name string
+		want string
+		opts []zapscript.EvalOption
+	}{
+		{
+			name: "struct env",
+			env:  zapscript.ArgExprEnv{},
+			opts: []zapscript.EvalOption{zapscript.WithTraits(traits)},
+			want: "hard",
+		},
+		{
+			name: "map env",
+			env:  map[string]any{"platform": "mister"},
+			opts: []zapscript.EvalOption{zapscript.WithTraits(traits)},
+			want: "hard",
+		},
+		{
+			name: "map env traits key wins",
+			env:  map[string]any{"traits": map[string]any{"difficulty": "easy"}},
+			opts: []zapscript.EvalOption{zapscript.WithTraits(traits)},
+			want: "easy",
+		},
+		{
+			name: "struct env traits field wins",
+			env: struct {
+				Traits map[string]string `expr:"traits"`
+			}{Traits: map[string]string{"difficulty": "easy"}},
+			opts: []zapscript.EvalOption{zapscript.WithTraits(traits)},
+			want: "easy",
+		},
+		{
+			name: "allowed fields",
+			env:  zapscript.ArgExprEnv{},
+			opts: []zapscript.EvalOption{
+				zapscript.WithTraits(traits),
+				zapscript.WithAllowedFields([]string{"platform"}),
+			},
+			want: "hard",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(input).EvalExpressions(tt.env, tt.opts...)
+			if err != nil {
+				t.Fatalf("EvalExpressions() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalExpressions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	_, err := zapscript.NewParser(input).EvalExpressions(map[string]any{})
+	if !errors.Is(err, zapscript.ErrUnknownVariable) {
+		t.Errorf("EvalExpressions() without traits error = %v, want ErrUnknownVariable", err)
+	}
+}
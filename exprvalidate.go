@@ -0,0 +1,68 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"github.com/expr-lang/expr"
+)
+
+// ValidateExpressions compiles every expression in script against the type
+// of envSample, such as ArgExprEnv{} or CustomLauncherExprEnv{}, without
+// running anything, so editors can report syntax errors and unknown fields
+// before a script is used. Each invalid expression is reported as an
+// *ExpressionError, in command and arg order; a nil result means all are
+// valid. A nil envSample only checks syntax. opts supply the custom
+// functions and options the expressions will be evaluated with, and an
+// invalid custom function is returned as the only error.
+func ValidateExpressions(script Script, envSample any, opts ...EvalOption) []error {
+	var evalOpts EvalOptions
+	for _, opt := range opts {
+		opt(&evalOpts)
+	}
+	custom, err := customFunctions(evalOpts.Functions)
+	if err != nil {
+		return []error{err}
+	}
+	compileOpts := compileOptions(evalOpts, custom, envSample)
+	if envSample != nil {
+		compileOpts = append(compileOpts, expr.Env(envSample))
+	}
+
+	var errs []error
+	check := func(value string, where ExpressionError) {
+		for _, part := range SplitArgParts(value) {
+			if part.Type != ArgPartTypeExpression {
+				continue
+			}
+			if _, compileErr := compileExpression(part.Value, envSample, evalOpts, compileOpts); compileErr != nil {
+				exprErr := where
+				exprErr.Expression = part.Value
+				exprErr.Err = compileErr
+				errs = append(errs, &exprErr)
+			}
+		}
+	}
+
+	for i, cmd := range script.Cmds {
+		for j, arg := range cmd.Args {
+			check(arg, ExpressionError{CmdIndex: i, CmdName: cmd.Name, ArgIndex: j})
+		}
+		for _, key := range cmd.AdvArgs.OrderedKeys() {
+			check(cmd.AdvArgs.Get(key), ExpressionError{CmdIndex: i, CmdName: cmd.Name, AdvArg: key})
+		}
+	}
+	return errs
+}
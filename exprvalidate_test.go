@@ -0,0 +1,155 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateExpressions(t *testing.T) {
+	t.Parallel()
+
+	type want struct {
+		expression string
+		advArg     zapscript.Key
+		cmdIndex   int
+		argIndex   int
+	}
+
+	tests := []struct {
+		env   any
+		name  string
+		input string
+		want  []want
+	}{
+		{
+			name:  "valid",
+			env:   zapscript.ArgExprEnv{},
+			input: "**launch:/roms/[[platform]]/[[basename(active_media.path)]]?when=[[media_playing]]||**stop",
+		},
+		{
+			name:  "typo",
+			env:   zapscript.ArgExprEnv{},
+			input: "**launch:/roms/[[platfrom]]",
+			want:  []want{{expression: "platfrom"}},
+		},
+		{
+			name:  "each error located",
+			env:   zapscript.ArgExprEnv{},
+			input: "**stop||**echo:a,[[1 +]],[[device.oss]]?when=[[nope]]",
+			want: []want{
+				{expression: "1 +", cmdIndex: 1, argIndex: 1},
+				{expression: "device.oss", cmdIndex: 1, argIndex: 2},
+				{expression: "nope", cmdIndex: 1, advArg: "when"},
+			},
+		},
+		{
+			name:  "custom launcher env",
+			env:   zapscript.CustomLauncherExprEnv{},
+			input: "**launch:[[media_path]]?action=[[upper(action)]]",
+		},
+		{
+			name:  "field of the other env",
+			env:   zapscript.CustomLauncherExprEnv{},
+			input: "**launch:[[active_media.path]]",
+			want:  []want{{expression: "active_media.path"}},
+		},
+		{
+			name:  "nil env checks syntax only",
+			input: "**launch:[[anything.goes]],[[(]]",
+			want:  []want{{expression: "(", argIndex: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+
+			var got []want
+			for _, err := range zapscript.ValidateExpressions(script, tt.env) {
+				var exprErr *zapscript.ExpressionError
+				if !errors.As(err, &exprErr) {
+					t.Fatalf("ValidateExpressions() error %v is not an *ExpressionError", err)
+				}
+				got = append(got, want{
+					expression: exprErr.Expression,
+					advArg:     exprErr.AdvArg,
+					cmdIndex:   exprErr.CmdIndex,
+					argIndex:   exprErr.ArgIndex,
+				})
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Errorf("ValidateExpressions() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateExpressionsMessage(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**launch:/roms/[[platfrom]]")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	errs := zapscript.ValidateExpressions(script, zapscript.ArgExprEnv{})
+	if len(errs) != 1 {
+		t.Fatalf("ValidateExpressions() = %v, want one error", errs)
+	}
+	msg := errs[0].Error()
+	for _, want := range []string{"[[platfrom]]", "command 1 (launch) arg 1", "unknown name platfrom"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+}
+
+func TestValidateExpressionsDoesNotRun(t *testing.T) {
+	t.Parallel()
+
+	ran := false
+	funcs := zapscript.WithFunctions(map[string]any{
+		"mark": func() string {
+			ran = true
+			return ""
+		},
+	})
+	script, err := zapscript.Parse("**echo:[[mark()]]")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if errs := zapscript.ValidateExpressions(script, zapscript.ArgExprEnv{}, funcs); errs != nil {
+		t.Errorf("ValidateExpressions() = %v, want nil", errs)
+	}
+	if ran {
+		t.Error("ValidateExpressions() ran an expression")
+	}
+
+	invalid := zapscript.WithFunctions(map[string]any{"x": 1})
+	errs := zapscript.ValidateExpressions(script, nil, invalid)
+	if len(errs) != 1 || !errors.Is(errs[0], zapscript.ErrInvalidExprFunction) {
+		t.Errorf("ValidateExpressions() = %v, want one %v", errs, zapscript.ErrInvalidExprFunction)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Fingerprint returns a stable SHA-256 hex digest of the script, so that
+// scripts which run the same thing can be deduplicated. It is computed over
+// the String form of the script after these normalizations:
+//
+//   - command names are lowercased
+//   - surrounding whitespace is trimmed from args and adv arg values
+//   - adv args are sorted by key instead of kept in their original order
+//   - recorded quote styles are ignored
+//   - trait keys are sorted
+//   - args are lowercased if FingerprintOptions.CaseInsensitiveArgs is set
+//
+// Everything else participates as is: arg order and case, adv arg value
+// case, whether an adv arg value was written as JSON, the command order, and
+// trait value types, so #level=5 and **traits:{"level":5} differ because the
+// latter is a float64. Warnings are ignored.
+func (s Script) Fingerprint(opts ...FingerprintOption) string {
+	var fpOpts FingerprintOptions
+	for _, opt := range opts {
+		opt(&fpOpts)
+	}
+
+	normalized := Script{Traits: s.Traits, Cmds: make([]Command, len(s.Cmds))}
+	for i, cmd := range s.Cmds {
+		args := make([]string, len(cmd.Args))
+		for j, arg := range cmd.Args {
+			args[j] = strings.TrimSpace(arg)
+			if fpOpts.CaseInsensitiveArgs {
+				args[j] = strings.ToLower(args[j])
+			}
+		}
+
+		var advArgs AdvArgs
+		if !cmd.AdvArgs.IsEmpty() {
+			raw := make(map[string]string, len(cmd.AdvArgs.raw))
+			for k, v := range cmd.AdvArgs.raw {
+				raw[k] = strings.TrimSpace(v)
+			}
+			var repeats map[string][]string
+			for k, values := range cmd.AdvArgs.repeats {
+				if repeats == nil {
+					repeats = make(map[string][]string, len(cmd.AdvArgs.repeats))
+				}
+				repeats[k] = make([]string, len(values))
+				for j, v := range values {
+					repeats[k][j] = strings.TrimSpace(v)
+				}
+			}
+			// no key order, so OrderedKeys sorts them
+			advArgs = AdvArgs{raw: raw, repeats: repeats, json: cmd.AdvArgs.json}
+		}
+
+		normalized.Cmds[i] = Command{
+			Name:    normalizeCmdName(cmd.Name),
+			Args:    args,
+			AdvArgs: advArgs,
+		}
+	}
+
+	sum := sha256.Sum256([]byte(normalized.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func fingerprint(t *testing.T, input string, opts ...zapscript.FingerprintOption) string {
+	t.Helper()
+	script, err := zapscript.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) unexpected error: %v", input, err)
+	}
+	return script.Fingerprint(opts...)
+}
+
+func TestFingerprintEqual(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		opts []zapscript.FingerprintOption
+	}{
+		{name: "command name case", a: "**LAUNCH:game.rom", b: "**launch:game.rom"},
+		{name: "arg whitespace", a: "**launch: game.rom ", b: "**launch:game.rom"},
+		{name: "quoting", a: `**say:"hello, world"`, b: `**say:hello^, world`},
+		{name: "adv arg order", a: "**launch:game?system=snes&launcher=x", b: "**launch:game?launcher=x&system=snes"},
+		{name: "adv arg name case", a: "**launch:game?System=snes", b: "**launch:game?system=snes"},
+		{name: "separator padding", a: "**delay:500 || **stop", b: "**delay:500||**stop"},
+		{name: "trait order", a: "#b=2 #a=1||**stop", b: "#a=1 #b=2||**stop"},
+		{name: "auto launch", a: "snes/mario.sfc", b: "**launch:snes/mario.sfc"},
+		{
+			name: "case-insensitive args",
+			a:    "**launch: Game.rom",
+			b:    "**LAUNCH:game.rom",
+			opts: []zapscript.FingerprintOption{zapscript.WithCaseInsensitiveArgs()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a, b := fingerprint(t, tt.a, tt.opts...), fingerprint(t, tt.b, tt.opts...)
+			if a != b {
+				t.Errorf("Fingerprint(%q) = %s, Fingerprint(%q) = %s, want equal", tt.a, a, tt.b, b)
+			}
+			if len(a) != 64 {
+				t.Errorf("Fingerprint(%q) = %q, want 64 hex digits", tt.a, a)
+			}
+		})
+	}
+}
+
+func TestFingerprintDifferent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+	}{
+		{name: "arg value", a: "**launch:game1.rom", b: "**launch:game2.rom"},
+		{name: "arg case", a: "**launch:Game.rom", b: "**launch:game.rom"},
+		{name: "arg order", a: "**greet:a,b", b: "**greet:b,a"},
+		{name: "extra empty arg", a: "**greet:a", b: `**greet:a,""`},
+		{name: "adv arg value", a: "**launch:game?system=snes", b: "**launch:game?system=nes"},
+		{name: "command order", a: "**stop||**delay:500", b: "**delay:500||**stop"},
+		{name: "trait value", a: "#level=5||**stop", b: "#level=6||**stop"},
+		{name: "trait type", a: "#level=5||**stop", b: `**traits:{"level":5}||**stop`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if a, b := fingerprint(t, tt.a), fingerprint(t, tt.b); a == b {
+				t.Errorf("Fingerprint(%q) = Fingerprint(%q) = %s, want different", tt.a, tt.b, a)
+			}
+		})
+	}
+}
+
+func TestFingerprintIgnoresStyles(t *testing.T) {
+	t.Parallel()
+
+	input := `**launch:'a, b'?system="snes"`
+	styled, err := zapscript.NewParserWithOptions(input, zapscript.WithKeepStyle()).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	if got, want := styled.Fingerprint(), fingerprint(t, input); got != want {
+		t.Errorf("Fingerprint() with KeepStyle = %s, want %s", got, want)
+	}
+}
+
+func TestFingerprintStable(t *testing.T) {
+	t.Parallel()
+
+	// callers store fingerprints, so the digest of a script must not change:
+	// it is the SHA-256 of the canonical text
+	const want = "dcaa7b9ae9dac291d5fb2b05b8aea464525f5697bf964f9e4426c167295a51af"
+	if got := fingerprint(t, "**launch: game.rom ?System=snes"); got != want {
+		t.Errorf("Fingerprint() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+// Format parses input with the default options and returns it in canonical
+// form, so that scripts which only differ in formatting compare equal as
+// strings. The canonical form is Script.String: lowercased command names,
+// trimmed args, quotes only where needed, adv args in their original order,
+// traits first and sorted, and || separators without padding. Parsing the
+// result produces the same Script as parsing input, apart from hints and
+// warnings about the original formatting.
+func Format(input string) (string, error) {
+	script, err := Parse(input)
+	if err != nil {
+		return "", err
+	}
+	return script.String(), nil
+}
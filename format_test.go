@@ -0,0 +1,120 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "lowercases command name", input: "**LAUNCH: game.rom ", want: "**launch:game.rom"},
+		{name: "already canonical", input: "**launch:game.rom", want: "**launch:game.rom"},
+		{name: "trims args", input: "**greet: hi , there ", want: "**greet:hi,there"},
+		{name: "unneeded quotes", input: `**launch:"game.rom"`, want: "**launch:game.rom"},
+		{name: "single quotes", input: `**say:'a, b'`, want: `**say:"a, b"`},
+		{name: "escapes", input: `**say:a^,b`, want: `**say:"a,b"`},
+		{name: "adv arg order kept", input: "**cmd?b=2&A=1", want: "**cmd?b=2&a=1"},
+		{name: "separator padding", input: "**delay:500 || **stop", want: "**delay:500||**stop"},
+		{name: "auto launch", input: "snes/mario.sfc", want: "**launch:snes/mario.sfc"},
+		{name: "media title", input: "@snes/Super Mario World", want: "**launch.title:snes/Super Mario World"},
+		{name: "traits sorted", input: "#name=mario #Level=5||**stop", want: "#level=5 #name=mario||**stop"},
+		{name: "expressions", input: "**echo:[[ device.hostname ]]", want: "**echo:[[ device.hostname ]]"},
+		{name: "JSON script", input: `{"cmds":[{"name":"stop"}]}`, want: "**stop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := zapscript.Format(tt.input)
+			if err != nil {
+				t.Fatalf("Format() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Format(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatEquivalent(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"**LAUNCH: game.rom ",
+		`**launch:'a, b', "c" ?system=snes&launcher="x&y"`,
+		"#name=mario #level=5 #rating=4.5 #tags=[a,\"b c\",3] #favorite||@snes/Super Mario World",
+		`**traits:{"data":{"x":1},"n":2}||**stop`,
+		"**input.keyboard:ab{enter}{a*2}",
+		"**input.text:hello, world?",
+		`**launch:[[platform]]/game?when=[[media_playing]]`,
+		`**api:{"key": "value"}?opts={"a": [1, 2]}`,
+		"**echo:2^^3^nnext",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			want, err := zapscript.Parse(input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			formatted, err := zapscript.Format(input)
+			if err != nil {
+				t.Fatalf("Format() unexpected error: %v", err)
+			}
+			got, err := zapscript.Parse(formatted)
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", formatted, err)
+			}
+
+			opts := cmp.Options{
+				diffOpts,
+				cmpopts.IgnoreFields(zapscript.Script{}, "Warnings"),
+			}
+			if diff := cmp.Diff(want, got, opts); diff != "" {
+				t.Errorf("Format() changed the script (-want +got):\n%s\nformatted=%q", diff, formatted)
+			}
+
+			again, err := zapscript.Format(formatted)
+			if err != nil {
+				t.Fatalf("Format(%q) unexpected error: %v", formatted, err)
+			}
+			if again != formatted {
+				t.Errorf("Format() is not idempotent: %q → %q", formatted, again)
+			}
+		})
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := zapscript.Format(`**say:"hello`); !errors.Is(err, zapscript.ErrUnmatchedQuote) {
+		t.Errorf("Format() error = %v, want %v", err, zapscript.ErrUnmatchedQuote)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// reSingleBracketVar matches a [name] or [name.field] reference that is not
+// part of a [[...]] expression.
+var reSingleBracketVar = regexp.MustCompile(`\[([a-z_][a-z0-9_]*(?:\.[a-z_][a-z0-9_]*)*)\]`)
+
+// exprEnvRoots holds the top-level expression env names. Only these trigger
+// bracket hints so ROM naming conventions like "[b]" or "[USA]" stay quiet.
+var exprEnvRoots = exprTagNames(reflect.TypeFor[ArgExprEnv]())
+
+func exprTagNames(typ reflect.Type) map[string]bool {
+	names := make(map[string]bool, typ.NumField())
+	for i := range typ.NumField() {
+		if tag := typ.Field(i).Tag.Get("expr"); tag != "" {
+			names[tag] = true
+		}
+	}
+	return names
+}
+
+// collectHints inspects parsed commands for common mistakes: a single '|'
+// used to chain commands, and [var] written instead of [[var]]. They are
+// reported as WarningLonePipe and WarningSingleBracket warnings.
+func collectHints(cmds []Command) []Warning {
+	var hints []Warning
+	var cmdIndex int
+	check := func(value string) {
+		if frag, ok := findLonePipeCmd(value); ok {
+			hints = append(hints, Warning{
+				Code:     WarningLonePipe,
+				Message:  "did you mean '||' to separate commands?",
+				Fragment: frag,
+				CmdIndex: cmdIndex,
+			})
+		}
+		for _, m := range reSingleBracketVar.FindAllStringSubmatchIndex(value, -1) {
+			if (m[0] > 0 && value[m[0]-1] == '[') || (m[1] < len(value) && value[m[1]] == ']') {
+				continue
+			}
+			name := value[m[2]:m[3]]
+			root, _, _ := strings.Cut(name, ".")
+			if !exprEnvRoots[root] {
+				continue
+			}
+			hints = append(hints, Warning{
+				Code:     WarningSingleBracket,
+				Message:  fmt.Sprintf("did you mean '[[%s]]' to use an expression?", name),
+				Fragment: value[m[0]:m[1]],
+				CmdIndex: cmdIndex,
+			})
+		}
+	}
+
+	for i, cmd := range cmds {
+		cmdIndex = i
+		for _, arg := range cmd.Args {
+			check(arg)
+		}
+		var keys []string
+		cmd.AdvArgs.Range(func(key Key, _ string) bool {
+			keys = append(keys, string(key))
+			return true
+		})
+		sort.Strings(keys)
+		for _, key := range keys {
+			check(cmd.AdvArgs.Get(Key(key)))
+		}
+	}
+
+	return hints
+}
+
+// findLonePipeCmd reports whether s contains a single '|' directly followed
+// by a command prefix, returning the fragment starting at the pipe.
+func findLonePipeCmd(s string) (string, bool) {
+	for i := 0; i < len(s); i++ {
+		if s[i] != SymCmdSep {
+			continue
+		}
+		if i > 0 && s[i-1] == SymCmdSep {
+			continue
+		}
+		if strings.HasPrefix(s[i+1:], "**") {
+			return s[i:], true
+		}
+	}
+	return "", false
+}
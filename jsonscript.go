@@ -0,0 +1,166 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// jsonScript is the JSON script format. Only commands and traits are read;
+// other fields, including a marshaled Script's warnings, are ignored.
+type jsonScript struct {
+	Traits map[string]any `json:"traits,omitempty"`
+	Cmds   []Command      `json:"cmds"`
+}
+
+// parseJSONScript parses the rest of the input as a JSON script, after the
+// leading { has been read. This lets API clients send commands without any
+// ZapScript escaping. Commands are checked with the same rules as the text
+// syntax and limits still apply.
+func (sr *ScriptReader) parseJSONScript() (Script, error) {
+	var input strings.Builder
+	_, _ = input.WriteRune(SymJSONStart)
+	for {
+		ch, err := sr.read()
+		if err != nil {
+			return Script{}, err
+		} else if ch == eof {
+			break
+		}
+		_, _ = input.WriteRune(ch)
+	}
+
+	var doc jsonScript
+	if err := json.Unmarshal([]byte(input.String()), &doc); err != nil {
+		return Script{}, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+
+	if limit := sr.opts.MaxCommands; limit > 0 && len(doc.Cmds) > limit {
+		return Script{}, &ParseError{
+			Err:      fmt.Errorf("%w: limit is %d", ErrTooManyCommands, limit),
+			Pos:      sr.pos,
+			CmdIndex: limit,
+			CmdName:  doc.Cmds[limit].Name,
+		}
+	}
+
+	script := Script{}
+	if len(doc.Traits) > 0 {
+		traits, err := tokenizeTraits(doc.Traits)
+		if err != nil {
+			return Script{}, &ParseError{Err: err, Pos: sr.pos, CmdIndex: TraitsCmdIndex}
+		}
+		doc.Traits = traits
+		if sr.opts.DisableExpressions && hasExprToken(doc.Traits) {
+			return Script{}, &ParseError{Err: ErrExpressionsDisabled, Pos: sr.pos, CmdIndex: TraitsCmdIndex}
+		}
+		script.Traits = doc.Traits
+	}
+	for i, cmd := range doc.Cmds {
+		checked, err := sr.checkJSONCommand(cmd)
+		if err != nil {
+			return Script{}, &ParseError{Err: err, Pos: sr.pos, CmdIndex: i, CmdName: cmd.Name}
+		}
+		script.Cmds = append(script.Cmds, checked)
+	}
+
+	if len(script.Cmds) == 0 && len(script.Traits) == 0 {
+		return script, ErrEmptyZapScript
+	}
+	return script, nil
+}
+
+// checkJSONCommand applies the text parser's command and adv arg name rules
+// to a decoded command and returns it normalized.
+func (sr *ScriptReader) checkJSONCommand(cmd Command) (Command, error) {
+	if cmd.Name == "" {
+		return cmd, ErrEmptyCmdName
+	}
+	for i, ch := range cmd.Name {
+		if !isCmdName(ch) || (i == 0 && !isCmdNameStart(ch)) {
+			return cmd, fmt.Errorf("%w: %q", ErrInvalidCmdName, cmd.Name)
+		}
+	}
+	cmd.Name = normalizeCmdName(cmd.Name)
+	if isReservedCmdName(cmd.Name) {
+		return cmd, fmt.Errorf("%w: %q", ErrReservedCommandName, cmd.Name)
+	}
+
+	if err := sr.checkArgCount(len(cmd.Args)); err != nil {
+		return cmd, err
+	}
+	// JSON args are tokenized as they are decoded, so a [[...]] arg already
+	// holds an expression by now.
+	if sr.opts.DisableExpressions && (hasExprToken(cmd.Args) || hasExprToken(cmd.AdvArgs.raw)) {
+		return cmd, ErrExpressionsDisabled
+	}
+	if len(cmd.Args) == 0 {
+		cmd.Args = nil
+	}
+
+	if cmd.AdvArgs.IsEmpty() {
+		cmd.AdvArgs = AdvArgs{}
+		return cmd, nil
+	}
+	raw := make(map[string]string, len(cmd.AdvArgs.raw))
+	keys := make([]string, 0, len(cmd.AdvArgs.raw))
+	for _, k := range cmd.AdvArgs.OrderedKeys() {
+		name, value := string(k), cmd.AdvArgs.Get(k)
+		if !isValidAdvArgKey(name) {
+			return cmd, fmt.Errorf("%w: %q", ErrInvalidAdvArgName, name)
+		}
+		key := sr.normalizeAdvArgName(name)
+		if _, ok := raw[key]; ok {
+			return cmd, fmt.Errorf("%w: %q", ErrDuplicateAdvArg, key)
+		}
+		raw[key] = value
+		keys = append(keys, key)
+	}
+	if err := sr.checkAdvArgSizes(raw, nil, keys); err != nil {
+		return cmd, err
+	}
+	cmd.AdvArgs = AdvArgs{raw: raw, keys: keys}
+	return cmd, nil
+}
+
+// hasExprToken reports whether a decoded JSON value contains an expression
+// token in any string, including nested array elements and map values.
+func hasExprToken(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return strings.ContainsFunc(v, isExprToken)
+	case []string:
+		return slices.ContainsFunc(v, func(s string) bool { return hasExprToken(s) })
+	case []any:
+		return slices.ContainsFunc(v, hasExprToken)
+	case map[string]string:
+		for _, e := range v {
+			if hasExprToken(e) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, e := range v {
+			if hasExprToken(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
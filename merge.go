@@ -0,0 +1,56 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+// Merge returns a new script that runs s's commands followed by other's, the
+// same as joining their text with ||. Traits are merged key by key with
+// other's value winning when both set a key, like a repeated trait in a
+// single script; values are not merged recursively. The result has nil
+// Traits if neither script has any. Warnings are kept, with the CmdIndex of
+// other's warnings shifted to match the merged command list.
+// Spans and positions are not shifted, they stay relative to the input each
+// script was parsed from.
+// Neither script is modified and the result shares no maps or slices with
+// them.
+func (s Script) Merge(other Script) Script {
+	merged := s.Clone()
+	appended := other.Clone()
+
+	for k, v := range appended.Traits {
+		if merged.Traits == nil {
+			merged.Traits = make(map[string]any, len(appended.Traits))
+		}
+		merged.Traits[k] = v
+	}
+
+	for _, w := range appended.Warnings {
+		if w.CmdIndex != TraitsCmdIndex {
+			w.CmdIndex += len(merged.Cmds)
+		}
+		merged.Warnings = append(merged.Warnings, w)
+	}
+	merged.Cmds = append(merged.Cmds, appended.Cmds...)
+	merged.TraitsSpans = append(merged.TraitsSpans, appended.TraitsSpans...)
+
+	return merged
+}
+
+// MergePrepend returns a new script that runs other's commands before s's,
+// for hooks that run something first. It is other.Merge(s), so s's traits
+// win over other's.
+func (s Script) MergePrepend(other Script) Script {
+	return other.Merge(s)
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestScriptMerge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		a       string
+		b       string
+		prepend bool
+	}{
+		{name: "commands appended", a: "**launch:game", b: "**delay:500||**stop"},
+		{name: "traits last wins", a: "#a=1 #b=x||**launch:game", b: "#a=2 #c||**stop"},
+		{name: "traits only", a: "#a=1", b: "#b=2"},
+		{name: "commands only with traits", a: "**stop", b: `**traits:{"data":{"x":1}}`},
+		{name: "prepend", a: "#a=1||**launch:game", b: "#a=2||**setup", prepend: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+
+			var got zapscript.Script
+			var joined string
+			if tt.prepend {
+				got = a.MergePrepend(b)
+				joined = tt.b + "||" + tt.a
+			} else {
+				got = a.Merge(b)
+				joined = tt.a + "||" + tt.b
+			}
+
+			// merging must match parsing the scripts joined with ||
+			want := mustParse(t, joined)
+			opts := cmp.Options{
+				diffOpts,
+				cmpopts.IgnoreFields(zapscript.Script{}, "Warnings"),
+				cmpopts.EquateEmpty(),
+			}
+			if diff := cmp.Diff(want, got, opts); diff != "" {
+				t.Errorf("Merge() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScriptMergeEmpty(t *testing.T) {
+	t.Parallel()
+
+	script := mustParse(t, "#a=1||**launch:game")
+	empty := zapscript.Script{}
+
+	opts := cmp.AllowUnexported(zapscript.AdvArgs{})
+	if diff := cmp.Diff(script, script.Merge(empty), opts); diff != "" {
+		t.Errorf("Merge(empty) mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(script, empty.Merge(script), opts); diff != "" {
+		t.Errorf("empty.Merge() mismatch (-want +got):\n%s", diff)
+	}
+
+	merged := empty.Merge(zapscript.Script{})
+	if merged.Traits != nil || merged.Cmds != nil {
+		t.Errorf("empty.Merge(empty) = %+v, want zero value", merged)
+	}
+}
+
+func TestScriptMergeDoesNotModifyInputs(t *testing.T) {
+	t.Parallel()
+
+	a := mustParse(t, "#a=1||**launch:game")
+	b := mustParse(t, "#a=2 #b||**stop")
+
+	merged := a.Merge(b)
+	merged.Traits["c"] = true
+	merged.Cmds[0].Args[0] = "other"
+	merged.Cmds[1].Name = "other"
+
+	if diff := cmp.Diff(map[string]any{"a": int64(1)}, a.Traits); diff != "" {
+		t.Errorf("Merge() changed receiver traits (-want +got):\n%s", diff)
+	}
+	if got := a.Cmds[0].Args[0]; got != "game" {
+		t.Errorf("receiver Args[0] = %q, want %q", got, "game")
+	}
+	if got := b.Cmds[0].Name; got != "stop" {
+		t.Errorf("other Cmds[0].Name = %q, want %q", got, "stop")
+	}
+}
+
+func TestScriptMergeWarnings(t *testing.T) {
+	t.Parallel()
+
+	a := mustParse(t, "**launch:game||**stop")
+	b := mustParse(t, "**launch:game?system=a&system=b")
+	if len(b.Warnings) != 1 {
+		t.Fatalf("Warnings = %v, want one duplicate adv arg warning", b.Warnings)
+	}
+
+	merged := a.Merge(b)
+	if len(merged.Warnings) != 1 || merged.Warnings[0].CmdIndex != 2 {
+		t.Errorf("Merge() warnings = %+v, want one with CmdIndex 2", merged.Warnings)
+	}
+}
+
+func mustParse(t *testing.T, input string) zapscript.Script {
+	t.Helper()
+	script, err := zapscript.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) unexpected error: %v", input, err)
+	}
+	return script
+}
@@ -0,0 +1,194 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Minify parses input with the default options and returns the shortest
+// equivalent script it can find, for tags with little storage. See
+// MinifyWithSavings.
+func Minify(input string) (string, error) {
+	minified, _, err := MinifyWithSavings(input)
+	return minified, err
+}
+
+// MinifyWithSavings is Minify that also returns the number of bytes saved.
+// The output has no whitespace outside values, quotes only values that are
+// shorter quoted, writes empty adv arg values as ?key, writes launch and
+// launch.title commands in their auto-launch and @ forms where they parse
+// the same, and writes traits as #key=value shorthand without separators.
+// The output always parses to a Script equivalent to parsing input, and is
+// never longer than input: if no shorter form is found, input is returned
+// unchanged with zero savings.
+func MinifyWithSavings(input string) (minified string, saved int, err error) {
+	script, err := Parse(input)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var b strings.Builder
+	if len(script.Traits) > 0 {
+		writeTraits(&b, script.Traits, "")
+	}
+	for _, cmd := range script.Cmds {
+		if b.Len() > 0 {
+			_, _ = b.WriteString(string([]rune{SymCmdSep, SymCmdSep}))
+		}
+		_, _ = b.WriteString(minifyCommand(cmd))
+	}
+	minified = b.String()
+
+	if len(minified) >= len(input) || !sameScript(script, minified) {
+		return input, 0, nil
+	}
+	return minified, len(input) - len(minified), nil
+}
+
+// minifyCommand returns the shortest form of cmd that parses back to it.
+func minifyCommand(cmd Command) string {
+	name := normalizeCmdName(cmd.Name)
+	if isInputMacroCmd(name) || isInputRawCmd(name) {
+		// these have their own arg syntax, which String already writes
+		// without quotes
+		return cmd.String()
+	}
+
+	var advArgs strings.Builder
+	if !cmd.AdvArgs.IsEmpty() {
+		_, _ = advArgs.WriteRune(SymAdvArgStart)
+		for i, key := range cmd.AdvArgs.OrderedKeys() {
+			// every value of a repeated key is written so GetAll is kept,
+			// only the last can have been read as JSON
+			values := cmd.AdvArgs.GetAll(key)
+			for j, value := range values {
+				if i > 0 || j > 0 {
+					_, _ = advArgs.WriteRune(SymAdvArgSep)
+				}
+				_, _ = advArgs.WriteString(string(key))
+				switch {
+				case value == "":
+				case j == len(values)-1 && cmd.AdvArgs.IsJSON(key):
+					_, _ = advArgs.WriteRune(SymAdvArgEq)
+					_, _ = advArgs.WriteString(value)
+				default:
+					_, _ = advArgs.WriteRune(SymAdvArgEq)
+					_, _ = advArgs.WriteString(shortestValue(value, true))
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	_, _ = b.WriteString("**")
+	_, _ = b.WriteString(cmd.Name)
+	if len(cmd.Args) > 0 {
+		_, _ = b.WriteRune(SymArgStart)
+		for i, arg := range cmd.Args {
+			if i > 0 {
+				_, _ = b.WriteRune(SymArgSep)
+			}
+			// an empty arg needs quotes only if it is the last one
+			if arg != "" || i == len(cmd.Args)-1 {
+				_, _ = b.WriteString(shortestValue(arg, false))
+			}
+		}
+	}
+	_, _ = b.WriteString(advArgs.String())
+	shortest := b.String()
+
+	// launch commands have shorter forms with their own parsing rules, so
+	// candidates are only used if they parse back to the same command
+	if len(cmd.Args) == 1 && (name == ZapScriptCmdLaunch || name == ZapScriptCmdLaunchTitle) {
+		prefix := ""
+		if name == ZapScriptCmdLaunchTitle {
+			prefix = string(SymMediaTitleStart)
+		}
+		for _, content := range []string{cmd.Args[0], shortestValue(cmd.Args[0], false)} {
+			candidate := prefix + content + advArgs.String()
+			if len(candidate) < len(shortest) && sameCommand(cmd, candidate) {
+				shortest = candidate
+			}
+		}
+	}
+
+	return shortest
+}
+
+// shortestValue returns the shortest of the unquoted, double-quoted and
+// single-quoted forms of a non-empty value, preferring them in that order.
+func shortestValue(value string, advArg bool) string {
+	parts := SplitArgParts(value)
+	var candidates []string
+	if value != "" {
+		var b strings.Builder
+		writeUnquoted(&b, parts, advArg)
+		candidates = append(candidates, b.String())
+	}
+	for _, quote := range []rune{SymArgDoubleQuote, SymArgSingleQuote} {
+		var b strings.Builder
+		writeQuoted(&b, parts, quote)
+		candidates = append(candidates, b.String())
+	}
+
+	shortest := candidates[0]
+	for _, c := range candidates[1:] {
+		if len(c) < len(shortest) {
+			shortest = c
+		}
+	}
+	return shortest
+}
+
+// sameCommand reports whether text parses to a script of only cmd.
+func sameCommand(cmd Command, text string) bool {
+	script, err := Parse(text)
+	return err == nil && len(script.Traits) == 0 && len(script.Cmds) == 1 && commandsEqual(cmd, script.Cmds[0])
+}
+
+// sameScript reports whether text parses to a script equivalent to want.
+func sameScript(want Script, text string) bool {
+	got, err := Parse(text)
+	if err != nil || len(got.Cmds) != len(want.Cmds) || !reflect.DeepEqual(got.Traits, want.Traits) {
+		return false
+	}
+	for i := range want.Cmds {
+		if !commandsEqual(want.Cmds[i], got.Cmds[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// commandsEqual compares the parsed content of two commands, ignoring quote
+// styles and adv arg order. Every value of a repeated adv arg is compared,
+// not only the last one as AdvArgs.Equal does.
+func commandsEqual(a, b Command) bool {
+	if a.Name != b.Name || !slices.Equal(a.Args, b.Args) || !a.AdvArgs.Equal(b.AdvArgs) ||
+		!maps.Equal(a.AdvArgs.json, b.AdvArgs.json) {
+		return false
+	}
+	for _, key := range a.AdvArgs.OrderedKeys() {
+		if !slices.Equal(a.AdvArgs.GetAll(key), b.AdvArgs.GetAll(key)) {
+			return false
+		}
+	}
+	return true
+}
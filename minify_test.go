@@ -0,0 +1,110 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestMinify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "already minimal", input: "**greet:a,,b||**stop", want: "**greet:a,,b||**stop"},
+		{name: "already minimal launch", input: "game.rom?system=snes", want: "game.rom?system=snes"},
+		{name: "input macro unchanged", input: "**input.keyboard:ab{enter}", want: "**input.keyboard:ab{enter}"},
+		{name: "launch auto-launch form", input: "**launch:game.rom", want: "game.rom"},
+		{name: "launch title form", input: "**launch.title:snes/Mario", want: "@snes/Mario"},
+		{name: "quotes dropped", input: `**launch:"a b"`, want: "a b"},
+		{name: "shorter escape", input: `**say:"hello, world"`, want: "**say:hello^, world"},
+		{name: "empty adv arg value", input: "**launch:game.rom?x=", want: "game.rom?x"},
+		{name: "JSON adv arg kept", input: `**launch:game?tags={"a":[1,2]}`, want: `game?tags={"a":[1,2]}`},
+		{name: "repeated adv arg kept", input: "x?_&_=1", want: "x?_&_=1"},
+		{name: "repeated adv arg values", input: `**launch:x?t="a"&t="b"&u=`, want: "x?t=a&t=b&u"},
+		{
+			name:  "padded",
+			input: "  **launch: game.rom ?system=snes&launcher= ",
+			want:  "game.rom?system=snes&launcher",
+		},
+		{
+			name:  "traits without separators",
+			input: "#name=mario #level=5||**launch.title:snes/Mario",
+			want:  "#level=5#name=mario||@snes/Mario",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, saved, err := zapscript.MinifyWithSavings(tt.input)
+			if err != nil {
+				t.Fatalf("MinifyWithSavings() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MinifyWithSavings() = %q, want %q", got, tt.want)
+			}
+			if want := len(tt.input) - len(tt.want); saved != want {
+				t.Errorf("MinifyWithSavings() saved = %d, want %d", saved, want)
+			}
+			assertEquivalent(t, tt.input, got)
+		})
+	}
+}
+
+func TestMinifyError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := zapscript.Minify(""); err == nil {
+		t.Error("Minify() expected error for empty input")
+	}
+}
+
+// assertEquivalent checks that both inputs parse to the same script,
+// ignoring quote styles.
+func assertEquivalent(t *testing.T, input, minified string) {
+	t.Helper()
+	want, err := zapscript.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse(%q) unexpected error: %v", input, err)
+	}
+	got, err := zapscript.Parse(minified)
+	if err != nil {
+		t.Fatalf("Parse(%q) unexpected error: %v", minified, err)
+	}
+	opts := cmp.Options{
+		diffOpts,
+		cmpopts.IgnoreFields(zapscript.Script{}, "Warnings"),
+	}
+	if diff := cmp.Diff(want, got, opts); diff != "" {
+		t.Errorf("minified script mismatch (-input +minified):\n%s", diff)
+	}
+	// AdvArgs.Equal only compares the last value of repeated keys
+	for i, cmd := range want.Cmds {
+		for _, key := range cmd.AdvArgs.OrderedKeys() {
+			if diff := cmp.Diff(cmd.AdvArgs.GetAll(key), got.Cmds[i].AdvArgs.GetAll(key)); diff != "" {
+				t.Errorf("command %d adv arg %q values mismatch (-input +minified):\n%s", i, key, diff)
+			}
+		}
+	}
+}
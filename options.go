@@ -0,0 +1,426 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import "strings"
+
+// AutoLaunchDetector inspects bare auto-launch content and optionally returns
+// the command it should become instead of the default launch command.
+type AutoLaunchDetector func(content string) (Command, bool)
+
+// Default parser limits. They are generous for hand-written scripts but stop
+// corrupt or hostile payloads from being processed in full. The number of
+// commands is not limited by default, see Options.MaxCommands.
+const (
+	DefaultMaxInputRunes       = 64 * 1024
+	DefaultMaxArgs             = 64
+	DefaultMaxAdvArgValueBytes = 8 * 1024
+)
+
+// Options controls optional parser behavior. A parser created with NewParser
+// uses the defaults.
+type Options struct {
+	// AutoLaunchDetectors are consulted in order for auto-launch content. The
+	// first detector that returns true supplies the command; if none match, a
+	// launch command is produced as usual.
+	AutoLaunchDetectors []AutoLaunchDetector
+	// Strict turns recoverable syntax mistakes into errors instead of
+	// silently accepting them. For example, text following a closing quote is
+	// normally appended to the quoted value but is an ErrTrailingAfterQuote
+	// error in strict mode, and a repeated adv arg or trait key is an
+	// ErrDuplicateAdvArg or ErrDuplicateTraitKey error instead of a warning.
+	Strict bool
+	// KeepStyle records how each arg and adv arg value was quoted, in
+	// Command.ArgStyles and AdvArgs.Style, so that Command.String and
+	// Script.String reproduce the original quoting instead of choosing it.
+	KeepStyle bool
+	// DisableExpressions treats [[ as literal text in args, adv args, trait
+	// values and media titles, for input from untrusted sources. Input that
+	// contains expression tokens, such as JSON args or private-use runes, is
+	// an ErrExpressionsDisabled error.
+	DisableExpressions bool
+	// DecodePercentEscapes decodes %XX escapes in adv arg values, such as
+	// %20 for a space and %26 for &, for tags written by URL tooling. It
+	// applies to quoted and unquoted values after parsing, but not to
+	// positional args, JSON values or expressions. A + is kept as is rather
+	// than read as a space, since tag filters use it. An invalid escape is an
+	// ErrInvalidPercentEscape error naming the key.
+	DecodePercentEscapes bool
+	// MultiLine makes a line break end a command as || does, for scripts
+	// written one command per line, such as .zap files. Blank lines are
+	// skipped, and a line ending in ^ continues onto the next one; use ^^ for
+	// a literal ^ at the end of a line. Line breaks inside quoted and JSON
+	// values are kept in the value.
+	MultiLine bool
+	// DisableAutoLaunch makes input that would fall back to a launch command,
+	// such as plain text, *single, **bad name or @ without a /, an
+	// ErrAutoLaunchDisabled error quoting the text, for hosts that only
+	// accept explicit **command syntax. Media title syntax is still parsed.
+	DisableAutoLaunch bool
+	// DisableMediaTitle reads a segment starting with @ as a launch command
+	// whose only arg is the whole segment, such as a path on a mount named
+	// with a leading @. It is not split into a system and title and ? does
+	// not start adv args.
+	DisableMediaTitle bool
+	// AdvArgAliases maps alternative adv arg names to the key they stand for,
+	// e.g. "sys" to KeySystem. Aliases are matched after the name has been
+	// lowercased.
+	AdvArgAliases map[string]Key
+	// MaxInputRunes caps the number of runes read from the input. Zero
+	// disables the limit.
+	MaxInputRunes int
+	// MaxArgs caps the number of positional args in a single command. Input
+	// macro keys are bounded separately by InputMacroMaxKeys. Zero disables
+	// the limit.
+	MaxArgs int
+	// MaxCommands caps the number of commands in a script, counting
+	// auto-launch and media title commands but not traits. A longer chain,
+	// usually content duplicated by a buggy tag writer, is an
+	// ErrTooManyCommands error as soon as the first extra command is read.
+	// The ParseError names that command and the error gives the limit, for
+	// text and JSON scripts alike.
+	// Zero, the default, disables the limit.
+	MaxCommands int
+	// MaxAdvArgValueBytes caps the size in bytes of each adv arg value, such
+	// as a ?data={...} JSON payload, as stored after quotes, escapes and
+	// percent escapes are decoded. A larger value is an ErrAdvArgTooLarge
+	// error. Zero disables the limit.
+	MaxAdvArgValueBytes int
+}
+
+// Option modifies parser Options.
+type Option func(*Options)
+
+func defaultOptions() Options {
+	return Options{
+		MaxInputRunes:       DefaultMaxInputRunes,
+		MaxArgs:             DefaultMaxArgs,
+		MaxAdvArgValueBytes: DefaultMaxAdvArgValueBytes,
+	}
+}
+
+// WithAutoLaunchDetectors appends detectors to the auto-launch detector list.
+func WithAutoLaunchDetectors(detectors ...AutoLaunchDetector) Option {
+	return func(o *Options) {
+		o.AutoLaunchDetectors = append(o.AutoLaunchDetectors, detectors...)
+	}
+}
+
+// WithStrictMode enables strict parsing, see Options.Strict.
+func WithStrictMode() Option {
+	return func(o *Options) {
+		o.Strict = true
+	}
+}
+
+// WithKeepStyle enables recording quote styles, see Options.KeepStyle.
+func WithKeepStyle() Option {
+	return func(o *Options) {
+		o.KeepStyle = true
+	}
+}
+
+// WithDisableExpressions disables expression parsing, see
+// Options.DisableExpressions.
+func WithDisableExpressions() Option {
+	return func(o *Options) {
+		o.DisableExpressions = true
+	}
+}
+
+// WithDecodePercentEscapes enables Options.DecodePercentEscapes.
+func WithDecodePercentEscapes() Option {
+	return func(o *Options) {
+		o.DecodePercentEscapes = true
+	}
+}
+
+// WithMultiLine enables multi-line scripts, see Options.MultiLine.
+func WithMultiLine() Option {
+	return func(o *Options) {
+		o.MultiLine = true
+	}
+}
+
+// WithoutAutoLaunch disables auto-launch commands, see
+// Options.DisableAutoLaunch.
+func WithoutAutoLaunch() Option {
+	return func(o *Options) {
+		o.DisableAutoLaunch = true
+	}
+}
+
+// WithoutMediaTitle disables media title syntax, see
+// Options.DisableMediaTitle.
+func WithoutMediaTitle() Option {
+	return func(o *Options) {
+		o.DisableMediaTitle = true
+	}
+}
+
+// WithAdvArgAliases adds adv arg name aliases, see Options.AdvArgAliases.
+// Alias names are lowercased.
+func WithAdvArgAliases(aliases map[string]Key) Option {
+	return func(o *Options) {
+		merged := make(map[string]Key, len(o.AdvArgAliases)+len(aliases))
+		for alias, key := range o.AdvArgAliases {
+			merged[alias] = key
+		}
+		for alias, key := range aliases {
+			merged[strings.ToLower(alias)] = key
+		}
+		o.AdvArgAliases = merged
+	}
+}
+
+// WithMaxInputRunes sets the maximum number of input runes. Zero disables the
+// limit.
+func WithMaxInputRunes(n int) Option {
+	return func(o *Options) {
+		o.MaxInputRunes = n
+	}
+}
+
+// WithMaxArgs sets the maximum number of positional args per command. Zero
+// disables the limit.
+func WithMaxArgs(n int) Option {
+	return func(o *Options) {
+		o.MaxArgs = n
+	}
+}
+
+// WithMaxCommands sets the maximum number of commands per script. Zero
+// disables the limit.
+func WithMaxCommands(n int) Option {
+	return func(o *Options) {
+		o.MaxCommands = n
+	}
+}
+
+// WithMaxAdvArgValueBytes sets the maximum size of an adv arg value in bytes.
+// Zero disables the limit.
+func WithMaxAdvArgValueBytes(n int) Option {
+	return func(o *Options) {
+		o.MaxAdvArgValueBytes = n
+	}
+}
+
+// DefaultFloatPrecision is the number of decimal places float expression
+// results are rounded to when EvalOptions.FloatPrecision is zero.
+const DefaultFloatPrecision = 6
+
+// EvalOptions controls optional expression evaluation behavior.
+type EvalOptions struct {
+	// Functions are extra functions available to expressions by name, such
+	// as a platform's basename(path). Each must be a func with one result,
+	// or two where the second is an error. Args are type checked when the
+	// expression is compiled. A function can replace basename or an expr
+	// built-in but not pathjoin or pathclean.
+	Functions map[string]any
+	// Traits, if not nil, are readable by expressions as traits, as in
+	// [[traits.difficulty == "hard"]], unless exprEnv has its own traits
+	// field or key, which is used instead. A trait that is not set reads as
+	// nil. Script.EvalExpressions sets it to the script's traits.
+	Traits map[string]any
+	// AllowedFields, if not nil, lists the env fields expressions may read,
+	// as dotted paths such as "active_media" or "device.os". A field nested
+	// under an entry is allowed too, so "device" allows device.os. An
+	// expression reading any other field, or the whole env through $env, is
+	// an ErrFieldNotAllowed error. Expressions that read no fields, such as
+	// [[1 + 1]], are always allowed.
+	AllowedFields []string
+	// SandboxRoot, if set, makes pathjoin and pathclean return an
+	// ErrPathEscapesSandbox error for paths outside it. Relative paths are
+	// resolved against the root.
+	SandboxRoot string
+	// FloatPrecision is the number of decimal places float results are
+	// rounded to when substituted, after which trailing zeros are removed,
+	// so [[10 / 3]] gives "3.333333" and [[8 / 2]] gives "4". Zero uses
+	// DefaultFloatPrecision and a negative value the fewest digits that read
+	// back as the same float. Use round() in the expression for whole
+	// numbers.
+	FloatPrecision int
+	// WindowsPaths makes the path functions accept backslash separators and
+	// return paths using them.
+	WindowsPaths bool
+}
+
+// EvalOption modifies EvalOptions.
+type EvalOption func(*EvalOptions)
+
+// WithSandboxRoot sets EvalOptions.SandboxRoot.
+func WithSandboxRoot(root string) EvalOption {
+	return func(o *EvalOptions) {
+		o.SandboxRoot = root
+	}
+}
+
+// WithFunctions adds functions to EvalOptions.Functions, replacing any
+// already added with the same name.
+func WithFunctions(funcs map[string]any) EvalOption {
+	return func(o *EvalOptions) {
+		merged := make(map[string]any, len(o.Functions)+len(funcs))
+		for name, fn := range o.Functions {
+			merged[name] = fn
+		}
+		for name, fn := range funcs {
+			merged[name] = fn
+		}
+		o.Functions = merged
+	}
+}
+
+// WithAllowedFields adds fields to EvalOptions.AllowedFields. Calling it
+// with no fields allows none.
+func WithAllowedFields(fields []string) EvalOption {
+	return func(o *EvalOptions) {
+		o.AllowedFields = append(make([]string, 0, len(o.AllowedFields)+len(fields)), o.AllowedFields...)
+		o.AllowedFields = append(o.AllowedFields, fields...)
+	}
+}
+
+// WithTraits sets EvalOptions.Traits.
+func WithTraits(traits map[string]any) EvalOption {
+	return func(o *EvalOptions) {
+		o.Traits = traits
+	}
+}
+
+// WithFloatPrecision sets EvalOptions.FloatPrecision.
+func WithFloatPrecision(precision int) EvalOption {
+	return func(o *EvalOptions) {
+		o.FloatPrecision = precision
+	}
+}
+
+// WithWindowsPaths enables EvalOptions.WindowsPaths.
+func WithWindowsPaths() EvalOption {
+	return func(o *EvalOptions) {
+		o.WindowsPaths = true
+	}
+}
+
+// DefaultEscapeDensityThreshold is the default ValidateOptions
+// EscapeDensityThreshold.
+const DefaultEscapeDensityThreshold = 0.3
+
+// ArgValidator reports whether an adv arg value passes a custom validate tag
+// rule, such as launcher, see WithArgValidator.
+type ArgValidator func(value string) bool
+
+// ValidateOptions controls optional Script.Validate checks and the custom
+// rules ValidateArgs applies.
+type ValidateOptions struct {
+	// ArgValidators maps custom validate tag rules to their validators.
+	ArgValidators map[string]ArgValidator
+	// ReservedTraitKeys are trait keys the host reserves for its own
+	// conventions, in addition to ReservedTraitKeys. Script.Validate warns
+	// when a script sets one. Keys are matched case-insensitively.
+	ReservedTraitKeys []string
+	// EscapeDensityThreshold is the fraction of a value's runes that may be
+	// part of ^ escape sequences before a WarningEscapeDensity is reported.
+	// Values with fewer than two escape sequences are never reported. Zero
+	// disables the check.
+	EscapeDensityThreshold float64
+}
+
+// ValidateOption modifies ValidateOptions.
+type ValidateOption func(*ValidateOptions)
+
+func defaultValidateOptions() ValidateOptions {
+	return ValidateOptions{
+		EscapeDensityThreshold: DefaultEscapeDensityThreshold,
+	}
+}
+
+// WithEscapeDensityThreshold sets ValidateOptions.EscapeDensityThreshold.
+// Zero disables the check.
+func WithEscapeDensityThreshold(threshold float64) ValidateOption {
+	return func(o *ValidateOptions) {
+		o.EscapeDensityThreshold = threshold
+	}
+}
+
+// WithReservedTraitKeys adds keys to ValidateOptions.ReservedTraitKeys.
+func WithReservedTraitKeys(keys ...string) ValidateOption {
+	return func(o *ValidateOptions) {
+		o.ReservedTraitKeys = append(o.ReservedTraitKeys, keys...)
+	}
+}
+
+// WithArgValidator registers fn as the validator of the custom validate tag
+// rule name for ValidateArgs, e.g. "launcher" checked against the host's
+// launcher registry.
+func WithArgValidator(name string, fn ArgValidator) ValidateOption {
+	return func(o *ValidateOptions) {
+		if o.ArgValidators == nil {
+			o.ArgValidators = make(map[string]ArgValidator)
+		}
+		o.ArgValidators[name] = fn
+	}
+}
+
+// StringOptions controls how Command.StringWithOptions and
+// Script.StringWithOptions write ZapScript.
+type StringOptions struct {
+	// QuotePreference chooses between quotes and ^-escapes for values with
+	// special characters.
+	QuotePreference QuotePreference
+}
+
+// StringOption modifies StringOptions.
+type StringOption func(*StringOptions)
+
+// WithQuotePreference sets StringOptions.QuotePreference.
+func WithQuotePreference(preference QuotePreference) StringOption {
+	return func(o *StringOptions) {
+		o.QuotePreference = preference
+	}
+}
+
+// FingerprintOptions controls optional Script.Fingerprint normalizations.
+type FingerprintOptions struct {
+	// CaseInsensitiveArgs lowercases args before hashing, for hosts whose
+	// media paths are case-insensitive.
+	CaseInsensitiveArgs bool
+}
+
+// FingerprintOption modifies FingerprintOptions.
+type FingerprintOption func(*FingerprintOptions)
+
+// WithCaseInsensitiveArgs enables FingerprintOptions.CaseInsensitiveArgs.
+func WithCaseInsensitiveArgs() FingerprintOption {
+	return func(o *FingerprintOptions) {
+		o.CaseInsensitiveArgs = true
+	}
+}
+
+// DecodeOptions controls how DecodeAdvArgs fills a struct.
+type DecodeOptions struct {
+	// IgnoreUnknownKeys skips adv args with no matching field instead of
+	// returning an *UnknownKeysError.
+	IgnoreUnknownKeys bool
+}
+
+// DecodeOption modifies DecodeOptions.
+type DecodeOption func(*DecodeOptions)
+
+// WithIgnoreUnknownKeys enables DecodeOptions.IgnoreUnknownKeys.
+func WithIgnoreUnknownKeys() DecodeOption {
+	return func(o *DecodeOptions) {
+		o.IgnoreUnknownKeys = true
+	}
+}
@@ -19,13 +19,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"strings"
 )
 
 func (sr *ScriptReader) parseMediaTitleSyntax() (*mediaTitleParseResult, error) {
-	result := &mediaTitleParseResult{
-		advArgs: make(map[string]string),
-	}
+	result := &mediaTitleParseResult{}
 	rawContent := ""
 
 	var contentBuilder strings.Builder
@@ -53,6 +53,16 @@ func (sr *ScriptReader) parseMediaTitleSyntax() (*mediaTitleParseResult, error)
 			continue
 		}
 
+		// Check for expressions, which are evaluated at scan time
+		if ch == SymExpressionStart {
+			exprValue, exprErr := sr.parseExpression()
+			if exprErr != nil {
+				return nil, exprErr
+			}
+			_, _ = contentBuilder.WriteString(exprValue)
+			continue
+		}
+
 		// Check for end of command
 		eoc, checkErr := sr.checkEndOfCmd(ch)
 		if checkErr != nil {
@@ -84,14 +94,16 @@ func (sr *ScriptReader) parseMediaTitleSyntax() (*mediaTitleParseResult, error)
 	result.rawContent = strings.TrimSpace(rawContent)
 
 	// Validate: must contain at least one / separator for system/title format
-	sepIdx := strings.Index(result.rawContent, string(SymMediaTitleSep))
+	sepIdx := mediaTitleSepIndex(result.rawContent)
 	if sepIdx == -1 {
 		// Not valid media title format, return for auto-launch fallback
 		result.valid = false
 		return result, nil
 	}
 
-	// Validate: both system ID and game name must be non-empty
+	// Validate: both system ID and game name must be non-empty. An
+	// expression counts as content since its value is only known once
+	// evaluated.
 	systemID := strings.TrimSpace(result.rawContent[:sepIdx])
 	gameName := strings.TrimSpace(result.rawContent[sepIdx+1:])
 	if systemID == "" || gameName == "" {
@@ -104,6 +116,23 @@ func (sr *ScriptReader) parseMediaTitleSyntax() (*mediaTitleParseResult, error)
 	return result, nil
 }
 
+// mediaTitleSepIndex returns the byte index of the first system/title
+// separator in content that is not inside an expression, or -1.
+func mediaTitleSepIndex(content string) int {
+	offset := 0
+	for _, part := range SplitArgParts(content) {
+		if part.Type == ArgPartTypeExpression {
+			offset += len(TokExpStart) + len(part.Value) + len(TokExprEnd)
+			continue
+		}
+		if i := strings.IndexRune(part.Value, SymMediaTitleSep); i != -1 {
+			return offset + i
+		}
+		offset += len(part.Value)
+	}
+	return -1
+}
+
 func (sr *ScriptReader) parseCommand(onlyOneArg bool) (Command, string, error) {
 	cmd := Command{}
 	var buf []rune
@@ -127,7 +156,7 @@ commandLoop:
 		}
 
 		switch {
-		case isCmdName(ch):
+		case isCmdNameStart(ch) || (cmd.Name != "" && isCmdName(ch)):
 			cmd.Name += string(ch)
 		case ch == SymArgStart || ch == SymAdvArgStart:
 			// parse arguments
@@ -136,6 +165,9 @@ commandLoop:
 			}
 
 			cmd.Name = normalizeCmdName(cmd.Name)
+			if isReservedCmdName(cmd.Name) {
+				return cmd, string(buf), fmt.Errorf("%w: %q", ErrReservedCommandName, cmd.Name)
+			}
 
 			onlyAdvArgs := false
 			if ch == SymAdvArgStart {
@@ -148,8 +180,9 @@ commandLoop:
 			}
 
 			var args []string
-			var advArgs map[string]string
+			var advArgs AdvArgs
 			var err error
+			sr.argStyles = nil
 
 			switch {
 			case isInputMacroCmd(cmd.Name):
@@ -171,10 +204,11 @@ commandLoop:
 
 			if len(args) > 0 {
 				cmd.Args = args
+				cmd.ArgStyles = sr.argStyles
 			}
 
-			if len(advArgs) > 0 {
-				cmd.AdvArgs = NewAdvArgs(advArgs)
+			if !advArgs.IsEmpty() {
+				cmd.AdvArgs = advArgs
 			}
 
 			break commandLoop
@@ -189,63 +223,257 @@ commandLoop:
 	}
 
 	cmd.Name = normalizeCmdName(cmd.Name)
+	if isReservedCmdName(cmd.Name) {
+		return cmd, string(buf), fmt.Errorf("%w: %q", ErrReservedCommandName, cmd.Name)
+	}
 
 	return cmd, string(buf), nil
 }
 
+// detectAutoLaunch runs the configured auto-launch detectors against content
+// and returns the command from the first one that matches.
+func (sr *ScriptReader) detectAutoLaunch(content string) (Command, bool) {
+	for _, detect := range sr.opts.AutoLaunchDetectors {
+		if cmd, ok := detect(content); ok {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
+
+// parseState is what NextCommand keeps between commands.
+type parseState struct {
+	// traits holds the traits read so far, later keys overwriting earlier
+	traits map[string]any
+	// pendingFallback is an invalid traits segment, which is an error if the
+	// script has no commands
+	pendingFallback *traitsParseResult
+	// err is the error parsing stopped at, returned by later calls
+	err error
+	// queued holds the commands of a JSON script not yet returned
+	queued      []Command
+	traitsSpans []Span
+	cmdCount    int
+	// sawContent is set once a non-whitespace rune has been read
+	sawContent bool
+	// done is set once the whole input has been parsed
+	done bool
+}
+
+// ParseScript parses the whole input into a Script. It calls NextCommand
+// until the end of the input and adds the traits and warnings. On
+// error, the Script holds what was parsed before it.
 func (sr *ScriptReader) ParseScript() (Script, error) {
 	script := Script{}
-	hasNonTraitContent := false
-	var pendingFallback *traitsParseResult
+	for {
+		cmd, err := sr.NextCommand()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			script.Traits, script.TraitsSpans = sr.state.traits, sr.state.traitsSpans
+			return script, err
+		}
+		script.Cmds = append(script.Cmds, cmd)
+	}
+
+	script.Traits, script.TraitsSpans = sr.state.traits, sr.state.traitsSpans
+	sr.warnings = append(sr.warnings, collectHints(script.Cmds)...)
+	SortWarnings(sr.warnings)
+	script.Warnings = sr.warnings
+
+	return script, nil
+}
+
+// NextCommand parses and returns the next command in the input, so commands
+// can be handled as they are read, e.g. from NewParserFromReader, instead of
+// once the whole script is parsed. It returns io.EOF after the last command.
+// Traits segments are not returned, they are collected for Traits. Input
+// that is empty or has neither commands nor traits is an error as it is for
+// ParseScript, and so is an error once returned on every later call.
+func (sr *ScriptReader) NextCommand() (Command, error) {
+	st := &sr.state
+	switch {
+	case st.err != nil:
+		return Command{}, st.err
+	case len(st.queued) > 0:
+		cmd := st.queued[0]
+		st.queued = st.queued[1:]
+		return cmd, nil
+	case st.done:
+		return Command{}, io.EOF
+	}
+
+	cmd, ok, err := sr.nextCommand()
+	if err != nil {
+		st.err = err
+		return Command{}, err
+	} else if !ok {
+		return sr.NextCommand()
+	}
+	return cmd, nil
+}
+
+// Traits returns the traits read so far, from #key=value and **traits
+// segments or a JSON script. It is complete once NextCommand has returned
+// io.EOF.
+func (sr *ScriptReader) Traits() map[string]any {
+	return maps.Clone(sr.state.traits)
+}
+
+// nextCommand parses up to the end of the next command and returns it. It
+// returns false without an error when there is no command to return, at the
+// end of the input or after queueing the commands of a JSON script.
+func (sr *ScriptReader) nextCommand() (Command, bool, error) {
+	st := &sr.state
+	var next Command
+	ready := false
+	// cmdName is the name of the command being parsed, as far as it is known
+	cmdName := ""
+	// cmdStart and cmdStartPos are the byte and rune offsets the command
+	// being parsed starts at
+	cmdStart, cmdStartPos := 0, int64(0)
+
+	// segmentEnd returns the byte offset and span of the command or traits
+	// segment being parsed, which ends at its || separator if it has one.
+	segmentEnd := func() (int, Span) {
+		if sr.cmdEnd >= 0 {
+			return sr.cmdEnd, Span{Start: cmdStartPos, End: sr.cmdEndPos}
+		}
+		return sr.offset(), Span{Start: cmdStartPos, End: sr.pos}
+	}
+
+	// parseErrAt wraps err in a ParseError, leaving errors that already carry
+	// a position from a nested call untouched.
+	parseErrAt := func(cmdIndex int, err error) error {
+		var pe *ParseError
+		if errors.As(err, &pe) {
+			return err
+		}
+		pe = &ParseError{Err: err, Pos: sr.pos, CmdIndex: cmdIndex}
+		if cmdIndex != TraitsCmdIndex {
+			pe.CmdName = cmdName
+		}
+		return pe
+	}
 
 	parseErr := func(err error) error {
-		return fmt.Errorf("parse error at %d: %w", sr.pos, err)
+		return parseErrAt(st.cmdCount, err)
+	}
+
+	addCmd := func(cmd Command) error {
+		cmdName = cmd.Name
+		if limit := sr.opts.MaxCommands; limit > 0 && st.cmdCount >= limit {
+			return fmt.Errorf("%w: limit is %d", ErrTooManyCommands, limit)
+		}
+		var cmdEnd int
+		cmdEnd, cmd.Span = segmentEnd()
+		cmd.Raw = sr.raw(cmdStart, cmdEnd)
+		next, ready = cmd, true
+		st.cmdCount++
+		return nil
 	}
 
 	parseAutoLaunchCmd := func(prefix string) error {
+		sr.cmdIndex = st.cmdCount
+		cmdName = ZapScriptCmdLaunch
+		// the prefix may have been read up to a separator, the command now
+		// ends wherever parsing its args stops
+		sr.cmdEnd, sr.cmdEndPos = -1, -1
 		args, advArgs, err := sr.parseArgs(prefix, false, true)
 		if err != nil {
 			return parseErr(err)
 		}
+		if sr.opts.DisableAutoLaunch {
+			end, _ := segmentEnd()
+			return parseErr(fmt.Errorf("%w: %q", ErrAutoLaunchDisabled, strings.TrimSpace(sr.raw(cmdStart, end))))
+		}
 		cmd := Command{
 			Name: ZapScriptCmdLaunch,
 		}
 		if len(args) > 0 {
 			cmd.Args = args
+			if detected, ok := sr.detectAutoLaunch(args[0]); ok {
+				cmd = detected
+			}
 		}
-		if len(advArgs) > 0 {
-			cmd.AdvArgs = NewAdvArgs(advArgs)
+		if !advArgs.IsEmpty() {
+			cmd.AdvArgs = advArgs
+		}
+		if addErr := addCmd(cmd); addErr != nil {
+			return parseErr(addErr)
 		}
-		script.Cmds = append(script.Cmds, cmd)
-		hasNonTraitContent = true
 		return nil
 	}
 
-	for {
+	// parseVerbatimLaunchCmd adds content as the only arg of a launch
+	// command, without looking for adv args
+	parseVerbatimLaunchCmd := func(content string) error {
+		sr.cmdIndex = st.cmdCount
+		cmdName = ZapScriptCmdLaunch
+		content = strings.TrimSpace(content)
+		if sr.opts.DisableAutoLaunch {
+			return parseErr(fmt.Errorf("%w: %q", ErrAutoLaunchDisabled, content))
+		}
+		cmd := Command{
+			Name: ZapScriptCmdLaunch,
+			Args: []string{content},
+		}
+		if detected, ok := sr.detectAutoLaunch(content); ok {
+			cmd = detected
+		}
+		if addErr := addCmd(cmd); addErr != nil {
+			return parseErr(addErr)
+		}
+		return nil
+	}
+
+	for !ready {
+		cmdStart, cmdStartPos = sr.offset(), sr.pos
+		sr.markSegment()
+		sr.cmdEnd, sr.cmdEndPos = -1, -1
 		ch, err := sr.read()
 		if err != nil {
-			return script, err
+			return Command{}, false, err
 		} else if ch == eof {
-			break
+			return Command{}, false, sr.finishScript()
 		}
 
+		if !isWhitespace(ch) {
+			st.sawContent = true
+		}
+		sr.cmdIndex = st.cmdCount
+
 		switch {
 		case isWhitespace(ch):
 			continue
 		case sr.pos == 1 && ch == SymJSONStart:
-			// reserve starting { as json script for later
-			return Script{}, ErrInvalidJSON
+			script, jsonErr := sr.parseJSONScript()
+			if jsonErr != nil {
+				return Command{}, false, jsonErr
+			}
+			st.traits, st.queued, st.done = script.Traits, script.Cmds, true
+			return Command{}, false, nil
+		case ch == SymMediaTitleStart && sr.opts.DisableMediaTitle:
+			rest, consumeErr := sr.consumeToEndOfCmd()
+			if consumeErr != nil {
+				return Command{}, false, parseErr(consumeErr)
+			}
+			if autoErr := parseVerbatimLaunchCmd(string(SymMediaTitleStart) + rest); autoErr != nil {
+				return Command{}, false, parseErr(autoErr)
+			}
+			continue
 		case ch == SymMediaTitleStart:
 			// Media title syntax: @System Name/Game Title (optional tags)?advArgs
+			cmdName = ZapScriptCmdLaunchTitle
 			result, err := sr.parseMediaTitleSyntax()
 			if err != nil {
-				return script, parseErr(err)
+				return Command{}, false, parseErr(err)
 			}
 
 			// If not valid media title format (no / found), treat as auto-launch
 			if !result.valid {
 				if autoErr := parseAutoLaunchCmd(string(SymMediaTitleStart) + result.rawContent); autoErr != nil {
-					return script, parseErr(autoErr)
+					return Command{}, false, parseErr(autoErr)
 				}
 				continue
 			}
@@ -258,116 +486,155 @@ func (sr *ScriptReader) ParseScript() (Script, error) {
 			}
 
 			// Only set AdvArgs if there are any
-			if len(result.advArgs) > 0 {
-				cmd.AdvArgs = NewAdvArgs(result.advArgs)
+			if !result.advArgs.IsEmpty() {
+				cmd.AdvArgs = result.advArgs
 			}
 
-			script.Cmds = append(script.Cmds, cmd)
-			hasNonTraitContent = true
+			if addErr := addCmd(cmd); addErr != nil {
+				return Command{}, false, parseErr(addErr)
+			}
 			continue
 		case ch == SymTraitsStart:
+			sr.cmdIndex = TraitsCmdIndex
 			// Traits shorthand syntax: #key=value #key2=value2
 			result, err := sr.parseTraitsSyntax()
 			if err != nil {
-				return script, parseErr(err)
+				return Command{}, false, parseErrAt(TraitsCmdIndex, err)
 			}
 
 			// If fallback is set due to invalid key, defer handling
 			if result.fallback != "" {
 				if result.invalidKey {
-					pendingFallback = result
+					st.pendingFallback = result
 				} else {
 					if autoErr := parseAutoLaunchCmd(result.fallback); autoErr != nil {
-						return script, parseErr(autoErr)
+						return Command{}, false, parseErr(autoErr)
 					}
 				}
 				continue
 			}
 
+			dupErr := sr.checkDuplicateKeys(
+				ErrDuplicateTraitKey, WarningDuplicateTraitKey, "trait key", result.duplicates,
+			)
+			if dupErr != nil {
+				return Command{}, false, parseErrAt(TraitsCmdIndex, dupErr)
+			}
+
 			// Merge traits (later overwrites earlier)
-			if script.Traits == nil {
-				script.Traits = make(map[string]any)
+			if st.traits == nil {
+				st.traits = make(map[string]any)
 			}
 			for k, v := range result.traits {
-				script.Traits[k] = v
+				st.traits[k] = v
 			}
+			_, span := segmentEnd()
+			st.traitsSpans = append(st.traitsSpans, span)
 			continue
 		case ch == SymCmdStart:
+			cmdName = ""
 			next, err := sr.peek()
 			if err != nil {
-				return script, parseErr(err)
+				return Command{}, false, parseErr(err)
 			}
 
 			switch next {
 			case eof:
-				return script, ErrUnexpectedEOF
+				return Command{}, false, ErrUnexpectedEOF
 			case SymCmdStart:
 				if skipErr := sr.skip(); skipErr != nil {
-					return script, parseErr(skipErr)
+					return Command{}, false, parseErr(skipErr)
 				}
 			default:
 				// assume it's actually an auto launch cmd
 				if autoErr := parseAutoLaunchCmd("*"); autoErr != nil {
-					return script, parseErr(autoErr)
+					return Command{}, false, parseErr(autoErr)
+				}
+				continue
+			}
+
+			// **# starts a comment, which runs to the end of the segment and
+			// is dropped
+			if next, peekErr := sr.peek(); peekErr != nil {
+				return Command{}, false, parseErr(peekErr)
+			} else if next == SymCommentStart {
+				if _, commentErr := sr.consumeToEndOfCmd(); commentErr != nil {
+					return Command{}, false, parseErr(commentErr)
 				}
 				continue
 			}
 
 			cmd, buf, err := sr.parseCommand(false)
+			cmdName = cmd.Name
 			switch {
 			case errors.Is(err, ErrInvalidCmdName):
 				// assume it's actually an auto launch cmd
 				if autoErr := parseAutoLaunchCmd("**" + buf); autoErr != nil {
-					return script, parseErr(autoErr)
+					return Command{}, false, parseErr(autoErr)
 				}
 				continue
 			case err != nil:
-				return script, parseErr(err)
+				return Command{}, false, parseErr(err)
 			default:
-				// Handle **traits command by merging into script.Traits
+				// Handle **traits command by merging into the traits
 				if cmd.Name == ZapScriptCmdTraits && len(cmd.Args) > 0 {
 					var traitsData map[string]any
 					if jsonErr := json.Unmarshal([]byte(cmd.Args[0]), &traitsData); jsonErr == nil {
-						if script.Traits == nil {
-							script.Traits = make(map[string]any)
+						if st.traits == nil {
+							st.traits = make(map[string]any)
 						}
 						for k, v := range traitsData {
-							script.Traits[k] = v
+							st.traits[k] = v
 						}
+						_, span := segmentEnd()
+						st.traitsSpans = append(st.traitsSpans, span)
 						continue
 					}
 				}
-				script.Cmds = append(script.Cmds, cmd)
-				hasNonTraitContent = true
+				if addErr := addCmd(cmd); addErr != nil {
+					return Command{}, false, parseErr(addErr)
+				}
 			}
 
 			continue
 		default:
 			err := sr.unread()
 			if err != nil {
-				return script, parseErr(err)
+				return Command{}, false, parseErr(err)
 			}
 
 			err = parseAutoLaunchCmd("")
 			if err != nil {
-				return script, parseErr(err)
+				return Command{}, false, parseErr(err)
 			}
 
 			continue
 		}
 	}
 
+	return next, true, nil
+}
+
+// finishScript checks the script once the input has been read and marks the
+// reader done.
+func (sr *ScriptReader) finishScript() error {
+	st := &sr.state
 	// Handle pending fallback from invalid trait key
-	if pendingFallback != nil {
-		if !hasNonTraitContent {
-			return script, ErrInvalidTraitKey
+	if st.pendingFallback != nil {
+		if st.cmdCount == 0 {
+			return ErrInvalidTraitKey
 		}
 		// Silent fallback when mixed with other content (documented behavior)
 	}
 
-	if len(script.Cmds) == 0 && len(script.Traits) == 0 {
-		return script, ErrEmptyZapScript
+	// a script with only traits is valid, the traits are its data
+	if st.cmdCount == 0 && len(st.traits) == 0 {
+		if !st.sawContent && sr.pos > 0 {
+			return ErrWhitespaceOnlyZapScript
+		}
+		return ErrEmptyZapScript
 	}
 
-	return script, nil
+	st.done = true
+	return nil
 }
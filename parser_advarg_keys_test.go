@@ -0,0 +1,134 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAdvArgKeyNormalization(t *testing.T) {
+	t.Parallel()
+
+	aliases := zapscript.WithAdvArgAliases(map[string]zapscript.Key{
+		"sys": zapscript.KeySystem,
+		"LN":  zapscript.KeyLauncher,
+	})
+
+	tests := []struct {
+		name         string
+		input        string
+		want         map[string]string
+		opts         []zapscript.Option
+		wantWarnings int
+	}{
+		{
+			name:  "mixed case key",
+			input: `**launch:game?Launcher=x`,
+			want:  map[string]string{"launcher": "x"},
+		},
+		{
+			name:  "upper case key",
+			input: `**launch:game?LAUNCHER=x&System=snes`,
+			want:  map[string]string{"launcher": "x", "system": "snes"},
+		},
+		{
+			name:  "value case is kept",
+			input: `**launch:game?launcher=MyLauncher`,
+			want:  map[string]string{"launcher": "MyLauncher"},
+		},
+		{
+			name:  "alias without option",
+			input: `**launch:game?sys=snes`,
+			want:  map[string]string{"sys": "snes"},
+		},
+		{
+			name:  "alias",
+			input: `**launch:game?sys=snes`,
+			opts:  []zapscript.Option{aliases},
+			want:  map[string]string{"system": "snes"},
+		},
+		{
+			name:  "alias is case-insensitive",
+			input: `**launch:game?SYS=snes&ln=x`,
+			opts:  []zapscript.Option{aliases},
+			want:  map[string]string{"system": "snes", "launcher": "x"},
+		},
+		{
+			name:         "alias and canonical key are duplicates",
+			input:        `**launch:game?sys=snes&system=nes`,
+			opts:         []zapscript.Option{aliases},
+			want:         map[string]string{"system": "nes"},
+			wantWarnings: 1,
+		},
+		{
+			name:         "keys differing only by case are duplicates",
+			input:        `**launch:game?launcher=a&Launcher=b`,
+			want:         map[string]string{"launcher": "b"},
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParserWithOptions(tt.input, tt.opts...).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, script.Cmds[0].AdvArgs.Raw()); diff != "" {
+				t.Errorf("AdvArgs mismatch (-want +got):\n%s", diff)
+			}
+			if len(script.Warnings) != tt.wantWarnings {
+				t.Errorf("got %d warnings, want %d: %v", len(script.Warnings), tt.wantWarnings, script.Warnings)
+			}
+		})
+	}
+}
+
+func TestAdvArgKeyNormalizationKeyConstants(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse(`**launch:game?Launcher=Custom&SYSTEM=snes&Action=Details`)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	advArgs := script.Cmds[0].AdvArgs
+	for key, want := range map[zapscript.Key]string{
+		zapscript.KeyLauncher: "Custom",
+		zapscript.KeySystem:   "snes",
+		zapscript.KeyAction:   "Details",
+	} {
+		if got := advArgs.Get(key); got != want {
+			t.Errorf("Get(%s) = %q, want %q", key, got, want)
+		}
+	}
+	if !zapscript.IsActionDetails(advArgs.GetAction()) {
+		t.Errorf("IsActionDetails(%q) = false, want true", advArgs.GetAction())
+	}
+
+	data, err := json.Marshal(advArgs)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if got, want := string(data), `{"launcher":"Custom","system":"snes","action":"Details"}`; got != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+var reUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func uuidDetector(content string) (zapscript.Command, bool) {
+	if !reUUID.MatchString(content) {
+		return zapscript.Command{}, false
+	}
+	return zapscript.Command{Name: "launch.uuid", Args: []string{content}}, true
+}
+
+func systemTitleDetector(content string) (zapscript.Command, bool) {
+	system, title, ok := strings.Cut(content, ":")
+	if !ok || system == "" || title == "" || strings.ContainsAny(content, `/\`) {
+		return zapscript.Command{}, false
+	}
+	return zapscript.Command{
+		Name: zapscript.ZapScriptCmdLaunchTitle,
+		Args: []string{system + "/" + title},
+	}, true
+}
+
+func TestParseAutoLaunchDetectors(t *testing.T) {
+	t.Parallel()
+
+	const uuid = "0b3c6a2e-9f1d-4c7a-8e5b-2d4f6a8c0e1f"
+
+	tests := []struct {
+		name      string
+		input     string
+		detectors []zapscript.AutoLaunchDetector
+		want      zapscript.Script
+	}{
+		{
+			name:      "uuid detected",
+			input:     uuid,
+			detectors: []zapscript.AutoLaunchDetector{uuidDetector},
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: "launch.uuid", Args: []string{uuid}}},
+			},
+		},
+		{
+			name:      "uuid detected with adv args",
+			input:     uuid + "?launcher=retroarch",
+			detectors: []zapscript.AutoLaunchDetector{uuidDetector},
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{
+					Name:    "launch.uuid",
+					Args:    []string{uuid},
+					AdvArgs: zapscript.NewAdvArgs(map[string]string{"launcher": "retroarch"}),
+				}},
+			},
+		},
+		{
+			name:      "no detector matches falls back to launch",
+			input:     "/games/snes/mario.sfc",
+			detectors: []zapscript.AutoLaunchDetector{uuidDetector, systemTitleDetector},
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"/games/snes/mario.sfc"}}},
+			},
+		},
+		{
+			name:      "second detector used when first declines",
+			input:     "snes:Super Mario World",
+			detectors: []zapscript.AutoLaunchDetector{uuidDetector, systemTitleDetector},
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{
+					Name: zapscript.ZapScriptCmdLaunchTitle,
+					Args: []string{"snes/Super Mario World"},
+				}},
+			},
+		},
+		{
+			name:  "first matching detector wins",
+			input: uuid,
+			detectors: []zapscript.AutoLaunchDetector{
+				uuidDetector,
+				func(string) (zapscript.Command, bool) {
+					return zapscript.Command{Name: "never"}, true
+				},
+			},
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: "launch.uuid", Args: []string{uuid}}},
+			},
+		},
+		{
+			name:      "detectors apply to each chained segment",
+			input:     uuid + "||**echo:hi||/games/a.rom",
+			detectors: []zapscript.AutoLaunchDetector{uuidDetector},
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "launch.uuid", Args: []string{uuid}},
+					{Name: zapscript.ZapScriptCmdEcho, Args: []string{"hi"}},
+					{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"/games/a.rom"}},
+				},
+			},
+		},
+		{
+			name:      "explicit commands are not passed to detectors",
+			input:     "**echo:" + uuid,
+			detectors: []zapscript.AutoLaunchDetector{uuidDetector},
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdEcho, Args: []string{uuid}}},
+			},
+		},
+		{
+			name:  "no detectors",
+			input: uuid,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{uuid}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := zapscript.NewParserWithOptions(tt.input, zapscript.WithAutoLaunchDetectors(tt.detectors...))
+			got, err := p.ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
+				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
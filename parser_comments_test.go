@@ -0,0 +1,113 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseComments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr    error
+		wantTraits map[string]any
+		name       string
+		input      string
+		want       []zapscript.Command
+	}{
+		{
+			name:  "between commands",
+			input: "**launch:game||**# this part reboots||**reboot",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"game"}}, {Name: "reboot"}},
+		},
+		{
+			name:  "leading",
+			input: "**# mapping for the arcade cabinet||**launch:game",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"game"}}},
+		},
+		{
+			name:  "trailing",
+			input: "**launch:game||**# done",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"game"}}},
+		},
+		{
+			name:  "empty comment",
+			input: "**#||**stop",
+			want:  []zapscript.Command{{Name: "stop"}},
+		},
+		{
+			name:  "syntax inside comment is ignored",
+			input: `**# "unclosed [[ expr ?a=b&c #x=1||**stop`,
+			want:  []zapscript.Command{{Name: "stop"}},
+		},
+		{
+			name:  "whitespace around comment",
+			input: "  **# note  ||  **stop",
+			want:  []zapscript.Command{{Name: "stop"}},
+		},
+		{
+			name:       "traits shorthand still works",
+			input:      "#difficulty=hard #lives=3||**# traits above||**launch:game",
+			want:       []zapscript.Command{{Name: "launch", Args: []string{"game"}}},
+			wantTraits: map[string]any{"difficulty": "hard", "lives": int64(3)},
+		},
+		{
+			name:       "comment with only traits",
+			input:      "**# defaults||#difficulty=hard",
+			wantTraits: map[string]any{"difficulty": "hard"},
+		},
+		{
+			name:    "comment only",
+			input:   "**# nothing to run",
+			wantErr: zapscript.ErrEmptyZapScript,
+		},
+		{
+			name:    "comments only",
+			input:   "**# one||**# two||",
+			wantErr: zapscript.ErrEmptyZapScript,
+		},
+		{
+			name:  "space before hash is not a comment",
+			input: "** # not a comment",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"** # not a comment"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.Parse(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got.Cmds, diffOpts); diff != "" {
+				t.Errorf("Parse() cmds mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantTraits, got.Traits); diff != "" {
+				t.Errorf("Parse() traits mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
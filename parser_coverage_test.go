@@ -21,6 +21,7 @@ import (
 
 	"github.com/ZaparooProject/go-zapscript"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 // ============================================================================
@@ -255,7 +256,7 @@ func TestParseInputMacroArgs(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -420,7 +421,7 @@ func TestParseQuotedArgs(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -711,7 +712,12 @@ func TestParseAdvArgs(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			// JSON value metadata is covered by TestAdvArgsIsJSON
+			opts := cmp.Options{
+				diffOpts,
+				cmpopts.IgnoreFields(zapscript.AdvArgs{}, "json"),
+			}
+			if diff := cmp.Diff(tt.want, got, opts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -770,7 +776,7 @@ func TestParseAdvArgsEscapeFallbackRegression(t *testing.T) {
 			if err != nil {
 				t.Fatalf("ParseScript() unexpected error: %v", err)
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -848,7 +854,12 @@ func TestParseEdgeCases(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			// JSON value metadata is covered by TestAdvArgsIsJSON
+			opts := cmp.Options{
+				diffOpts,
+				cmpopts.IgnoreFields(zapscript.AdvArgs{}, "json"),
+			}
+			if diff := cmp.Diff(tt.want, got, opts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -0,0 +1,182 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDisableExpressions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		input      string
+		wantCmds   []zapscript.Command
+		wantTraits map[string]any
+	}{
+		{
+			name:  "args",
+			input: `**cmd:[[x]],"[[y]]",'a[[z]]b'`,
+			wantCmds: []zapscript.Command{
+				{Name: "cmd", Args: []string{"[[x]]", "[[y]]", "a[[z]]b"}},
+			},
+		},
+		{
+			name:  "adv args",
+			input: `**cmd?a=[[x]]&b="[[y]]"`,
+			wantCmds: []zapscript.Command{{
+				Name:    "cmd",
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{"a": "[[x]]", "b": "[[y]]"}),
+			}},
+		},
+		{
+			name:  "escaped",
+			input: `**cmd:^[[x]]?a=^[[y]]`,
+			wantCmds: []zapscript.Command{{
+				Name:    "cmd",
+				Args:    []string{"[[x]]"},
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{"a": "[[y]]"}),
+			}},
+		},
+		{
+			name:  "auto launch",
+			input: `/roms/[[x]].bin`,
+			wantCmds: []zapscript.Command{
+				{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"/roms/[[x]].bin"}},
+			},
+		},
+		{
+			name:  "media title",
+			input: `@snes/[[x]]`,
+			wantCmds: []zapscript.Command{
+				{Name: zapscript.ZapScriptCmdLaunchTitle, Args: []string{"snes/[[x]]"}},
+			},
+		},
+		{
+			name:  "traits",
+			input: `#a=[[x]] #b="[[y]]" #c=[[[z]]] #d=["[[w]]"]`,
+			wantTraits: map[string]any{
+				"a": "[[x]]",
+				"b": "[[y]]",
+				"c": "[[[z]]]",
+				"d": []any{"[[w]]"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := zapscript.NewParserWithOptions(tt.input, zapscript.WithDisableExpressions())
+			got, err := p.ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCmds, got.Cmds, diffOpts); diff != "" {
+				t.Errorf("ParseScript() cmds mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantTraits, got.Traits); diff != "" {
+				t.Errorf("ParseScript() traits mismatch (-want +got):\n%s", diff)
+			}
+			if exprs := got.Expressions(); len(exprs) != 0 {
+				t.Errorf("Expressions() = %q, want none", exprs)
+			}
+
+			reparsed, err := zapscript.NewParserWithOptions(got.String(), zapscript.WithDisableExpressions()).
+				ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript(String()) unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(got.Cmds, reparsed.Cmds, diffOpts); diff != "" {
+				t.Errorf("round trip cmds mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(got.Traits, reparsed.Traits); diff != "" {
+				t.Errorf("round trip traits mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDisableExpressionsEscapeUnchanged(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		`**cmd:^[[x]]`,
+		`**cmd:"a^[[x]]b"?a=^[[y]]`,
+		`#a=^[[x]]`,
+		`**cmd:[x],^[`,
+	}
+	for _, input := range inputs {
+		want, err := zapscript.NewParser(input).ParseScript()
+		if err != nil {
+			t.Fatalf("ParseScript(%q) unexpected error: %v", input, err)
+		}
+		got, err := zapscript.NewParserWithOptions(input, zapscript.WithDisableExpressions()).ParseScript()
+		if err != nil {
+			t.Fatalf("ParseScript(%q) with expressions disabled unexpected error: %v", input, err)
+		}
+		if diff := cmp.Diff(want, got, diffOpts); diff != "" {
+			t.Errorf("ParseScript(%q) mismatch (-want +got):\n%s", input, diff)
+		}
+	}
+}
+
+func TestDisableExpressionsRejectsTokens(t *testing.T) {
+	t.Parallel()
+
+	inputs := map[string]string{
+		"token in arg":         "**cmd:" + tokExpr("x"),
+		"token in trait":       "#a=" + tokExpr("x"),
+		"JSON expression arg":  `{"cmds":[{"name":"cmd","args":["[[x]]"]}]}`,
+		"JSON expression adv":  `{"cmds":[{"name":"cmd","advArgs":{"a":"[[x]]"}}]}`,
+		"JSON escaped token":   `{"cmds":[{"name":"cmd","args":["\ue000x\ue001"]}]}`,
+		"JSON token in traits": `{"traits":{"a":["\ue000x\ue001"]}}`,
+	}
+	for name, input := range inputs {
+		_, err := zapscript.NewParserWithOptions(input, zapscript.WithDisableExpressions()).ParseScript()
+		if !errors.Is(err, zapscript.ErrExpressionsDisabled) {
+			t.Errorf("%s: ParseScript() error = %v, want ErrExpressionsDisabled", name, err)
+		}
+	}
+}
+
+func TestDisableExpressionsEval(t *testing.T) {
+	t.Parallel()
+
+	p := zapscript.NewParserWithOptions("a"+tokExpr("platform"), zapscript.WithDisableExpressions())
+	_, err := p.EvalExpressions(zapscript.ArgExprEnv{Platform: "mister"})
+	if !errors.Is(err, zapscript.ErrExpressionsDisabled) {
+		t.Errorf("EvalExpressions() error = %v, want ErrExpressionsDisabled", err)
+	}
+	if code := zapscript.ErrorCode(err); code != "expressions_disabled" {
+		t.Errorf("ErrorCode() = %q, want %q", code, "expressions_disabled")
+	}
+
+	p = zapscript.NewParserWithOptions("plain [text]", zapscript.WithDisableExpressions())
+	got, err := p.EvalExpressions(zapscript.ArgExprEnv{})
+	if err != nil {
+		t.Fatalf("EvalExpressions() unexpected error: %v", err)
+	}
+	if got != "plain [text]" {
+		t.Errorf("EvalExpressions() = %q, want %q", got, "plain [text]")
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDuplicateKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantStrictErr error
+		name          string
+		input         string
+		wantKey       string
+		wantWarnings  []zapscript.Warning
+	}{
+		{
+			name:          "adv arg with different values",
+			input:         `**launch:game?launcher=a&launcher=b`,
+			wantStrictErr: zapscript.ErrDuplicateAdvArg,
+			wantKey:       `"launcher"`,
+			wantWarnings: []zapscript.Warning{{
+				Code:     zapscript.WarningDuplicateAdvArg,
+				Message:  `adv arg "launcher" is set more than once, using the last value`,
+				Fragment: "launcher",
+				Pos:      26,
+				CmdIndex: 0,
+			}},
+		},
+		{
+			name:          "adv arg with identical values",
+			input:         `**launch:game?launcher=a&launcher=a`,
+			wantStrictErr: zapscript.ErrDuplicateAdvArg,
+			wantKey:       `"launcher"`,
+			wantWarnings: []zapscript.Warning{{
+				Code:     zapscript.WarningDuplicateAdvArg,
+				Message:  `adv arg "launcher" is set more than once, using the last value`,
+				Fragment: "launcher",
+				Pos:      26,
+				CmdIndex: 0,
+			}},
+		},
+		{
+			name:          "adv arg repeated three times warns once",
+			input:         `**launch:game?a=1&a=2&a=3`,
+			wantStrictErr: zapscript.ErrDuplicateAdvArg,
+			wantKey:       `"a"`,
+			wantWarnings: []zapscript.Warning{{
+				Code:     zapscript.WarningDuplicateAdvArg,
+				Message:  `adv arg "a" is set more than once, using the last value`,
+				Fragment: "a",
+				Pos:      19,
+				CmdIndex: 0,
+			}},
+		},
+		{
+			name:          "adv arg on auto-launch",
+			input:         `game.rom?a=1&a=2`,
+			wantStrictErr: zapscript.ErrDuplicateAdvArg,
+			wantKey:       `"a"`,
+			wantWarnings: []zapscript.Warning{{
+				Code:     zapscript.WarningDuplicateAdvArg,
+				Message:  `adv arg "a" is set more than once, using the last value`,
+				Fragment: "a",
+				Pos:      14,
+				CmdIndex: 0,
+			}},
+		},
+		{
+			name:          "trait with different values",
+			input:         `#count=1 #count=2`,
+			wantStrictErr: zapscript.ErrDuplicateTraitKey,
+			wantKey:       `"count"`,
+			wantWarnings: []zapscript.Warning{{
+				Code:     zapscript.WarningDuplicateTraitKey,
+				Message:  `trait key "count" is set more than once, using the last value`,
+				Fragment: "count",
+				Pos:      11,
+				CmdIndex: zapscript.TraitsCmdIndex,
+			}},
+		},
+		{
+			name:          "trait with identical values is case-insensitive",
+			input:         `#favorite #Favorite`,
+			wantStrictErr: zapscript.ErrDuplicateTraitKey,
+			wantKey:       `"favorite"`,
+			wantWarnings: []zapscript.Warning{{
+				Code:     zapscript.WarningDuplicateTraitKey,
+				Message:  `trait key "favorite" is set more than once, using the last value`,
+				Fragment: "favorite",
+				Pos:      12,
+				CmdIndex: zapscript.TraitsCmdIndex,
+			}},
+		},
+		{
+			name:          "adv arg in later command",
+			input:         `**launch:a||**launch:b?x=1&x=2`,
+			wantStrictErr: zapscript.ErrDuplicateAdvArg,
+			wantKey:       `"x"`,
+			wantWarnings: []zapscript.Warning{{
+				Code:     zapscript.WarningDuplicateAdvArg,
+				Message:  `adv arg "x" is set more than once, using the last value`,
+				Fragment: "x",
+				Pos:      28,
+				CmdIndex: 1,
+			}},
+		},
+		{
+			name:  "same adv arg in separate commands",
+			input: `**launch:a?launcher=x||**launch:b?launcher=x`,
+		},
+		{
+			name:  "same trait in separate segments",
+			input: `#count=1||#count=2`,
+		},
+		{
+			name:  "distinct keys",
+			input: `#a #b||**launch:game?x=1&y=2`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("lenient ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantWarnings, script.Warnings); diff != "" {
+				t.Errorf("lenient ParseScript() warnings mismatch (-want +got):\n%s", diff)
+			}
+
+			_, err = zapscript.NewParserWithOptions(tt.input, zapscript.WithStrictMode()).ParseScript()
+			if tt.wantStrictErr == nil {
+				if err != nil {
+					t.Fatalf("strict ParseScript() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantStrictErr) {
+				t.Fatalf("strict ParseScript() error = %v, want %v", err, tt.wantStrictErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantKey) {
+				t.Errorf("strict ParseScript() error = %q, want it to name %s", err, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestDuplicateKeysKeepLastValue(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser(`#count=1 #count=2||**launch:game?launcher=a&launcher=b`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	if got := script.Cmds[0].AdvArgs.Get(zapscript.KeyLauncher); got != "b" {
+		t.Errorf("launcher = %q, want %q", got, "b")
+	}
+	if diff := cmp.Diff(map[string]any{"count": int64(2)}, script.Traits); diff != "" {
+		t.Errorf("Traits mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAdvArgsGetAll(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		input      string
+		wantString string
+		wantLast   string
+		wantAll    []string
+	}{
+		{
+			name:       "three repeats in order",
+			input:      "**launch.random:snes?tag=usa&system=snes&tag=en&tag=-unlicensed",
+			wantAll:    []string{"usa", "en", "-unlicensed"},
+			wantLast:   "-unlicensed",
+			wantString: "**launch.random:snes?tag=usa&tag=en&tag=-unlicensed&system=snes",
+		},
+		{
+			name:       "single key",
+			input:      "**launch.random:snes?tag=usa",
+			wantAll:    []string{"usa"},
+			wantLast:   "usa",
+			wantString: "**launch.random:snes?tag=usa",
+		},
+		{
+			name:       "not set",
+			input:      "**launch.random:snes?system=snes",
+			wantString: "**launch.random:snes?system=snes",
+		},
+		{
+			name:       "quoted repeats",
+			input:      `**launch.random:snes?tag="a&b"&tag=c`,
+			wantAll:    []string{"a&b", "c"},
+			wantLast:   "c",
+			wantString: `**launch.random:snes?tag="a&b"&tag=c`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			advArgs := script.Cmds[0].AdvArgs
+			if diff := cmp.Diff(tt.wantAll, advArgs.GetAll("tag")); diff != "" {
+				t.Errorf("GetAll() mismatch (-want +got):\n%s", diff)
+			}
+			if got := advArgs.Get("tag"); got != tt.wantLast {
+				t.Errorf("Get() = %q, want %q", got, tt.wantLast)
+			}
+
+			got := script.String()
+			if got != tt.wantString {
+				t.Errorf("String() = %q, want %q", got, tt.wantString)
+			}
+			reparsed, err := zapscript.Parse(got)
+			if err != nil {
+				t.Fatalf("Parse(String()) unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantAll, reparsed.Cmds[0].AdvArgs.GetAll("tag")); diff != "" {
+				t.Errorf("GetAll() after round trip mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdvArgsGetAllCopies(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**cmd?tag=a&tag=b")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	advArgs := script.Cmds[0].AdvArgs
+	advArgs.GetAll("tag")[0] = "changed"
+	if diff := cmp.Diff([]string{"a", "b"}, advArgs.GetAll("tag")); diff != "" {
+		t.Errorf("GetAll() mismatch after modifying result (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"c"}, advArgs.With("tag", "c").GetAll("tag")); diff != "" {
+		t.Errorf("With().GetAll() mismatch (-want +got):\n%s", diff)
+	}
+	if got := advArgs.Without("tag").GetAll("tag"); got != nil {
+		t.Errorf("Without().GetAll() = %q, want nil", got)
+	}
+}
+
+// Repeated keys are written once with their last value, as the JSON object
+// form has no room for more.
+func TestAdvArgsGetAllJSONLastWins(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**cmd?tag=a&tag=b")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	data, err := json.Marshal(script.Cmds[0].AdvArgs)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if got, want := string(data), `{"tag":"b"}`; got != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
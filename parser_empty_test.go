@@ -0,0 +1,88 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEmptyScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr        error
+		name           string
+		input          string
+		wantWhitespace bool
+	}{
+		{name: "empty", input: "", wantErr: zapscript.ErrEmptyZapScript},
+		{name: "only BOM", input: "\uFEFF", wantErr: zapscript.ErrEmptyZapScript},
+		{name: "empty traits", input: "**traits:{}", wantErr: zapscript.ErrEmptyZapScript},
+		{name: "spaces", input: "   ", wantErr: zapscript.ErrEmptyZapScript, wantWhitespace: true},
+		{name: "mixed whitespace", input: "   \n  \t\r\n", wantErr: zapscript.ErrEmptyZapScript, wantWhitespace: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := zapscript.NewParser(tt.input).ParseScript()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseScript() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := errors.Is(err, zapscript.ErrWhitespaceOnlyZapScript); got != tt.wantWhitespace {
+				t.Errorf("errors.Is(err, ErrWhitespaceOnlyZapScript) = %v, want %v", got, tt.wantWhitespace)
+			}
+		})
+	}
+}
+
+func TestTraitsOnlyScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		want  map[string]any
+		name  string
+		input string
+	}{
+		{name: "shorthand", input: "#a=1", want: map[string]any{"a": int64(1)}},
+		{name: "several shorthand", input: "#a=1 #b", want: map[string]any{"a": int64(1), "b": true}},
+		{name: "full syntax", input: `**traits:{"a":1}`, want: map[string]any{"a": float64(1)}},
+		{name: "surrounding whitespace", input: "  #a=1  ", want: map[string]any{"a": int64(1)}},
+		{name: "trailing separator", input: "#a=1||  ", want: map[string]any{"a": int64(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if len(script.Cmds) != 0 {
+				t.Errorf("ParseScript() got %d commands, want none", len(script.Cmds))
+			}
+			if diff := cmp.Diff(tt.want, script.Traits); diff != "" {
+				t.Errorf("Traits mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInvalidEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		wantPos string
+	}{
+		{name: "invalid byte in arg", input: "**cmd:a\xffb", wantPos: "position 8"},
+		{name: "truncated sequence at end", input: "**cmd:a\xc3", wantPos: "position 8"},
+		{name: "invalid first byte", input: "\xfe**cmd", wantPos: "position 1"},
+		{name: "invalid byte after command start", input: "*\xff", wantPos: "position 2"},
+		{name: "invalid byte in adv arg", input: "**cmd?key=\x80", wantPos: "position 11"},
+		{name: "invalid byte in trait", input: "#a=\xff", wantPos: "position 4"},
+		{name: "NUL byte", input: "**cmd:a\x00b", wantPos: "position 8"},
+		{name: "control character", input: "**cmd:\x1b[0m", wantPos: "position 7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := zapscript.NewParser(tt.input).ParseScript()
+			if !errors.Is(err, zapscript.ErrInvalidEncoding) {
+				t.Fatalf("ParseScript() error = %v, want %v", err, zapscript.ErrInvalidEncoding)
+			}
+			if !strings.Contains(err.Error(), tt.wantPos) {
+				t.Errorf("ParseScript() error = %q, want it to mention %q", err, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestValidEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []zapscript.Command
+	}{
+		{
+			name:  "leading BOM is stripped",
+			input: "\uFEFF**launch:game",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"game"}}},
+		},
+		{
+			name:  "BOM after start is kept",
+			input: "**launch:a\uFEFFb",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"a\uFEFFb"}}},
+		},
+		{
+			name:  "literal replacement character",
+			input: "**launch:a\uFFFDb",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"a\uFFFDb"}}},
+		},
+		{
+			name:  "whitespace control characters",
+			input: "**launch:a\tb\r\n",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"a\tb"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, script.Cmds, diffOpts); diff != "" {
+				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLeadingBOMBeforeJSON(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser("\uFEFF{\"cmds\":[{\"name\":\"stop\"}]}").ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	if len(script.Cmds) != 1 || script.Cmds[0].Name != "stop" {
+		t.Errorf("ParseScript() cmds = %v, want a single stop command", script.Cmds)
+	}
+}
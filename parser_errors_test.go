@@ -0,0 +1,160 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestParseErrorCmdIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr      error
+		name         string
+		input        string
+		wantCmdName  string
+		opts         []zapscript.Option
+		wantCmdIndex int
+	}{
+		{
+			name:         "unterminated quote in middle command",
+			input:        `**launch:a||**bad:"||**echo:done`,
+			wantErr:      zapscript.ErrUnmatchedQuote,
+			wantCmdIndex: 1,
+			wantCmdName:  "bad",
+		},
+		{
+			name:         "first command",
+			input:        `**echo:{bad}||**launch:a||**launch:b`,
+			wantErr:      zapscript.ErrInvalidJSON,
+			wantCmdIndex: 0,
+			wantCmdName:  "echo",
+		},
+		{
+			name:         "middle command",
+			input:        `**launch:a||**echo:{bad}||**launch:b`,
+			wantErr:      zapscript.ErrInvalidJSON,
+			wantCmdIndex: 1,
+			wantCmdName:  "echo",
+		},
+		{
+			name:         "last command",
+			input:        `**launch:a||**launch:b||**echo:"unterminated`,
+			wantErr:      zapscript.ErrUnmatchedQuote,
+			wantCmdIndex: 2,
+			wantCmdName:  "echo",
+		},
+		{
+			name:         "auto-launch command",
+			input:        `**launch:a||{bad}`,
+			wantErr:      zapscript.ErrInvalidJSON,
+			wantCmdIndex: 1,
+			wantCmdName:  "launch",
+		},
+		{
+			name:         "media title command",
+			input:        `**launch:a||@snes/Game?a="unterminated`,
+			wantErr:      zapscript.ErrUnmatchedQuote,
+			wantCmdIndex: 1,
+			wantCmdName:  "launch.title",
+		},
+		{
+			name:         "traits segments are not counted",
+			input:        `#a=1||**launch:a||**echo:{bad}`,
+			wantErr:      zapscript.ErrInvalidJSON,
+			wantCmdIndex: 1,
+			wantCmdName:  "echo",
+		},
+		{
+			name:         "traits segment",
+			input:        `**launch:a||#a #a`,
+			opts:         []zapscript.Option{zapscript.WithStrictMode()},
+			wantErr:      zapscript.ErrDuplicateTraitKey,
+			wantCmdIndex: zapscript.TraitsCmdIndex,
+			wantCmdName:  "",
+		},
+		{
+			name:         "command limit",
+			input:        `a||b||c`,
+			opts:         []zapscript.Option{zapscript.WithMaxCommands(2)},
+			wantErr:      zapscript.ErrTooManyCommands,
+			wantCmdIndex: 2,
+			wantCmdName:  "launch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := zapscript.NewParserWithOptions(tt.input, tt.opts...).ParseScript()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseScript() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var pe *zapscript.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("ParseScript() error = %v, want a *ParseError", err)
+			}
+			if pe.CmdIndex != tt.wantCmdIndex {
+				t.Errorf("CmdIndex = %d, want %d", pe.CmdIndex, tt.wantCmdIndex)
+			}
+			if pe.CmdName != tt.wantCmdName {
+				t.Errorf("CmdName = %q, want %q", pe.CmdName, tt.wantCmdName)
+			}
+		})
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  *zapscript.ParseError
+		want string
+	}{
+		{
+			err:  &zapscript.ParseError{Err: zapscript.ErrUnmatchedQuote, Pos: 32, CmdIndex: 1, CmdName: "bad"},
+			want: "parse error at 32: command 2 (bad): unmatched quote",
+		},
+		{
+			err:  &zapscript.ParseError{Err: zapscript.ErrEmptyCmdName, Pos: 3, CmdIndex: 0},
+			want: "parse error at 3: command 1: command name is empty",
+		},
+		{
+			err: &zapscript.ParseError{
+				Err:      zapscript.ErrDuplicateTraitKey,
+				Pos:      4,
+				CmdIndex: zapscript.TraitsCmdIndex,
+			},
+			want: "parse error at 4: traits: duplicate trait key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
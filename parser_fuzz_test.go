@@ -16,86 +16,162 @@
 package zapscript
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
+// parseScriptSeeds is the seed corpus shared by the whole-script fuzz tests.
+var parseScriptSeeds = []string{
+	// Valid commands
+	`**launch:game.rom`,
+	`**delay:1000`,
+	`**launch.title:snes/Super Mario World`,
+	`**cmd:arg1,arg2,arg3?key=value&other=thing`,
+	// Input macro — new grammar seeds
+	`**input.keyboard:{a*5}`,
+	`**input.keyboard:{"hello"*2}`,
+	`**input.keyboard:{text:world*3}`,
+	`**input.keyboard:{delay:100}`,
+	`**input.keyboard:{_shift}ABC{^shift}`,
+	`**input.keyboard:{~enter:200}`,
+	`**input.keyboard:{hold:a:1s}`,
+	`**input.text:raw text with spaces`,
+	`**input.text:url?with=query`,
+	`**input.keyboard:{a*1001}`,
+	`**input.keyboard:{}`,
+	`**input.keyboard:{*5}`,
+	// Chained commands
+	`**launch:game||**delay:500||**notify:done`,
+	// Generic launch (no ** prefix)
+	`/path/to/game.rom`,
+	`Genesis/Sonic.md?launcher=custom`,
+	// Media title syntax
+	`@snes/Super Mario World`,
+	`@genesis/Sonic (USA) (Rev 1)?tags=region:us`,
+	`@[[active_media.system_id]]/[[a / b]] Game`,
+	// Expressions
+	`**launch:[[game_path]]`,
+	`**notify:Hello [[username]]!`,
+	// Quotes
+	`**cmd:"quoted arg",unquoted`,
+	`**cmd:'single quotes'`,
+	// Escapes
+	`**cmd:arg^,with^,commas`,
+	`**path:C^:^/Games^/ROM.bin`,
+	// JSON-like
+	`**api:{"key": "value"}`,
+	// Edge cases
+	``,
+	`**`,
+	`**:`,
+	`**cmd:`,
+	`||`,
+	`||||`,
+	`**cmd?`,
+	`**cmd?=`,
+	`**cmd?key=`,
+	`**cmd?=value`,
+	// Malformed
+	`[[`,
+	`]]`,
+	`[[unclosed`,
+	`"unclosed quote`,
+	`'unclosed single`,
+	// Special characters
+	`**cmd:émoji🎮`,
+	`**cmd:日本語`, //nolint:gosmopolitan // Japanese test case
+	`**cmd:	tabs	and  spaces`,
+	// Long input
+	`**cmd:` + string(make([]byte, 1000)),
+	// Invalid encoding
+	"**cmd:\xff",
+	"**cmd:a\xc3",
+	"\xed\xa0\x80",
+	"**\xfe**cmd",
+	"**cmd:a?key=\x80",
+	"#trait=\xff",
+	"@snes/\xc0\xaf",
+	"**cmd:a\x00b",
+	"**cmd:\x1b[0m",
+	"\uFEFF**launch:game",
+	"\uFEFF\uFEFF",
+	// Traits
+	`#name=mario #level=5 #tags=[a,b]||**launch:game`,
+	`**traits:{"data":{"x":1,"y":[true,null]},"s":"a||b"}`,
+	`#a=1||**traits:{"b":"c"}`,
+	`#d=[[scanned.data]] #e="v[[version]]" #f=[[[platform]],^[[x]]]`,
+}
+
 // FuzzParseScript tests that ParseScript never panics on arbitrary input.
 // The parser should either return a valid Script or an error, never crash.
 func FuzzParseScript(f *testing.F) {
-	// Seed corpus with valid and edge-case inputs
-	seeds := []string{
-		// Valid commands
-		`**launch:game.rom`,
-		`**delay:1000`,
-		`**launch.title:snes/Super Mario World`,
-		`**cmd:arg1,arg2,arg3?key=value&other=thing`,
-		// Input macro — new grammar seeds
-		`**input.keyboard:{a*5}`,
-		`**input.keyboard:{"hello"*2}`,
-		`**input.keyboard:{text:world*3}`,
-		`**input.keyboard:{delay:100}`,
-		`**input.keyboard:{_shift}ABC{^shift}`,
-		`**input.keyboard:{~enter:200}`,
-		`**input.keyboard:{hold:a:1s}`,
-		`**input.text:raw text with spaces`,
-		`**input.text:url?with=query`,
-		`**input.keyboard:{a*1001}`,
-		`**input.keyboard:{}`,
-		`**input.keyboard:{*5}`,
-		// Chained commands
-		`**launch:game||**delay:500||**notify:done`,
-		// Generic launch (no ** prefix)
-		`/path/to/game.rom`,
-		`Genesis/Sonic.md?launcher=custom`,
-		// Media title syntax
-		`@snes/Super Mario World`,
-		`@genesis/Sonic (USA) (Rev 1)?tags=region:us`,
-		// Expressions
-		`**launch:[[game_path]]`,
-		`**notify:Hello [[username]]!`,
-		// Quotes
-		`**cmd:"quoted arg",unquoted`,
-		`**cmd:'single quotes'`,
-		// Escapes
-		`**cmd:arg^,with^,commas`,
-		`**path:C^:^/Games^/ROM.bin`,
-		// JSON-like
-		`**api:{"key": "value"}`,
-		// Edge cases
-		``,
-		`**`,
-		`**:`,
-		`**cmd:`,
-		`||`,
-		`||||`,
-		`**cmd?`,
-		`**cmd?=`,
-		`**cmd?key=`,
-		`**cmd?=value`,
-		// Malformed
-		`[[`,
-		`]]`,
-		`[[unclosed`,
-		`"unclosed quote`,
-		`'unclosed single`,
-		// Special characters
-		`**cmd:émoji🎮`,
-		`**cmd:日本語`, //nolint:gosmopolitan // Japanese test case
-		`**cmd:	tabs	and  spaces`,
-		// Long input
-		`**cmd:` + string(make([]byte, 1000)),
+	for _, seed := range parseScriptSeeds {
+		f.Add(seed)
 	}
 
-	for _, seed := range seeds {
+	f.Fuzz(func(t *testing.T, input string) {
+		// Should not panic - either returns result or error
+		script, err := NewParser(input).ParseScript()
+
+		// Errors must be deterministic for the same input
+		_, again := NewParser(input).ParseScript()
+		if fmt.Sprint(err) != fmt.Sprint(again) {
+			t.Fatalf("ParseScript(%q) errors differ: %v, then %v", input, err, again)
+		}
+
+		if err == nil && !utf8.ValidString(input) {
+			t.Fatalf("ParseScript(%q) accepted invalid UTF-8", input)
+		}
+
+		// each command's span must cover its source text
+		runes := []rune(strings.TrimPrefix(input, utf8BOM))
+		for _, cmd := range script.Cmds {
+			if cmd.Raw == "" {
+				continue
+			}
+			if cmd.Span.Start < 0 || cmd.Span.End > int64(len(runes)) || cmd.Span.Start > cmd.Span.End ||
+				string(runes[cmd.Span.Start:cmd.Span.End]) != cmd.Raw {
+				t.Fatalf("ParseScript(%q) command %q has span %v", input, cmd.Raw, cmd.Span)
+			}
+		}
+	})
+}
+
+// FuzzScanner tests that the Scanner never panics and that its tokens are
+// in order, do not overlap, hold their source text and cover every rune but
+// whitespace between them.
+func FuzzScanner(f *testing.F) {
+	for _, seed := range parseScriptSeeds {
 		f.Add(seed)
 	}
 
-	f.Fuzz(func(_ *testing.T, input string) {
-		p := NewParser(input)
-		// Should not panic - either returns result or error
-		_, _ = p.ParseScript()
+	f.Fuzz(func(t *testing.T, input string) {
+		runes := []rune(strings.TrimPrefix(input, utf8BOM))
+		prevEnd := int64(0)
+		for _, tok := range Tokenize(input) {
+			if tok.Start < prevEnd || tok.End <= tok.Start || tok.End > int64(len(runes)) {
+				t.Fatalf("Tokenize(%q) token %+v after offset %d", input, tok, prevEnd)
+			}
+			if string(runes[tok.Start:tok.End]) != tok.Value {
+				t.Fatalf("Tokenize(%q) token %+v does not match its span", input, tok)
+			}
+			for _, ch := range runes[prevEnd:tok.Start] {
+				if !isWhitespace(ch) {
+					t.Fatalf("Tokenize(%q) skipped %q before token %+v", input, ch, tok)
+				}
+			}
+			prevEnd = tok.End
+		}
+		for _, ch := range runes[prevEnd:] {
+			if !isWhitespace(ch) {
+				t.Fatalf("Tokenize(%q) skipped %q at the end", input, ch)
+			}
+		}
 	})
 }
 
@@ -171,6 +247,9 @@ func FuzzEvalExpressions(f *testing.F) {
 		{`[[x]]`, `x`, `value||pipes`},
 		{`[[x]]`, `x`, `[[nested]]`},
 		{`[[x]]`, `x`, `"quotes"`},
+		// Helpers
+		{`[[default(x, "none")]]`, `x`, ``},
+		{`[[default(x, "none")]]`, `default`, `shadowed`},
 	}
 
 	for _, s := range seeds {
@@ -331,3 +410,169 @@ func FuzzCommandString(f *testing.F) {
 		}
 	})
 }
+
+// FuzzScriptString tests that Script.String output re-parses to an equivalent
+// script.
+func FuzzScriptString(f *testing.F) {
+	for _, seed := range parseScriptSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		script, err := NewParser(input).ParseScript()
+		if err != nil {
+			return
+		}
+
+		str := script.String()
+		script2, err := NewParser(str).ParseScript()
+		if err != nil {
+			t.Fatalf("round-trip reparse failed: input=%q → string=%q → error=%v", input, str, err)
+		}
+
+		// Raw is the source text, which String does not reproduce
+		opts := cmp.Options{cmp.AllowUnexported(AdvArgs{}), cmpopts.IgnoreFields(Command{}, "Raw", "Span")}
+		if diff := cmp.Diff(script.Cmds, script2.Cmds, opts); diff != "" {
+			t.Errorf("round-trip cmds mismatch (-want +got):\n%s\ninput=%q → string=%q", diff, input, str)
+		}
+		if diff := cmp.Diff(script.Traits, script2.Traits, cmpopts.EquateEmpty(), cmpopts.EquateNaNs()); diff != "" {
+			t.Errorf("round-trip traits mismatch (-want +got):\n%s\ninput=%q → string=%q", diff, input, str)
+		}
+
+		// output that keeps the source quoting must parse the same way
+		styled, err := NewParserWithOptions(input, WithKeepStyle()).ParseScript()
+		if err != nil {
+			t.Fatalf("KeepStyle parse failed where default parse succeeded: input=%q → error=%v", input, err)
+		}
+		styledStr := styled.String()
+		script3, err := NewParser(styledStr).ParseScript()
+		if err != nil {
+			t.Fatalf("KeepStyle round-trip reparse failed: input=%q → string=%q → error=%v", input, styledStr, err)
+		}
+		if diff := cmp.Diff(script.Cmds, script3.Cmds, opts); diff != "" {
+			t.Errorf("KeepStyle round-trip cmds mismatch (-want +got):\n%s\ninput=%q → string=%q",
+				diff, input, styledStr)
+		}
+	})
+}
+
+// FuzzFormat tests that Format is idempotent.
+func FuzzFormat(f *testing.F) {
+	for _, seed := range parseScriptSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		formatted, err := Format(input)
+		if err != nil {
+			return
+		}
+		again, err := Format(formatted)
+		if err != nil {
+			t.Fatalf("Format() of formatted output failed: input=%q → formatted=%q → error=%v",
+				input, formatted, err)
+		}
+		if again != formatted {
+			t.Errorf("Format() not idempotent: input=%q → %q → %q", input, formatted, again)
+		}
+	})
+}
+
+// FuzzMinify tests that Minify never grows a script and that its output
+// parses to an equivalent script.
+func FuzzMinify(f *testing.F) {
+	for _, seed := range parseScriptSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		want, err := Parse(input)
+		if err != nil {
+			return
+		}
+		minified, err := Minify(input)
+		if err != nil {
+			t.Fatalf("Minify() unexpected error: input=%q error=%v", input, err)
+		}
+		if len(minified) > len(input) {
+			t.Errorf("Minify() grew script: input=%q → %q", input, minified)
+		}
+		if !sameScript(want, minified) {
+			t.Errorf("Minify() changed script: input=%q → %q", input, minified)
+		}
+	})
+}
+
+// FuzzQuotePreference tests that a literal value written with each
+// QuotePreference parses back to the same value as an arg and an adv arg.
+func FuzzQuotePreference(f *testing.F) {
+	f.Add("a,b")
+	f.Add(`it's "q"||^[[x]]`)
+	f.Add("{k?a=b&c")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		runes := []rune(value)
+		if !utf8.ValidString(value) || len(runes) == 0 || isWhitespace(runes[0]) ||
+			isWhitespace(runes[len(runes)-1]) || strings.ContainsAny(value, TokExpStart+TokExprEnd) {
+			return
+		}
+		for _, ch := range runes {
+			if checkEncoding(ch, utf8.RuneLen(ch), 0) != nil {
+				return
+			}
+		}
+
+		cmd := Command{Name: "cmd", Args: []string{value, value}, AdvArgs: NewAdvArgs(map[string]string{"k": value})}
+		for _, pref := range []QuotePreference{QuotePreferQuotes, QuotePreferEscapes, QuotePreferAuto} {
+			str := cmd.StringWithOptions(WithQuotePreference(pref))
+			script, err := Parse(str)
+			if err != nil {
+				t.Fatalf("reparse failed: value=%q pref=%d → string=%q → error=%v", value, pref, str, err)
+			}
+			if len(script.Cmds) != 1 {
+				t.Fatalf("reparse produced %d commands: value=%q pref=%d → string=%q",
+					len(script.Cmds), value, pref, str)
+			}
+			got := script.Cmds[0]
+			if diff := cmp.Diff(cmd.Args, got.Args); diff != "" {
+				t.Errorf("args mismatch (-want +got):\n%s\nvalue=%q pref=%d → string=%q", diff, value, pref, str)
+			}
+			if got.AdvArgs.Get("k") != value {
+				t.Errorf("adv arg = %q, want %q: pref=%d → string=%q", got.AdvArgs.Get("k"), value, pref, str)
+			}
+		}
+	})
+}
+
+// FuzzDetokenizeExpressions tests that TokenizeExpressions undoes
+// DetokenizeExpressions for any value the parser can produce.
+func FuzzDetokenizeExpressions(f *testing.F) {
+	f.Add("a, b")
+	f.Add("a^" + TokExpStart + "x" + TokExprEnd + "[[y]]^")
+	f.Add("[" + TokExpStart + "x]y" + TokExprEnd + "^^[")
+	f.Add(TokExpStart + `replace(a, "]]", x[0])` + TokExprEnd)
+
+	f.Fuzz(func(t *testing.T, value string) {
+		parts, complete := splitArgParts(value)
+		if !complete || !utf8.ValidString(value) {
+			return
+		}
+		for _, part := range parts {
+			// the parser ends an expression at the first ]] outside string
+			// literals and brackets
+			source := []rune(part.Value + "]]")
+			if part.Type == ArgPartTypeExpression && exprEnd(source, 0) != len(source)-2 {
+				return
+			}
+		}
+
+		rendered := DetokenizeExpressions(value)
+		got, err := TokenizeExpressions(rendered)
+		if err != nil {
+			t.Fatalf("TokenizeExpressions(%q) error: %v (value=%q)", rendered, err, value)
+		}
+		if got != value {
+			t.Errorf("round trip mismatch: value=%q → rendered=%q → %q", value, rendered, got)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseHints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		wantCmds []zapscript.Command
+		want     []zapscript.Warning
+	}{
+		{
+			name:     "single pipe before command",
+			input:    `**launch:game.rom|**echo:done`,
+			wantCmds: []zapscript.Command{{Name: "launch", Args: []string{"game.rom|**echo:done"}}},
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningLonePipe,
+				Message:  "did you mean '||' to separate commands?",
+				Fragment: "|**echo:done",
+			}},
+		},
+		{
+			name:     "single pipe in auto-launch content",
+			input:    `/games/a.rom|**echo:done`,
+			wantCmds: []zapscript.Command{{Name: "launch", Args: []string{"/games/a.rom|**echo:done"}}},
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningLonePipe,
+				Message:  "did you mean '||' to separate commands?",
+				Fragment: "|**echo:done",
+			}},
+		},
+		{
+			name:     "single bracket variable",
+			input:    `**echo:[platform]`,
+			wantCmds: []zapscript.Command{{Name: "echo", Args: []string{"[platform]"}}},
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningSingleBracket,
+				Message:  "did you mean '[[platform]]' to use an expression?",
+				Fragment: "[platform]",
+			}},
+		},
+		{
+			name:  "single bracket nested field in adv arg",
+			input: `**launch:game.rom?when=[media_playing]`,
+			wantCmds: []zapscript.Command{{
+				Name:    "launch",
+				Args:    []string{"game.rom"},
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{"when": "[media_playing]"}),
+			}},
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningSingleBracket,
+				Message:  "did you mean '[[media_playing]]' to use an expression?",
+				Fragment: "[media_playing]",
+			}},
+		},
+		{
+			name:     "single bracket dotted field",
+			input:    `**echo:[active_media.name]`,
+			wantCmds: []zapscript.Command{{Name: "echo", Args: []string{"[active_media.name]"}}},
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningSingleBracket,
+				Message:  "did you mean '[[active_media.name]]' to use an expression?",
+				Fragment: "[active_media.name]",
+			}},
+		},
+		{
+			name:  "hint in a later command",
+			input: `**echo:ok||**echo:[platform]`,
+			wantCmds: []zapscript.Command{
+				{Name: "echo", Args: []string{"ok"}},
+				{Name: "echo", Args: []string{"[platform]"}},
+			},
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningSingleBracket,
+				Message:  "did you mean '[[platform]]' to use an expression?",
+				Fragment: "[platform]",
+				CmdIndex: 1,
+			}},
+		},
+		{
+			name:  "double pipe is a real separator",
+			input: `**launch:game.rom||**echo:done`,
+			wantCmds: []zapscript.Command{
+				{Name: "launch", Args: []string{"game.rom"}},
+				{Name: "echo", Args: []string{"done"}},
+			},
+		},
+		{
+			name:  "windows path",
+			input: `C:\Games\SNES\[USA]\Super Mario World [!].sfc`,
+			wantCmds: []zapscript.Command{
+				{Name: "launch", Args: []string{`C:\Games\SNES\[USA]\Super Mario World [!].sfc`}},
+			},
+		},
+		{
+			name:     "rom dump code brackets",
+			input:    `/games/nes/Zelda [b].nes`,
+			wantCmds: []zapscript.Command{{Name: "launch", Args: []string{"/games/nes/Zelda [b].nes"}}},
+		},
+		{
+			name:     "pipe without command prefix",
+			input:    `**echo:"a|b"`,
+			wantCmds: []zapscript.Command{{Name: "echo", Args: []string{"a|b"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantCmds, got.Cmds, diffOpts); diff != "" {
+				t.Errorf("hints must not alter commands (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got.Warnings); diff != "" {
+				t.Errorf("Warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseHintsIgnoresExpressions(t *testing.T) {
+	t.Parallel()
+
+	got, err := zapscript.NewParser(`**echo:[[platform]]`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	if len(got.Warnings) != 0 {
+		t.Errorf("expected no hints for a real expression, got %v", got.Warnings)
+	}
+}
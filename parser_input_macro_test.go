@@ -22,6 +22,7 @@ import (
 
 	zapscript "github.com/ZaparooProject/go-zapscript"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 // kbd builds the expected Script for a single **input.keyboard command.
@@ -34,8 +35,13 @@ func txt(args ...string) zapscript.Script {
 	return zapscript.Script{Cmds: []zapscript.Command{{Name: "input.text", Args: args}}}
 }
 
-// diffOpts is the cmp option used consistently with parser_coverage_test.go.
-var diffOpts = cmp.AllowUnexported(zapscript.AdvArgs{})
+// diffOpts compares parsed scripts by content. Where commands and traits
+// came from in the input is ignored, tests that care about it check it
+// directly. AdvArgs are compared with their Equal method.
+var diffOpts = cmp.Options{
+	cmpopts.IgnoreFields(zapscript.Command{}, "Raw", "Span"),
+	cmpopts.IgnoreFields(zapscript.Script{}, "TraitsSpans"),
+}
 
 // ─── Regression test for issue #939 ──────────────────────────────────────────
 
@@ -497,3 +503,86 @@ func TestInputTextGrammar(t *testing.T) {
 		})
 	}
 }
+
+// ─── Expressions in extended names ───────────────────────────────────────────
+
+func TestInputMacroExtExpressions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		want    zapscript.Script
+	}{
+		{
+			name:  "expression as key name",
+			input: "**input.keyboard:{[[active_media.system_id]]}",
+			want:  kbd("{" + tokExpr("active_media.system_id") + "}"),
+		},
+		{
+			name:  "expression with repeat",
+			input: "**input.keyboard:{[[k]]*2}",
+			want:  kbd("{"+tokExpr("k")+"}", "{"+tokExpr("k")+"}"),
+		},
+		{
+			name:  "closing brace inside expression",
+			input: `**input.keyboard:{[[x ? "}" : "a"]]}b`,
+			want:  kbd("{"+tokExpr(`x ? "}" : "a"`)+"}", "b"),
+		},
+		{
+			name:  "expression after prefix",
+			input: "**input.keyboard:{delay:[[ms]]}{shift+[[k]]}",
+			want:  kbd("{delay:"+tokExpr("ms")+"}", "{shift+"+tokExpr("k")+"}"),
+		},
+		{
+			name:  "quoted literal is typed as is",
+			input: `**input.keyboard:{"[[x]]"}`,
+			want:  kbd("[", "[", "x", "]", "]"),
+		},
+		{
+			name:    "unmatched expression",
+			input:   "**input.keyboard:{[[x}",
+			wantErr: zapscript.ErrUnmatchedExpression,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := zapscript.NewParser(tt.input).ParseScript()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseScript() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
+				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
+			}
+
+			reparsed, err := zapscript.NewParser(got.String()).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript(String()) unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(got, reparsed, diffOpts); diff != "" {
+				t.Errorf("String() round trip mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInputMacroExtExpressions_Eval(t *testing.T) {
+	t.Parallel()
+	script, err := zapscript.NewParser("**input.keyboard:{[[active_media.system_id]]}").ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	env := zapscript.ArgExprEnv{ActiveMedia: zapscript.ExprEnvActiveMedia{SystemID: "snes"}}
+	got, err := zapscript.NewParser(script.Cmds[0].Args[0]).EvalExpressions(env)
+	if err != nil {
+		t.Fatalf("EvalExpressions() unexpected error: %v", err)
+	}
+	if got != "{snes}" {
+		t.Errorf("EvalExpressions() = %q, want %q", got, "{snes}")
+	}
+}
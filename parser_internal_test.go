@@ -16,6 +16,7 @@
 package zapscript
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,17 +66,17 @@ func TestIsCmdName(t *testing.T) {
 			char:     '.',
 			expected: true,
 		},
-		// Invalid characters
 		{
 			name:     "underscore",
 			char:     '_',
-			expected: false,
+			expected: true,
 		},
 		{
 			name:     "dash",
 			char:     '-',
-			expected: false,
+			expected: true,
 		},
+		// Invalid characters
 		{
 			name:     "space",
 			char:     ' ',
@@ -309,3 +310,60 @@ func TestIsWhitespace(t *testing.T) {
 		})
 	}
 }
+
+func TestReaderPositionUnreadNewline(t *testing.T) {
+	t.Parallel()
+
+	sr := NewParser("ab\ncd")
+	for range 3 {
+		_, err := sr.read()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int64(3), sr.Pos())
+	assert.Equal(t, int64(2), sr.Line())
+	assert.Equal(t, int64(0), sr.Column())
+
+	assert.NoError(t, sr.unread())
+	assert.Equal(t, int64(2), sr.Pos())
+	assert.Equal(t, int64(1), sr.Line())
+	assert.Equal(t, int64(2), sr.Column())
+
+	ch, err := sr.read()
+	assert.NoError(t, err)
+	assert.Equal(t, '\n', ch)
+	assert.Equal(t, int64(2), sr.Line())
+
+	assert.NoError(t, sr.skip())
+	assert.Equal(t, int64(4), sr.Pos())
+	assert.Equal(t, int64(2), sr.Line())
+	assert.Equal(t, int64(1), sr.Column())
+
+	assert.NoError(t, sr.unread())
+	assert.Equal(t, int64(3), sr.Pos())
+	assert.Equal(t, int64(2), sr.Line())
+	assert.Equal(t, int64(0), sr.Column())
+}
+
+// TestExprEnvFieldVersionsComplete guards against adding an ArgExprEnv field
+// without recording the schema version that introduced it.
+func TestExprEnvFieldVersionsComplete(t *testing.T) {
+	t.Parallel()
+
+	for name := range exprTagNames(reflect.TypeFor[ArgExprEnv]()) {
+		version, ok := exprEnvFieldVersions[name]
+		assert.True(t, ok, "env field %q has no schema version", name)
+		assert.LessOrEqual(t, version, ExprEnvSchemaVersion, "env field %q", name)
+	}
+
+	envType := reflect.TypeFor[ArgExprEnv]()
+	for i := range envType.NumMethod() {
+		name := envType.Method(i).Name
+		version, ok := exprEnvMethodVersions[name]
+		assert.True(t, ok, "env method %q has no schema version", name)
+		assert.LessOrEqual(t, version, ExprEnvSchemaVersion, "env method %q", name)
+	}
+	for name := range exprEnvMethodVersions {
+		_, ok := envType.MethodByName(name)
+		assert.True(t, ok, "env method %q does not exist", name)
+	}
+}
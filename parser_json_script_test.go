@@ -0,0 +1,232 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseJSONScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		want    zapscript.Script
+	}{
+		{
+			name:  "commands with args and adv args",
+			input: `{"cmds":[{"name":"launch","args":["game.rom"],"advArgs":{"launcher":"x"}},{"name":"stop"}]}`,
+			want: zapscript.Script{Cmds: []zapscript.Command{
+				{
+					Name:    "launch",
+					Args:    []string{"game.rom"},
+					AdvArgs: zapscript.NewAdvArgs(map[string]string{"launcher": "x"}),
+				},
+				{Name: "stop"},
+			}},
+		},
+		{
+			name:  "no escaping needed",
+			input: `{"cmds":[{"name":"echo","args":["a, b || c ^ [d]"]}]}`,
+			want: zapscript.Script{Cmds: []zapscript.Command{
+				{Name: "echo", Args: []string{"a, b || c ^ [d]"}},
+			}},
+		},
+		{
+			name:  "expressions",
+			input: `{"cmds":[{"name":"echo","args":["[[d]]","^[[e]]"],"advArgs":{"when":"[[x]]"}}]}`,
+			want: zapscript.Script{Cmds: []zapscript.Command{{
+				Name: "echo",
+				Args: []string{zapscript.TokExpStart + "d" + zapscript.TokExprEnd, "[[e]]"},
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{
+					"when": zapscript.TokExpStart + "x" + zapscript.TokExprEnd,
+				}),
+			}}},
+		},
+		{
+			name:  "names normalized",
+			input: `{"cmds":[{"name":"Launch.Random","advArgs":{"System":"snes"}}]}`,
+			want: zapscript.Script{Cmds: []zapscript.Command{
+				{Name: "launch.random", AdvArgs: zapscript.NewAdvArgs(map[string]string{"system": "snes"})},
+			}},
+		},
+		{
+			name:  "traits only",
+			input: `{"traits":{"name":"mario","level":5}}`,
+			want:  zapscript.Script{Traits: map[string]any{"name": "mario", "level": float64(5)}},
+		},
+		{
+			name:  "unknown fields ignored",
+			input: `{"version":2,"cmds":[{"name":"stop","extra":true}],"hints":[{"code":"x"}]}`,
+			want:  zapscript.Script{Cmds: []zapscript.Command{{Name: "stop"}}},
+		},
+		{
+			name:  "surrounding whitespace",
+			input: "{\"cmds\":[{\"name\":\"stop\"}]}\n",
+			want:  zapscript.Script{Cmds: []zapscript.Command{{Name: "stop"}}},
+		},
+		{name: "invalid JSON", input: `{"cmds":[`, wantErr: zapscript.ErrInvalidJSON},
+		{name: "trailing text", input: `{"cmds":[]}**stop`, wantErr: zapscript.ErrInvalidJSON},
+		{name: "wrong type", input: `{"cmds":"stop"}`, wantErr: zapscript.ErrInvalidJSON},
+		{
+			name:  "number and bool adv args",
+			input: `{"cmds":[{"name":"a","advArgs":{"k":1,"flag":true}}]}`,
+			want: zapscript.Script{Cmds: []zapscript.Command{
+				{Name: "a", AdvArgs: zapscript.NewAdvArgs(map[string]string{"k": "1", "flag": "true"})},
+			}},
+		},
+		{
+			name:    "object adv arg",
+			input:   `{"cmds":[{"name":"a","advArgs":{"k":{"x":1}}}]}`,
+			wantErr: zapscript.ErrInvalidAdvArgValue,
+		},
+		{
+			name:    "unmatched expression",
+			input:   `{"cmds":[{"name":"a","args":["[[x"]}]}`,
+			wantErr: zapscript.ErrUnmatchedExpression,
+		},
+		{name: "empty object", input: `{}`, wantErr: zapscript.ErrEmptyZapScript},
+		{name: "empty name", input: `{"cmds":[{"args":["x"]}]}`, wantErr: zapscript.ErrEmptyCmdName},
+		{name: "invalid name", input: `{"cmds":[{"name":"la unch"}]}`, wantErr: zapscript.ErrInvalidCmdName},
+		{name: "leading hyphen name", input: `{"cmds":[{"name":"-launch"}]}`, wantErr: zapscript.ErrInvalidCmdName},
+		{
+			name:    "reserved name",
+			input:   `{"cmds":[{"name":"zap.internal.x"}]}`,
+			wantErr: zapscript.ErrReservedCommandName,
+		},
+		{
+			name:    "invalid adv arg name",
+			input:   `{"cmds":[{"name":"a","advArgs":{"k-1":"v"}}]}`,
+			wantErr: zapscript.ErrInvalidAdvArgName,
+		},
+		{
+			name:    "adv arg names collide after normalizing",
+			input:   `{"cmds":[{"name":"a","advArgs":{"k":"v","K":"w"}}]}`,
+			wantErr: zapscript.ErrDuplicateAdvArg,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := zapscript.NewParser(tt.input).ParseScript()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseScript() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
+				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseJSONScriptErrorIndex(t *testing.T) {
+	t.Parallel()
+
+	_, err := zapscript.NewParser(`{"cmds":[{"name":"stop"},{"name":"bad name"}]}`).ParseScript()
+	var pe *zapscript.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("ParseScript() error = %v, want a *ParseError", err)
+	}
+	if pe.CmdIndex != 1 || pe.CmdName != "bad name" {
+		t.Errorf("ParseError CmdIndex = %d, CmdName = %q, want 1, %q", pe.CmdIndex, pe.CmdName, "bad name")
+	}
+}
+
+func TestParseJSONScriptLimits(t *testing.T) {
+	t.Parallel()
+
+	input := `{"cmds":[{"name":"a"},{"name":"b"},{"name":"c"}]}`
+	_, err := zapscript.NewParserWithOptions(input, zapscript.WithMaxCommands(2)).ParseScript()
+	if !errors.Is(err, zapscript.ErrTooManyCommands) {
+		t.Errorf("ParseScript() error = %v, want %v", err, zapscript.ErrTooManyCommands)
+	}
+
+	input = `{"cmds":[{"name":"a","args":["1","2","3"]}]}`
+	_, err = zapscript.NewParserWithOptions(input, zapscript.WithMaxArgs(2)).ParseScript()
+	if !errors.Is(err, zapscript.ErrTooManyArgs) {
+		t.Errorf("ParseScript() error = %v, want %v", err, zapscript.ErrTooManyArgs)
+	}
+}
+
+// TestParseJSONScriptRoundTrip checks that marshaling a script parsed from
+// text and parsing the JSON gives back the same script.
+func TestParseJSONScriptRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"**launch:snes/mario.sfc?system=snes&launcher=retroarch",
+		"**greet:hi,there||**delay:500||**stop",
+		`**say:"hello, world",'it^'s'`,
+		`**launch:game?tags={"a":[1,2]}`,
+		`**launch:/roms/[[platform]]/game.bin?when=[[media_playing]]`,
+		"#name=mario #level=5 #tags=[a,b]||@snes/Super Mario World",
+		`**traits:{"data":{"x":1}}||**input.keyboard:ab{enter}`,
+		"/games/sonic.md?launcher=custom",
+		"**x?1a=v&_b=w",
+		"**input.text:hi there",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			want, err := zapscript.NewParser(input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript(text) unexpected error: %v", err)
+			}
+			data, err := json.Marshal(want)
+			if err != nil {
+				t.Fatalf("json.Marshal() unexpected error: %v", err)
+			}
+
+			got, err := zapscript.NewParser(string(data)).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript(%s) unexpected error: %v", data, err)
+			}
+
+			// JSON numbers decode as float64 and the JSON flag on adv args
+			// is text syntax metadata
+			opts := cmp.Options{
+				diffOpts,
+				cmpopts.IgnoreFields(zapscript.AdvArgs{}, "json"),
+				cmp.FilterValues(func(a, b any) bool {
+					_, aInt := a.(int64)
+					_, bFloat := b.(float64)
+					return aInt && bFloat
+				}, cmp.Comparer(func(a, b any) bool {
+					ai, _ := a.(int64)
+					bf, _ := b.(float64)
+					return float64(ai) == bf
+				})),
+				cmpopts.IgnoreFields(zapscript.Script{}, "Warnings"),
+			}
+			if diff := cmp.Diff(want, got, opts); diff != "" {
+				t.Errorf("JSON script mismatch (-text +json):\n%s\njson=%s", diff, data)
+			}
+		})
+	}
+}
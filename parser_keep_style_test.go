@@ -0,0 +1,120 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKeepStyleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// inputs that are already canonical re-serialize byte for byte
+	inputs := []string{
+		"**stop",
+		"**launch:/games/snes/mario.sfc",
+		`**say:'hello, world',"second, arg",plain`,
+		`**say:'it^'s',"say ^"hi^""`,
+		`**launch:"quoted"?launcher="retro arch"&slot=1&system='snes'`,
+		`**api:{"key":"value, more"}?data={"a":[1,2]}`,
+		`**cmd:arg^,with^,commas?q=a^&b`,
+		`**notify:Hello [[username]]!,"[[a]], [[b]]"`,
+		`**cmd:"",''`,
+		"**launch:a||**launch:'b'||**delay:500",
+		`**echo:"line^nbreak"`,
+		`**input.keyboard:ab{enter}`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.NewParserWithOptions(input, zapscript.WithKeepStyle()).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if got := script.String(); got != input {
+				t.Errorf("Script.String() = %q, want %q", got, input)
+			}
+		})
+	}
+}
+
+func TestKeepStyleRecordsStyles(t *testing.T) {
+	t.Parallel()
+
+	input := `**cmd:plain,'single',"double",{"j":1},"quoted"tail?a=x&b='y'&c="z"&d={"k":2}`
+	script, err := zapscript.NewParserWithOptions(input, zapscript.WithKeepStyle()).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	cmd := script.Cmds[0]
+
+	wantArgs := []zapscript.QuoteStyle{
+		zapscript.QuoteStyleNone,
+		zapscript.QuoteStyleSingle,
+		zapscript.QuoteStyleDouble,
+		zapscript.QuoteStyleJSON,
+		zapscript.QuoteStyleAuto,
+	}
+	if diff := cmp.Diff(wantArgs, cmd.ArgStyles); diff != "" {
+		t.Errorf("ArgStyles mismatch (-want +got):\n%s", diff)
+	}
+
+	wantAdv := map[zapscript.Key]zapscript.QuoteStyle{
+		"a": zapscript.QuoteStyleNone,
+		"b": zapscript.QuoteStyleSingle,
+		"c": zapscript.QuoteStyleDouble,
+		"d": zapscript.QuoteStyleJSON,
+	}
+	for key, want := range wantAdv {
+		if got := cmd.AdvArgs.Style(key); got != want {
+			t.Errorf("AdvArgs.Style(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestKeepStyleNewValuesUseAutoQuoting(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParserWithOptions(`**launch:'a'?system='snes'`, zapscript.WithKeepStyle()).
+		ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	cmd := script.Cmds[0]
+	cmd.Args = append(cmd.Args, "b,c")
+	cmd.AdvArgs = cmd.AdvArgs.With(zapscript.KeySystem, "nes").With(zapscript.KeyLauncher, "x&y")
+
+	want := `**launch:'a',"b,c"?system=nes&launcher="x&y"`
+	if got := cmd.String(); got != want {
+		t.Errorf("Command.String() = %q, want %q", got, want)
+	}
+}
+
+func TestKeepStyleDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser(`**say:'hi'?a='b'`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	if got, want := script.String(), "**say:hi?a=b"; got != want {
+		t.Errorf("Script.String() = %q, want %q", got, want)
+	}
+}
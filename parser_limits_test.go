@@ -0,0 +1,304 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func repeatJoin(s string, n int, sep string) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = s
+	}
+	return strings.Join(parts, sep)
+}
+
+func TestParseLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		opts    []zapscript.Option
+	}{
+		{
+			name:  "input runes at limit",
+			input: "**echo:" + strings.Repeat("é", 13),
+			opts:  []zapscript.Option{zapscript.WithMaxInputRunes(20)},
+		},
+		{
+			name:    "input runes over limit",
+			input:   "**echo:" + strings.Repeat("é", 14),
+			opts:    []zapscript.Option{zapscript.WithMaxInputRunes(20)},
+			wantErr: zapscript.ErrScriptTooLarge,
+		},
+		{
+			name:    "input runes over limit inside quoted arg",
+			input:   `**echo:"` + strings.Repeat("a", 30) + `"`,
+			opts:    []zapscript.Option{zapscript.WithMaxInputRunes(20)},
+			wantErr: zapscript.ErrScriptTooLarge,
+		},
+		{
+			name:  "input rune limit disabled",
+			input: "**echo:" + strings.Repeat("a", zapscript.DefaultMaxInputRunes),
+			opts:  []zapscript.Option{zapscript.WithMaxInputRunes(0)},
+		},
+		{
+			name:  "default input rune limit at boundary",
+			input: "**echo:" + strings.Repeat("a", zapscript.DefaultMaxInputRunes-len("**echo:")),
+		},
+		{
+			name:    "default input rune limit exceeded",
+			input:   "**echo:" + strings.Repeat("a", zapscript.DefaultMaxInputRunes),
+			wantErr: zapscript.ErrScriptTooLarge,
+		},
+		{
+			name:  "args at limit",
+			input: "**cmd:" + repeatJoin("a", 3, ","),
+			opts:  []zapscript.Option{zapscript.WithMaxArgs(3)},
+		},
+		{
+			name:    "args over limit",
+			input:   "**cmd:" + repeatJoin("a", 4, ","),
+			opts:    []zapscript.Option{zapscript.WithMaxArgs(3)},
+			wantErr: zapscript.ErrTooManyArgs,
+		},
+		{
+			name:    "args over limit with explicit empty arg",
+			input:   `**cmd:a,b,c,""`,
+			opts:    []zapscript.Option{zapscript.WithMaxArgs(3)},
+			wantErr: zapscript.ErrTooManyArgs,
+		},
+		{
+			name:  "default args limit at boundary",
+			input: "**cmd:" + repeatJoin("a", zapscript.DefaultMaxArgs, ","),
+		},
+		{
+			name:    "default args limit exceeded",
+			input:   "**cmd:" + repeatJoin("a", zapscript.DefaultMaxArgs+1, ","),
+			wantErr: zapscript.ErrTooManyArgs,
+		},
+		{
+			name:  "input macro keys are not counted as args",
+			input: "**input.keyboard:" + strings.Repeat("a", zapscript.DefaultMaxArgs+1),
+		},
+		{
+			name:  "commands at limit",
+			input: repeatJoin("**echo:x", 3, "||"),
+			opts:  []zapscript.Option{zapscript.WithMaxCommands(3)},
+		},
+		{
+			name:    "commands over limit",
+			input:   repeatJoin("**echo:x", 4, "||"),
+			opts:    []zapscript.Option{zapscript.WithMaxCommands(3)},
+			wantErr: zapscript.ErrTooManyCommands,
+		},
+		{
+			name:    "auto-launch commands count toward limit",
+			input:   "a.rom||b.rom||@snes/Mario||d.rom",
+			opts:    []zapscript.Option{zapscript.WithMaxCommands(3)},
+			wantErr: zapscript.ErrTooManyCommands,
+		},
+		{
+			name:  "traits do not count toward command limit",
+			input: "#a||**echo:x||#b||**echo:y",
+			opts:  []zapscript.Option{zapscript.WithMaxCommands(2)},
+		},
+		{
+			name:  "commands not limited by default",
+			input: repeatJoin("**stop", 1000, "||"),
+		},
+		{
+			name:    "traits interleaved over limit",
+			input:   "#a||**echo:x||#b||**echo:y||#c||**echo:z",
+			opts:    []zapscript.Option{zapscript.WithMaxCommands(2)},
+			wantErr: zapscript.ErrTooManyCommands,
+		},
+		{
+			name:  "traits commands do not count toward command limit",
+			input: `**traits:{"a":1}||**echo:x||**traits:{"b":2}||**echo:y`,
+			opts:  []zapscript.Option{zapscript.WithMaxCommands(2)},
+		},
+		{
+			name:  "adv arg value at limit",
+			input: "**cmd?data=" + strings.Repeat("a", 10),
+			opts:  []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+		},
+		{
+			name:    "adv arg value over limit",
+			input:   "**cmd?data=" + strings.Repeat("a", 11),
+			opts:    []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+			wantErr: zapscript.ErrAdvArgTooLarge,
+		},
+		{
+			name:  "quoted adv arg value at limit",
+			input: `**cmd?data="` + strings.Repeat("&", 10) + `"`,
+			opts:  []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+		},
+		{
+			name:    "quoted adv arg value over limit",
+			input:   `**cmd?data="` + strings.Repeat("&", 11) + `"`,
+			opts:    []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+			wantErr: zapscript.ErrAdvArgTooLarge,
+		},
+		{
+			name:  "escaped adv arg value at limit",
+			input: "**cmd?data=" + strings.Repeat("^&", 10),
+			opts:  []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+		},
+		{
+			name:    "escaped adv arg value over limit",
+			input:   "**cmd?data=" + strings.Repeat("^&", 11),
+			opts:    []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+			wantErr: zapscript.ErrAdvArgTooLarge,
+		},
+		{
+			name:  "JSON adv arg value at limit",
+			input: `**cmd?data={"a":"` + strings.Repeat("x", 10) + `"}`,
+			opts:  []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(18)},
+		},
+		{
+			name:    "JSON adv arg value over limit",
+			input:   `**cmd?data={"a":"` + strings.Repeat("x", 11) + `"}`,
+			opts:    []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(18)},
+			wantErr: zapscript.ErrAdvArgTooLarge,
+		},
+		{
+			name:  "percent-decoded adv arg value at limit",
+			input: "**cmd?data=" + strings.Repeat("%41", 10),
+			opts: []zapscript.Option{
+				zapscript.WithMaxAdvArgValueBytes(10), zapscript.WithDecodePercentEscapes(),
+			},
+		},
+		{
+			name:  "percent-decoded adv arg value over limit",
+			input: "**cmd?data=" + strings.Repeat("%41", 11),
+			opts: []zapscript.Option{
+				zapscript.WithMaxAdvArgValueBytes(10), zapscript.WithDecodePercentEscapes(),
+			},
+			wantErr: zapscript.ErrAdvArgTooLarge,
+		},
+		{
+			name:    "earlier repeat of adv arg over limit",
+			input:   "**cmd?data=" + strings.Repeat("a", 11) + "&data=b",
+			opts:    []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+			wantErr: zapscript.ErrAdvArgTooLarge,
+		},
+		{
+			name:  "positional args are not limited",
+			input: "**cmd:" + strings.Repeat("a", 11),
+			opts:  []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+		},
+		{
+			name:  "JSON script adv arg value at limit",
+			input: `{"cmds":[{"name":"cmd","advArgs":{"data":"` + strings.Repeat("a", 10) + `"}}]}`,
+			opts:  []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+		},
+		{
+			name:    "JSON script adv arg value over limit",
+			input:   `{"cmds":[{"name":"cmd","advArgs":{"data":"` + strings.Repeat("a", 11) + `"}}]}`,
+			opts:    []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(10)},
+			wantErr: zapscript.ErrAdvArgTooLarge,
+		},
+		{
+			name:  "adv arg value limit disabled",
+			input: "**cmd?data=" + strings.Repeat("a", zapscript.DefaultMaxAdvArgValueBytes+1),
+			opts:  []zapscript.Option{zapscript.WithMaxAdvArgValueBytes(0)},
+		},
+		{
+			name:  "default adv arg value limit at boundary",
+			input: "**cmd?data=" + strings.Repeat("a", zapscript.DefaultMaxAdvArgValueBytes),
+		},
+		{
+			name:    "default adv arg value limit exceeded",
+			input:   "**cmd?data=" + strings.Repeat("a", zapscript.DefaultMaxAdvArgValueBytes+1),
+			wantErr: zapscript.ErrAdvArgTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := zapscript.NewParserWithOptions(tt.input, tt.opts...).ParseScript()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ParseScript() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseScript() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAdvArgTooLargeError(t *testing.T) {
+	t.Parallel()
+
+	_, err := zapscript.NewParserWithOptions(
+		"**cmd?system=snes&data="+strings.Repeat("é", 6),
+		zapscript.WithMaxAdvArgValueBytes(10),
+	).ParseScript()
+	if !errors.Is(err, zapscript.ErrAdvArgTooLarge) {
+		t.Fatalf("ParseScript() error = %v, want %v", err, zapscript.ErrAdvArgTooLarge)
+	}
+	if want := `adv arg "data" is 12 bytes, limit is 10`; !strings.Contains(err.Error(), want) {
+		t.Errorf("ParseScript() error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestTooManyCommandsError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "text",
+			input: "#a||a.rom||**echo:x||#b||@snes/Mario||**stop",
+			want:  "parse error at 44: command 4 (stop): script exceeds maximum number of commands: limit is 3",
+		},
+		{
+			name:  "JSON",
+			input: `{"cmds":[{"name":"a"},{"name":"b"},{"name":"c"},{"name":"d"},{"name":"e"}]}`,
+			want:  "parse error at 75: command 4 (d): script exceeds maximum number of commands: limit is 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := zapscript.NewParserWithOptions(tt.input, zapscript.WithMaxCommands(3)).ParseScript()
+			if !errors.Is(err, zapscript.ErrTooManyCommands) {
+				t.Fatalf("ParseScript() error = %v, want %v", err, zapscript.ErrTooManyCommands)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("ParseScript() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
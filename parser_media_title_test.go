@@ -417,6 +417,62 @@ func TestParseMediaTitleSyntax(t *testing.T) {
 				},
 			},
 		},
+
+		// Expressions
+		{
+			name:  "expression in system",
+			input: `@[[active_media.system_id]]/Some Game`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "launch.title", Args: []string{tokExpr("active_media.system_id") + "/Some Game"}},
+				},
+			},
+		},
+		{
+			name:  "expression in title",
+			input: `@snes/[[scanned.data]] (USA)?launcher=[[platform]]`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{
+						Name:    "launch.title",
+						Args:    []string{"snes/" + tokExpr("scanned.data") + " (USA)"},
+						AdvArgs: zapscript.NewAdvArgs(map[string]string{"launcher": tokExpr("platform")}),
+					},
+				},
+			},
+		},
+		{
+			name:  "slash inside expression is not the separator",
+			input: `@[[a / b]]/Game`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "launch.title", Args: []string{tokExpr("a / b") + "/Game"}},
+				},
+			},
+		},
+		{
+			name:  "expression containing only slash falls back to launch",
+			input: `@[[a / b]]`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "launch", Args: []string{"@" + tokExpr("a / b")}},
+				},
+			},
+		},
+		{
+			name:  "escaped expression stays literal",
+			input: `@snes/^[[x]] Game`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "launch.title", Args: []string{"snes/[[x]] Game"}},
+				},
+			},
+		},
+		{
+			name:    "unmatched expression",
+			input:   `@snes/[[x`,
+			wantErr: zapscript.ErrUnmatchedExpression,
+		},
 	}
 
 	for _, tt := range tests {
@@ -428,7 +484,7 @@ func TestParseMediaTitleSyntax(t *testing.T) {
 				t.Errorf("ParseScript() error = %v, wantErr = %v", err, tt.wantErr)
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
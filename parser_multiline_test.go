@@ -0,0 +1,166 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseMultiLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantTraits map[string]any
+		name       string
+		input      string
+		want       []zapscript.Command
+	}{
+		{
+			name:  "three lines",
+			input: "**launch:a\n**delay:500\n**launch:b",
+			want: []zapscript.Command{
+				{Name: "launch", Args: []string{"a"}},
+				{Name: "delay", Args: []string{"500"}},
+				{Name: "launch", Args: []string{"b"}},
+			},
+		},
+		{
+			name:  "blank lines and trailing newline",
+			input: "\n**launch:a\n\n  \n**stop\n",
+			want:  []zapscript.Command{{Name: "launch", Args: []string{"a"}}, {Name: "stop"}},
+		},
+		{
+			name:  "CRLF line endings",
+			input: "**launch:a?system=snes\r\n\r\n**stop\r\n",
+			want: []zapscript.Command{
+				{
+					Name:    "launch",
+					Args:    []string{"a"},
+					AdvArgs: zapscript.NewAdvArgs(map[string]string{"system": "snes"}),
+				},
+				{Name: "stop"},
+			},
+		},
+		{
+			name:  "continuation",
+			input: "**launch.random:snes,^\nnes?tags=region:usa&^\r\nlauncher=x\n**stop",
+			want: []zapscript.Command{
+				{
+					Name: "launch.random",
+					Args: []string{"snes", "nes"},
+					AdvArgs: zapscript.NewAdvArgs(map[string]string{
+						"tags": "region:usa", "launcher": "x",
+					}),
+				},
+				{Name: "stop"},
+			},
+		},
+		{
+			name:  "continuation inside quotes",
+			input: "**echo:\"one ^\ntwo\"",
+			want:  []zapscript.Command{{Name: "echo", Args: []string{"one two"}}},
+		},
+		{
+			name:  "escaped caret at end of line",
+			input: "**echo:a^^\n**stop",
+			want:  []zapscript.Command{{Name: "echo", Args: []string{"a^"}}, {Name: "stop"}},
+		},
+		{
+			name:  "mixed separators",
+			input: "**echo:a||**echo:b\n**stop",
+			want: []zapscript.Command{
+				{Name: "echo", Args: []string{"a"}},
+				{Name: "echo", Args: []string{"b"}},
+				{Name: "stop"},
+			},
+		},
+		{
+			name:  "line break kept in quoted value",
+			input: "**echo:\"a\nb\"\n**stop",
+			want:  []zapscript.Command{{Name: "echo", Args: []string{"a\nb"}}, {Name: "stop"}},
+		},
+		{
+			name:  "auto launch and media title lines",
+			input: "game.rom\n@snes/Mario\n**stop",
+			want: []zapscript.Command{
+				{Name: "launch", Args: []string{"game.rom"}},
+				{Name: "launch.title", Args: []string{"snes/Mario"}},
+				{Name: "stop"},
+			},
+		},
+		{
+			name:       "traits and comments",
+			input:      "#difficulty=hard\n**# launch the game\n**launch:game",
+			want:       []zapscript.Command{{Name: "launch", Args: []string{"game"}}},
+			wantTraits: map[string]any{"difficulty": "hard"},
+		},
+		{
+			name:  "lone carriage return is not a line break",
+			input: "**echo:a\rb",
+			want:  []zapscript.Command{{Name: "echo", Args: []string{"a\rb"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.Parse(tt.input, zapscript.WithMultiLine())
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got.Cmds, diffOpts); diff != "" {
+				t.Errorf("Parse() cmds mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantTraits, got.Traits); diff != "" {
+				t.Errorf("Parse() traits mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseMultiLineSpans(t *testing.T) {
+	t.Parallel()
+
+	input := "**launch:a,^\nb\r\n**stop"
+	got, err := zapscript.Parse(input, zapscript.WithMultiLine())
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	var raws []string
+	for _, cmd := range got.Cmds {
+		raws = append(raws, cmd.Raw)
+	}
+	if diff := cmp.Diff([]string{"**launch:a,^\nb", "**stop"}, raws); diff != "" {
+		t.Errorf("Raw mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseSingleLineKeepsNewlines(t *testing.T) {
+	t.Parallel()
+
+	got, err := zapscript.Parse("**echo:a\n**stop")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	want := []zapscript.Command{{Name: "echo", Args: []string{"a\n**stop"}}}
+	if diff := cmp.Diff(want, got.Cmds, diffOpts); diff != "" {
+		t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -209,7 +209,7 @@ func TestParseEscapeSequences(t *testing.T) {
 				t.Errorf("ParseScript() error = %v, wantErr = %v", err, tt.wantErr)
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -300,7 +300,7 @@ func TestParseQuotedArgEdgeCases(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -352,11 +352,52 @@ func TestParseExpressionEdgeCases(t *testing.T) {
 				zapscript.TokExpStart + "b" + zapscript.TokExprEnd +
 				zapscript.TokExpStart + "c" + zapscript.TokExprEnd,
 		},
-		// Expression with bracket inside (first ]] ends it)
+		// Expression with a balanced index inside
 		{
-			name:  "bracket inside expression ends at first close",
+			name:  "index inside expression",
 			input: "[[a[0]]]",
-			want:  zapscript.TokExpStart + "a[0" + zapscript.TokExprEnd + "]",
+			want:  zapscript.TokExpStart + "a[0]" + zapscript.TokExprEnd,
+		},
+		{
+			name:  "nested index inside expression",
+			input: "[[a[b[0]]]]x",
+			want:  zapscript.TokExpStart + "a[b[0]]" + zapscript.TokExprEnd + "x",
+		},
+		// Closing brackets inside string literals
+		{
+			name:  "close inside double quotes",
+			input: `[[ replace(name, "]]", "") ]]`,
+			want:  zapscript.TokExpStart + ` replace(name, "]]", "") ` + zapscript.TokExprEnd,
+		},
+		{
+			name:  "close inside single quotes",
+			input: `[['a]]b']]`,
+			want:  zapscript.TokExpStart + `'a]]b'` + zapscript.TokExprEnd,
+		},
+		{
+			name:  "close inside backticks",
+			input: "[[`a]]b`]]",
+			want:  zapscript.TokExpStart + "`a]]b`" + zapscript.TokExprEnd,
+		},
+		{
+			name:  "escaped quote inside string",
+			input: `[["a\"]]"]]`,
+			want:  zapscript.TokExpStart + `"a\"]]"` + zapscript.TokExprEnd,
+		},
+		{
+			name:  "index inside string",
+			input: `[["a[0" + x[0]]]`,
+			want:  zapscript.TokExpStart + `"a[0" + x[0]` + zapscript.TokExprEnd,
+		},
+		{
+			name:  "stray close ends expression",
+			input: "[[a]]]",
+			want:  zapscript.TokExpStart + "a" + zapscript.TokExprEnd + "]",
+		},
+		{
+			name:    "unclosed string",
+			input:   `[["a]]`,
+			wantErr: zapscript.ErrUnmatchedExpression,
 		},
 		// Unmatched opening
 		{
@@ -530,7 +571,7 @@ func TestParseJSONArgMutations(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -615,7 +656,7 @@ func TestParseInputMacroArgMutations(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -724,7 +765,7 @@ func TestParseAdvArgsMutations(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -861,7 +902,7 @@ func TestParseArgsMutations(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -953,7 +994,7 @@ func TestParseCommandMutations(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -1019,10 +1060,10 @@ func TestParseScriptMutations(t *testing.T) {
 				},
 			},
 		},
-		// Starting with { reserved for JSON (error)
+		// Starting with { is a JSON script
 		{
 			name:    "starting brace error",
-			input:   `{"key":"value"}`,
+			input:   `{"key":`,
 			wantErr: zapscript.ErrInvalidJSON,
 		},
 		// Traits command merges into script.Traits
@@ -1047,7 +1088,7 @@ func TestParseScriptMutations(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -1359,7 +1400,7 @@ func TestMediaTitleMutations(t *testing.T) {
 			if tt.wantErr != nil {
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
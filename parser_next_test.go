@@ -0,0 +1,200 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+// collectCommands calls NextCommand until it returns an error, which is
+// returned with the commands read before it.
+func collectCommands(sr *zapscript.ScriptReader) ([]zapscript.Command, error) {
+	var cmds []zapscript.Command
+	for {
+		cmd, err := sr.NextCommand()
+		if err != nil {
+			return cmds, err
+		}
+		cmds = append(cmds, cmd)
+	}
+}
+
+func TestNextCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr    error
+		wantTraits map[string]any
+		name       string
+		input      string
+		want       []zapscript.Command
+	}{
+		{
+			name:    "command chain",
+			input:   "**echo:a||/games/mario.sfc||@snes/Mario",
+			wantErr: io.EOF,
+			want: []zapscript.Command{
+				{Name: "echo", Args: []string{"a"}, Raw: "**echo:a", Span: zapscript.Span{Start: 0, End: 8}},
+				{
+					Name: zapscript.ZapScriptCmdLaunch,
+					Args: []string{"/games/mario.sfc"},
+					Raw:  "/games/mario.sfc",
+					Span: zapscript.Span{Start: 10, End: 26},
+				},
+				{
+					Name: zapscript.ZapScriptCmdLaunchTitle,
+					Args: []string{"snes/Mario"},
+					Raw:  "@snes/Mario",
+					Span: zapscript.Span{Start: 28, End: 39},
+				},
+			},
+		},
+		{
+			name:       "traits between commands",
+			input:      "#a=1||**echo:a||#b||**stop",
+			wantErr:    io.EOF,
+			wantTraits: map[string]any{"a": int64(1), "b": true},
+			want: []zapscript.Command{
+				{Name: "echo", Args: []string{"a"}, Raw: "**echo:a", Span: zapscript.Span{Start: 6, End: 14}},
+				{Name: zapscript.ZapScriptCmdStop, Raw: "**stop", Span: zapscript.Span{Start: 20, End: 26}},
+			},
+		},
+		{
+			name:       "traits only",
+			input:      "#a=1 #b=x",
+			wantErr:    io.EOF,
+			wantTraits: map[string]any{"a": int64(1), "b": "x"},
+		},
+		{
+			name:    "error mid-stream",
+			input:   `**echo:a||**echo:"b||**stop`,
+			wantErr: zapscript.ErrUnmatchedQuote,
+			want: []zapscript.Command{
+				{Name: "echo", Args: []string{"a"}, Raw: "**echo:a", Span: zapscript.Span{Start: 0, End: 8}},
+			},
+		},
+		{
+			name:       "JSON script",
+			input:      `{"cmds":[{"name":"echo","args":["a"]},{"name":"stop"}],"traits":{"a":1}}`,
+			wantErr:    io.EOF,
+			wantTraits: map[string]any{"a": float64(1)},
+			want: []zapscript.Command{
+				{Name: "echo", Args: []string{"a"}},
+				{Name: zapscript.ZapScriptCmdStop},
+			},
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: zapscript.ErrEmptyZapScript,
+		},
+		{
+			name:    "whitespace only",
+			input:   "   ",
+			wantErr: zapscript.ErrWhitespaceOnlyZapScript,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sr := zapscript.NewParser(tt.input)
+			got, err := collectCommands(sr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NextCommand() error = %v, want %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("commands mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantTraits, sr.Traits()); diff != "" {
+				t.Errorf("traits mismatch (-want +got):\n%s", diff)
+			}
+
+			// the end and errors are sticky
+			if _, again := sr.NextCommand(); !errors.Is(again, tt.wantErr) {
+				t.Errorf("NextCommand() again error = %v, want %v", again, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNextCommandFromReader(t *testing.T) {
+	t.Parallel()
+
+	sr := zapscript.NewParserFromReader(strings.NewReader("**echo:a||#k=v||**echo:b||**stop"))
+
+	cmd, err := sr.NextCommand()
+	if err != nil {
+		t.Fatalf("NextCommand() unexpected error: %v", err)
+	}
+	if cmd.Name != "echo" || cmd.Raw != "**echo:a" {
+		t.Errorf("NextCommand() = %q (%s), want **echo:a", cmd.Raw, cmd.Name)
+	}
+	if traits := sr.Traits(); traits != nil {
+		t.Errorf("Traits() before traits segment = %v, want nil", traits)
+	}
+
+	rest, err := collectCommands(sr)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("NextCommand() error = %v, want io.EOF", err)
+	}
+	if len(rest) != 2 || rest[0].Raw != "**echo:b" || rest[1].Name != zapscript.ZapScriptCmdStop {
+		t.Errorf("remaining commands = %+v, want **echo:b and **stop", rest)
+	}
+	if diff := cmp.Diff(map[string]any{"k": "v"}, sr.Traits()); diff != "" {
+		t.Errorf("traits mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseScriptMatchesNextCommand(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"**echo:a||#k=v||/games/mario.sfc?launcher=x||@snes/Mario",
+		`{"cmds":[{"name":"echo","args":["a"]}],"traits":{"a":1}}`,
+		"#k=v",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+
+			sr := zapscript.NewParser(input)
+			cmds, err := collectCommands(sr)
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("NextCommand() error = %v, want io.EOF", err)
+			}
+			if diff := cmp.Diff(script.Cmds, cmds); diff != "" {
+				t.Errorf("commands mismatch (-ParseScript +NextCommand):\n%s", diff)
+			}
+			if diff := cmp.Diff(script.Traits, sr.Traits()); diff != "" {
+				t.Errorf("traits mismatch (-ParseScript +NextCommand):\n%s", diff)
+			}
+		})
+	}
+}
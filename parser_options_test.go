@@ -0,0 +1,264 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewParserMatchesNoOptions(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"**launch.random:snes,nes?launcher=retroarch",
+		"/games/snes/mario.sfc||**stop",
+		"@SNES/Super Mario World",
+		"#source=nfc **echo:hi",
+		"**echo:one|**echo:two",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+
+			want, wantErr := zapscript.NewParser(input).ParseScript()
+			got, gotErr := zapscript.NewParserWithOptions(input).ParseScript()
+			if (gotErr == nil) != (wantErr == nil) {
+				t.Fatalf("error mismatch: NewParser %v, NewParserWithOptions %v", wantErr, gotErr)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("script mismatch (-NewParser +NewParserWithOptions):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseWithoutAutoLaunch(t *testing.T) {
+	t.Parallel()
+
+	launch := func(arg string) zapscript.Script {
+		return zapscript.Script{
+			Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{arg}}},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		// want is the default result, and the result without auto-launch
+		// when wantText is empty
+		want zapscript.Script
+		// wantText is the text quoted in the ErrAutoLaunchDisabled error
+		wantText string
+	}{
+		{
+			name:  "explicit command",
+			input: "**launch:/games/snes/mario.sfc",
+			want:  launch("/games/snes/mario.sfc"),
+		},
+		{
+			name:  "media title",
+			input: "@SNES/Super Mario World",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{
+					Name: zapscript.ZapScriptCmdLaunchTitle,
+					Args: []string{"SNES/Super Mario World"},
+				}},
+			},
+		},
+		{
+			name:     "plain text",
+			input:    "hello world",
+			want:     launch("hello world"),
+			wantText: "hello world",
+		},
+		{
+			name:     "plain text after command",
+			input:    "**stop||/games/snes/mario.sfc",
+			wantText: "/games/snes/mario.sfc",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: zapscript.ZapScriptCmdStop},
+					{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"/games/snes/mario.sfc"}},
+				},
+			},
+		},
+		{
+			name:     "single star",
+			input:    "*mario.sfc",
+			want:     launch("*mario.sfc"),
+			wantText: "*mario.sfc",
+		},
+		{
+			name:     "invalid command name",
+			input:    "**bad name",
+			want:     launch("**bad name"),
+			wantText: "**bad name",
+		},
+		{
+			name:     "media title without slash",
+			input:    "@Super Mario World",
+			want:     launch("@Super Mario World"),
+			wantText: "@Super Mario World",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
+				t.Errorf("default script mismatch (-want +got):\n%s", diff)
+			}
+
+			got, err = zapscript.NewParserWithOptions(tt.input, zapscript.WithoutAutoLaunch()).ParseScript()
+			if tt.wantText == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
+					t.Errorf("script mismatch (-want +got):\n%s", diff)
+				}
+				return
+			}
+			if !errors.Is(err, zapscript.ErrAutoLaunchDisabled) {
+				t.Fatalf("expected ErrAutoLaunchDisabled, got %v", err)
+			}
+			if want := strconv.Quote(tt.wantText); !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not quote %s", err, want)
+			}
+		})
+	}
+}
+
+func TestParseWithoutMediaTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  zapscript.Script
+		// wantVerbatim is the result with WithoutMediaTitle
+		wantVerbatim zapscript.Script
+	}{
+		{
+			name:  "system and title",
+			input: "@snes/Mario",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunchTitle, Args: []string{"snes/Mario"}}},
+			},
+			wantVerbatim: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"@snes/Mario"}}},
+			},
+		},
+		{
+			name:  "adv args",
+			input: "@snes/Mario?launcher=x",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{
+					Name:    zapscript.ZapScriptCmdLaunchTitle,
+					Args:    []string{"snes/Mario"},
+					AdvArgs: zapscript.NewAdvArgs(map[string]string{"launcher": "x"}),
+				}},
+			},
+			wantVerbatim: zapscript.Script{
+				Cmds: []zapscript.Command{{
+					Name: zapscript.ZapScriptCmdLaunch,
+					Args: []string{"@snes/Mario?launcher=x"},
+				}},
+			},
+		},
+		{
+			name:  "no slash",
+			input: "@Mario Kart",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"@Mario Kart"}}},
+			},
+			wantVerbatim: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"@Mario Kart"}}},
+			},
+		},
+		{
+			name:  "followed by command",
+			input: "@share/games/mario.sfc||**stop",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: zapscript.ZapScriptCmdLaunchTitle, Args: []string{"share/games/mario.sfc"}},
+					{Name: zapscript.ZapScriptCmdStop},
+				},
+			},
+			wantVerbatim: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"@share/games/mario.sfc"}},
+					{Name: zapscript.ZapScriptCmdStop},
+				},
+			},
+		},
+		{
+			name:  "at sign after start",
+			input: "/mnt/@share/m.sfc",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"/mnt/@share/m.sfc"}}},
+			},
+			wantVerbatim: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"/mnt/@share/m.sfc"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
+				t.Errorf("default script mismatch (-want +got):\n%s", diff)
+			}
+
+			got, err = zapscript.NewParserWithOptions(tt.input, zapscript.WithoutMediaTitle()).ParseScript()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantVerbatim, got, diffOpts); diff != "" {
+				t.Errorf("script mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseWithoutMediaTitleAndAutoLaunch(t *testing.T) {
+	t.Parallel()
+
+	_, err := zapscript.NewParserWithOptions(
+		"@snes/Mario", zapscript.WithoutMediaTitle(), zapscript.WithoutAutoLaunch(),
+	).ParseScript()
+	if !errors.Is(err, zapscript.ErrAutoLaunchDisabled) {
+		t.Fatalf("expected ErrAutoLaunchDisabled, got %v", err)
+	}
+}
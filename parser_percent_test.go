@@ -0,0 +1,130 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodePercentEscapes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr  error
+		wantAdv  map[string]string
+		name     string
+		input    string
+		wantArgs []string
+	}{
+		{
+			name:     "space",
+			input:    "**launch:a%20b?name=Super%20Mario",
+			wantArgs: []string{"a%20b"},
+			wantAdv:  map[string]string{"name": "Super Mario"},
+		},
+		{
+			name:     "ampersand",
+			input:    "**launch:a?name=Tom%26Jerry&system=snes",
+			wantArgs: []string{"a"},
+			wantAdv:  map[string]string{"name": "Tom&Jerry", "system": "snes"},
+		},
+		{
+			name:     "plus is kept",
+			input:    "**launch.random:snes?tags=+region:usa,~lang:en%2Cfr",
+			wantArgs: []string{"snes"},
+			wantAdv:  map[string]string{"tags": "+region:usa,~lang:en,fr"},
+		},
+		{
+			name:     "quoted value",
+			input:    `**launch:a?name="100%25 & more"`,
+			wantArgs: []string{"a"},
+			wantAdv:  map[string]string{"name": "100% & more"},
+		},
+		{
+			name:     "quoted positional arg is not decoded",
+			input:    `**launch:"a%20b"?name=x%2Fy`,
+			wantArgs: []string{"a%20b"},
+			wantAdv:  map[string]string{"name": "x/y"},
+		},
+		{
+			name:     "expressions are not decoded",
+			input:    "**launch:a?name=n%3D[[n%20]]",
+			wantArgs: []string{"a"},
+			wantAdv:  map[string]string{"name": "n=" + tokExpr("n%20")},
+		},
+		{
+			name:     "JSON values are not decoded",
+			input:    `**launch:a?data={"pct":"50%"}`,
+			wantArgs: []string{"a"},
+			wantAdv:  map[string]string{"data": `{"pct":"50%"}`},
+		},
+		{
+			name:    "invalid sequence",
+			input:   "**launch:a?system=snes&name=bad%zz",
+			wantErr: zapscript.ErrInvalidPercentEscape,
+		},
+		{
+			name:    "truncated sequence",
+			input:   "**launch:a?name=50%",
+			wantErr: zapscript.ErrInvalidPercentEscape,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.Parse(tt.input, zapscript.WithDecodePercentEscapes())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if !strings.Contains(err.Error(), `adv arg "name"`) {
+					t.Errorf("Parse() error = %v, want it to name the key", err)
+				}
+				return
+			}
+			want := zapscript.Command{
+				Name:    "launch",
+				Args:    tt.wantArgs,
+				AdvArgs: zapscript.NewAdvArgs(tt.wantAdv),
+			}
+			if strings.HasPrefix(tt.input, "**launch.random") {
+				want.Name = "launch.random"
+			}
+			if diff := cmp.Diff([]zapscript.Command{want}, got.Cmds, diffOpts); diff != "" {
+				t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodePercentEscapesDefaultOff(t *testing.T) {
+	t.Parallel()
+
+	got, err := zapscript.Parse("**launch:a?name=bad%zz%20")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if name := got.Cmds[0].AdvArgs.Get(zapscript.KeyName); name != "bad%zz%20" {
+		t.Errorf("name = %q, want it unchanged", name)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestParseMatchesNewParser checks the pooled Parse gives the same result as
+// a fresh parser for every corpus input, including after errors.
+func TestParseMatchesNewParser(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range parseScriptSeeds {
+		want, wantErr := NewParser(input).ParseScript()
+		got, err := Parse(input)
+		if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Errorf("Parse(%q) error = %v, want %v", input, err, wantErr)
+		}
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(AdvArgs{})); diff != "" {
+			t.Errorf("Parse(%q) mismatch (-want +got):\n%s", input, diff)
+		}
+	}
+}
+
+// TestResetMatchesNewParser checks a parser reused with Reset gives the same
+// result as a fresh one for every corpus input, including after errors, and
+// keeps its options.
+func TestResetMatchesNewParser(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range [][]Option{nil, {WithStrictMode(), WithKeepStyle()}} {
+		sr := NewParserFromReader(strings.NewReader("**launch:first||**stop"), opts...)
+		if _, err := sr.ParseScript(); err != nil {
+			t.Fatalf("ParseScript() unexpected error: %v", err)
+		}
+		for _, input := range parseScriptSeeds {
+			want, wantErr := NewParserWithOptions(input, opts...).ParseScript()
+			sr.Reset(input)
+			if sr.Pos() != 0 || sr.Line() != 1 || sr.Column() != 0 {
+				t.Fatalf("Reset(%q) position = %d, line %d, column %d, want 0, 1, 0",
+					input, sr.Pos(), sr.Line(), sr.Column())
+			}
+			got, err := sr.ParseScript()
+			if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+				t.Errorf("Reset(%q) error = %v, want %v", input, err, wantErr)
+			}
+			if diff := cmp.Diff(want, got, cmp.AllowUnexported(AdvArgs{})); diff != "" {
+				t.Errorf("Reset(%q) mismatch (-want +got):\n%s", input, diff)
+			}
+		}
+	}
+}
+
+// TestParseConcurrent hammers Parse from many goroutines so the race
+// detector can catch state shared between pooled parsers.
+func TestParseConcurrent(t *testing.T) {
+	t.Parallel()
+
+	type result struct {
+		err    string
+		script Script
+	}
+	want := make([]result, len(parseScriptSeeds))
+	for i, input := range parseScriptSeeds {
+		script, err := NewParser(input).ParseScript()
+		want[i] = result{script: script, err: fmt.Sprint(err)}
+	}
+
+	const workers = 16
+	const rounds = 20
+	var wg sync.WaitGroup
+	errs := make(chan string, workers)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range rounds {
+				// each worker walks the corpus from a different offset so
+				// pooled parsers see a mix of inputs and options
+				for n := range parseScriptSeeds {
+					i := (n + w + r) % len(parseScriptSeeds)
+					opts := []Option{WithKeepStyle()}
+					if (w+r)%2 == 0 {
+						opts = nil
+					}
+					got, err := Parse(parseScriptSeeds[i], opts...)
+					if fmt.Sprint(err) != want[i].err {
+						errs <- fmt.Sprintf("Parse(%q) error = %v, want %s", parseScriptSeeds[i], err, want[i].err)
+						return
+					}
+					for j := range got.Cmds {
+						got.Cmds[j].ArgStyles = nil
+					}
+					if !cmp.Equal(want[i].script, got, cmp.AllowUnexported(AdvArgs{}), ignoreStyles) {
+						errs <- fmt.Sprintf("Parse(%q) result differs from NewParser", parseScriptSeeds[i])
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for msg := range errs {
+		t.Error(msg)
+	}
+}
+
+// TestEvalConcurrent hammers Eval from many goroutines, each with its own
+// input and env, so the race detector can catch shared state and any mixing
+// of results between pooled parsers shows up as a wrong value.
+func TestEvalConcurrent(t *testing.T) {
+	t.Parallel()
+
+	const workers = 32
+	const rounds = 50
+	var wg sync.WaitGroup
+	errs := make(chan string, workers)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			input := fmt.Sprintf("%d:%sn * 2%s:%supper(name)%s", w, TokExpStart, TokExprEnd, TokExpStart, TokExprEnd)
+			env := map[string]any{"n": w, "name": fmt.Sprintf("worker%d", w)}
+			want := fmt.Sprintf("%d:%d:WORKER%d", w, w*2, w)
+			for range rounds {
+				got, err := Eval(input, env)
+				if err != nil {
+					errs <- fmt.Sprintf("Eval(%q) unexpected error: %v", input, err)
+					return
+				}
+				if got != want {
+					errs <- fmt.Sprintf("Eval(%q) = %q, want %q", input, got, want)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for msg := range errs {
+		t.Error(msg)
+	}
+}
+
+var ignoreStyles = cmp.FilterPath(func(p cmp.Path) bool {
+	sf, ok := p.Last().(cmp.StructField)
+	return ok && sf.Name() == "styles"
+}, cmp.Ignore())
+
+func BenchmarkParse(b *testing.B) {
+	const input = `**launch:snes/mario.sfc?system=snes&launcher=retroarch||**delay:500||**notify:"done, [[platform]]"`
+
+	b.Run("NewParser", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := NewParser(input).ParseScript(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Reset", func(b *testing.B) {
+		sr := NewParser(input)
+		b.ReportAllocs()
+		for b.Loop() {
+			sr.Reset(input)
+			if _, err := sr.ParseScript(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := Parse(input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestParserPosition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr  error
+		name     string
+		input    string
+		wantPos  int64
+		wantLine int64
+		wantCol  int64
+	}{
+		{
+			name:     "initial state",
+			input:    "",
+			wantErr:  zapscript.ErrEmptyZapScript,
+			wantPos:  0,
+			wantLine: 1,
+			wantCol:  0,
+		},
+		{
+			name:     "fully consumed single line",
+			input:    "**launch:game.rom",
+			wantPos:  17,
+			wantLine: 1,
+			wantCol:  17,
+		},
+		{
+			name:     "unmatched quote on first line",
+			input:    `**echo:"abc`,
+			wantErr:  zapscript.ErrUnmatchedQuote,
+			wantPos:  11,
+			wantLine: 1,
+			wantCol:  11,
+		},
+		{
+			name:     "error on second line",
+			input:    "**echo:a||\n**echo:\"x",
+			wantErr:  zapscript.ErrUnmatchedQuote,
+			wantPos:  20,
+			wantLine: 2,
+			wantCol:  9,
+		},
+		{
+			name:     "multi-byte runes count once",
+			input:    "**echo:日本\n語[[x",
+			wantErr:  zapscript.ErrUnmatchedExpression,
+			wantPos:  14,
+			wantLine: 2,
+			wantCol:  4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := zapscript.NewParser(tt.input)
+			_, err := p.ParseScript()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseScript() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := p.Pos(); got != tt.wantPos {
+				t.Errorf("Pos() = %d, want %d", got, tt.wantPos)
+			}
+			if got := p.Line(); got != tt.wantLine {
+				t.Errorf("Line() = %d, want %d", got, tt.wantLine)
+			}
+			if got := p.Column(); got != tt.wantCol {
+				t.Errorf("Column() = %d, want %d", got, tt.wantCol)
+			}
+		})
+	}
+}
@@ -16,9 +16,13 @@
 package zapscript
 
 import (
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"unicode"
+	"unicode/utf8"
 
 	"pgregory.net/rapid"
 )
@@ -318,11 +322,11 @@ func TestPropertyIsCmdNameAlphanumeric(t *testing.T) {
 
 		result := isCmdName(ch)
 
-		// Expected: a-z, A-Z, 0-9, or .
+		// Expected: a-z, A-Z, 0-9, ., - or _
 		expected := (ch >= 'a' && ch <= 'z') ||
 			(ch >= 'A' && ch <= 'Z') ||
 			(ch >= '0' && ch <= '9') ||
-			ch == '.'
+			ch == '.' || ch == '-' || ch == '_'
 
 		if result != expected {
 			t.Fatalf("isCmdName(%q) = %v, expected %v", ch, result, expected)
@@ -500,3 +504,103 @@ func TestPropertyParseScriptHandlesUnicode(t *testing.T) {
 		}
 	})
 }
+
+// ============================================================================
+// Expression Token Tests
+// ============================================================================
+
+// exprValueGen generates parsed values mixing text, including [, ] and ^,
+// with expression tokens.
+func exprValueGen() *rapid.Generator[string] {
+	return rapid.Custom(func(t *rapid.T) string {
+		parts := rapid.SliceOfN(rapid.Bool(), 0, 6).Draw(t, "parts")
+		var b strings.Builder
+		for i, isExpr := range parts {
+			if isExpr {
+				src := rapid.StringMatching(`[a-z0-9 +.()]{1,10}`).Draw(t, "expr"+strconv.Itoa(i))
+				b.WriteString(TokExpStart + src + TokExprEnd)
+			} else {
+				b.WriteString(rapid.StringMatching(`[a-z\[\]^ ]{0,8}`).Draw(t, "text"+strconv.Itoa(i)))
+			}
+		}
+		return b.String()
+	})
+}
+
+// TestPropertyDetokenizeExpressionsInverse verifies that TokenizeExpressions
+// restores any value DetokenizeExpressions renders.
+func TestPropertyDetokenizeExpressionsInverse(t *testing.T) {
+	t.Parallel()
+	rapid.Check(t, func(t *rapid.T) {
+		value := exprValueGen().Draw(t, "value")
+
+		text := DetokenizeExpressions(value)
+		if strings.ContainsAny(text, TokExpStart+TokExprEnd) {
+			t.Fatalf("DetokenizeExpressions(%q) = %q, still has tokens", value, text)
+		}
+		got, err := TokenizeExpressions(text)
+		if err != nil {
+			t.Fatalf("TokenizeExpressions(%q) error: %v (value=%q)", text, err, value)
+		}
+		if got != value {
+			t.Fatalf("round trip mismatch: value=%q → text=%q → %q", value, text, got)
+		}
+	})
+}
+
+// TestDetokenizeExpressionsFuzzCorpus checks the inverse relationship for the
+// values parsed from every string in the fuzz corpus.
+func TestDetokenizeExpressionsFuzzCorpus(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "fuzz", "*", "*"))
+	if err != nil {
+		t.Fatalf("failed to list fuzz corpus: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			quoted, ok := strings.CutPrefix(line, "string(")
+			if !ok {
+				continue
+			}
+			input, err := strconv.Unquote(strings.TrimSuffix(quoted, ")"))
+			if err != nil {
+				t.Fatalf("%s: bad corpus entry %q: %v", file, line, err)
+			}
+
+			var values []string
+			if tokenized, tokErr := TokenizeExpressions(input); tokErr == nil {
+				values = append(values, tokenized)
+			}
+			if script, parseErr := NewParser(input).ParseScript(); parseErr == nil {
+				for _, cmd := range script.Cmds {
+					values = append(values, cmd.Args...)
+					for _, k := range cmd.AdvArgs.OrderedKeys() {
+						values = append(values, cmd.AdvArgs.Get(k))
+					}
+				}
+			}
+
+			for _, value := range values {
+				if !utf8.ValidString(value) {
+					continue
+				}
+				if _, complete := splitArgParts(value); !complete {
+					continue
+				}
+				text := DetokenizeExpressions(value)
+				got, err := TokenizeExpressions(text)
+				if err != nil {
+					t.Errorf("%s: TokenizeExpressions(%q) error: %v (value=%q)", file, text, err, value)
+				} else if got != value {
+					t.Errorf("%s: round trip mismatch: value=%q → text=%q → %q", file, value, text, got)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommandRaw(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "chain with whitespace and trailing separators",
+			input: "  **greet:a^,b ||@snes/Mario World?x=1||  game.rom ||",
+			want:  []string{"**greet:a^,b ", "@snes/Mario World?x=1", "game.rom "},
+		},
+		{
+			name:  "escaped separator",
+			input: "**launch:x^||y",
+			want:  []string{"**launch:x^||y"},
+		},
+		{
+			name:  "quoted separator",
+			input: `**a:"q||r"||**b`,
+			want:  []string{`**a:"q||r"`, "**b"},
+		},
+		{
+			name:  "after traits",
+			input: "#a=1 **stop||ünï.rom|",
+			want:  []string{"**stop", "ünï.rom"},
+		},
+		{
+			name:  "traits command skipped",
+			input: `**traits:{"a":1}||**b`,
+			want:  []string{"**b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			got := make([]string, 0, len(script.Cmds))
+			for _, cmd := range script.Cmds {
+				got = append(got, cmd.Raw)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Command.Raw mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommandRawJSONScript(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse(`{"cmds":[{"name":"stop"}]}`)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if raw := script.Cmds[0].Raw; raw != "" {
+		t.Errorf("Command.Raw = %q, want empty for a JSON script", raw)
+	}
+}
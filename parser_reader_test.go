@@ -0,0 +1,143 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+// largeScript returns a multi-kilobyte script mixing commands, traits,
+// comments and multibyte text.
+func largeScript() string {
+	var b strings.Builder
+	_, _ = b.WriteString("#difficulty=hard #lives=3||")
+	for i := range 100 {
+		if i > 0 {
+			_, _ = b.WriteString("||")
+		}
+		switch i % 5 {
+		case 0:
+			_, _ = b.WriteString(`**launch:"/games/snes/スーパー, mario.sfc"?system=snes&launcher=x`)
+		case 1:
+			_, _ = b.WriteString("@SNES/Super Mario World (USA)?launcher=y")
+		case 2:
+			_, _ = b.WriteString(`**echo:a^,b,[[platform == "mister"]]?when=[[media_playing]]`)
+		case 3:
+			_, _ = b.WriteString("**# a comment with ümlauts and 🎮")
+		default:
+			_, _ = b.WriteString("game.rom")
+		}
+	}
+	return b.String()
+}
+
+func TestNewParserFromReader(t *testing.T) {
+	t.Parallel()
+
+	large := largeScript()
+	tests := []struct {
+		name  string
+		input string
+		opts  []zapscript.Option
+	}{
+		{name: "single command", input: "**launch:game?system=snes"},
+		{name: "multibyte", input: "**echo:日本語🎮||**stop"}, //nolint:gosmopolitan // unicode test case
+		{name: "traits and commands", input: `#a=1 #b=[x,y]||**traits:{"c":true}||**launch:a`},
+		{name: "JSON script", input: `{"cmds":[{"name":"launch","args":["a"]}]}`},
+		{name: "input macro", input: "**input.keyboard:ab{enter}*3||**stop"},
+		{
+			name:  "multi-line",
+			input: "**launch:a,^\r\nb\n\n**stop\n",
+			opts:  []zapscript.Option{zapscript.WithMultiLine()},
+		},
+		{name: "large", input: large},
+		{name: "error late in large input", input: large + `||**echo:"unclosed`},
+		{name: "invalid encoding", input: large + "||**echo:\x01"},
+		{name: "limit", input: large, opts: []zapscript.Option{zapscript.WithMaxInputRunes(2000)}},
+		{name: "empty", input: ""},
+		{name: "byte order mark", input: "\uFEFF**stop"},
+	}
+
+	readers := map[string]func(io.Reader) io.Reader{
+		"whole":    func(r io.Reader) io.Reader { return r },
+		"one byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+		"data err": iotest.DataErrReader,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want, wantErr := zapscript.NewParserWithOptions(tt.input, tt.opts...).ParseScript()
+			for name, wrap := range readers {
+				sr := zapscript.NewParserFromReader(wrap(strings.NewReader(tt.input)), tt.opts...)
+				got, err := sr.ParseScript()
+				if diff := cmp.Diff(errString(wantErr), errString(err)); diff != "" {
+					t.Errorf("%s: ParseScript() error mismatch (-string +reader):\n%s", name, diff)
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("%s: ParseScript() mismatch (-string +reader):\n%s", name, diff)
+				}
+			}
+		})
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// endlessReader never runs out of input.
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+	return len(p), nil
+}
+
+func TestNewParserFromReaderStopsAtLimit(t *testing.T) {
+	t.Parallel()
+
+	_, err := zapscript.NewParserFromReader(io.MultiReader(strings.NewReader("**echo:"), endlessReader{})).
+		ParseScript()
+	if !errors.Is(err, zapscript.ErrScriptTooLarge) {
+		t.Errorf("ParseScript() error = %v, want %v", err, zapscript.ErrScriptTooLarge)
+	}
+}
+
+func TestNewParserFromReaderError(t *testing.T) {
+	t.Parallel()
+
+	readErr := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader("**launch:a||**ec"), iotest.ErrReader(readErr))
+	_, err := zapscript.NewParserFromReader(iotest.OneByteReader(r)).ParseScript()
+	if !errors.Is(err, readErr) {
+		t.Errorf("ParseScript() error = %v, want it to wrap %v", err, readErr)
+	}
+}
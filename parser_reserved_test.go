@@ -0,0 +1,62 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestReservedCommandName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		reserved bool
+	}{
+		{name: "exact match", input: "**zap.internal", reserved: true},
+		{name: "exact match with args", input: "**zap.internal:a", reserved: true},
+		{name: "prefix match", input: "**zap.internal.inject:a,b", reserved: true},
+		{name: "prefix match with adv args", input: "**zap.internal.inject?x=1", reserved: true},
+		{name: "upper case", input: "**ZAP.Internal.Inject:a", reserved: true},
+		{name: "later in chain", input: "**launch:a||**zap.internal.x", reserved: true},
+		{name: "similar prefix", input: "**zap.internals:a"},
+		{name: "other zap namespace", input: "**zap.public:a"},
+		{name: "namespace not at start", input: "**my.zap.internal:a"},
+		{name: "normal command", input: "**launch:game.rom"},
+		{name: "auto-launch content", input: "zap.internal.thing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := zapscript.NewParser(tt.input).ParseScript()
+			if tt.reserved {
+				if !errors.Is(err, zapscript.ErrReservedCommandName) {
+					t.Fatalf("ParseScript() error = %v, want %v", err, zapscript.ErrReservedCommandName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseEscapedCmdSeparator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  zapscript.Script
+	}{
+		{
+			name:  "arg",
+			input: "**echo:a^|^|b",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: "echo", Args: []string{"a||b"}}},
+			},
+		},
+		{
+			name:  "escaped first pipe",
+			input: "**echo:a^||b",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: "echo", Args: []string{"a||b"}}},
+			},
+		},
+		{
+			name:  "followed by command",
+			input: "**echo:a^|^|b||**stop",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "echo", Args: []string{"a||b"}},
+					{Name: zapscript.ZapScriptCmdStop},
+				},
+			},
+		},
+		{
+			name:  "adv arg",
+			input: "**echo:x?when=a^|^|b",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{
+					Name:    "echo",
+					Args:    []string{"x"},
+					AdvArgs: zapscript.NewAdvArgs(map[string]string{"when": "a||b"}),
+				}},
+			},
+		},
+		{
+			name:  "auto launch",
+			input: "/games/a^|^|b.sfc",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{Name: zapscript.ZapScriptCmdLaunch, Args: []string{"/games/a||b.sfc"}}},
+			},
+		},
+		{
+			name:  "input macro",
+			input: "**input.keyboard:a^|^|b",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{{
+					Name: zapscript.ZapScriptCmdInputKeyboard,
+					Args: []string{"a", "|", "|", "b"},
+				}},
+			},
+		},
+		{
+			name:  "input macro followed by command",
+			input: "**input.keyboard:^|^|||**stop",
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: zapscript.ZapScriptCmdInputKeyboard, Args: []string{"|", "|"}},
+					{Name: zapscript.ZapScriptCmdStop},
+				},
+			},
+		},
+		{
+			name:  "trait value",
+			input: "#k=a^|^|b",
+			want: zapscript.Script{
+				Traits: map[string]any{"k": "a||b"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
+				t.Errorf("script mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStringEscapesCmdSeparator(t *testing.T) {
+	t.Parallel()
+
+	cmd := zapscript.Command{Name: "echo", Args: []string{"a||b"}}
+	got := cmd.StringWithOptions(zapscript.WithQuotePreference(zapscript.QuotePreferEscapes))
+	if want := "**echo:a^|^|b"; got != want {
+		t.Fatalf("StringWithOptions() = %q, want %q", got, want)
+	}
+
+	script, err := zapscript.NewParser(got).ParseScript()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]zapscript.Command{cmd}, script.Cmds, diffOpts); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTrailingTextAfterQuote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		input       string
+		wantPos     string
+		wantLenient []zapscript.Command
+	}{
+		{
+			name:    "before comma",
+			input:   `**cmd:"quoted"garbage,b`,
+			wantPos: "position 15",
+			wantLenient: []zapscript.Command{
+				{Name: "cmd", Args: []string{"quotedgarbage", "b"}},
+			},
+		},
+		{
+			name:    "before adv args",
+			input:   `**cmd:"quoted"x?a=1`,
+			wantPos: "position 15",
+			wantLenient: []zapscript.Command{{
+				Name:    "cmd",
+				Args:    []string{"quotedx"},
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{"a": "1"}),
+			}},
+		},
+		{
+			name:    "at EOF",
+			input:   `**cmd:'quoted'x`,
+			wantPos: "position 15",
+			wantLenient: []zapscript.Command{
+				{Name: "cmd", Args: []string{"quotedx"}},
+			},
+		},
+		{
+			name:    "escape after quote",
+			input:   `**cmd:"a"^,b`,
+			wantPos: "position 10",
+			wantLenient: []zapscript.Command{
+				{Name: "cmd", Args: []string{"a,b"}},
+			},
+		},
+		{
+			name:    "adv arg value",
+			input:   `**cmd?a="x"y&b=2`,
+			wantPos: "position 12",
+			wantLenient: []zapscript.Command{{
+				Name:    "cmd",
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{"a": "xy", "b": "2"}),
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("lenient ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantLenient, got.Cmds, diffOpts); diff != "" {
+				t.Errorf("lenient ParseScript() mismatch (-want +got):\n%s", diff)
+			}
+
+			_, err = zapscript.NewParserWithOptions(tt.input, zapscript.WithStrictMode()).ParseScript()
+			if !errors.Is(err, zapscript.ErrTrailingAfterQuote) {
+				t.Fatalf("strict ParseScript() error = %v, want %v", err, zapscript.ErrTrailingAfterQuote)
+			}
+			if !strings.Contains(err.Error(), tt.wantPos) {
+				t.Errorf("strict ParseScript() error = %q, want it to mention %q", err, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestStrictModeAllowsTextAfterQuote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []zapscript.Command
+	}{
+		{
+			name:  "separator",
+			input: `**cmd:"a,b",c`,
+			want:  []zapscript.Command{{Name: "cmd", Args: []string{"a,b", "c"}}},
+		},
+		{
+			name:  "whitespace before separator",
+			input: `**cmd:"a" ,"b" `,
+			want:  []zapscript.Command{{Name: "cmd", Args: []string{"a", "b"}}},
+		},
+		{
+			name:  "adv args",
+			input: `**cmd:"a"?x="1"&y=2`,
+			want: []zapscript.Command{{
+				Name:    "cmd",
+				Args:    []string{"a"},
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{"x": "1", "y": "2"}),
+			}},
+		},
+		{
+			name:  "command separator",
+			input: `**cmd:"a"||**cmd:'b'`,
+			want: []zapscript.Command{
+				{Name: "cmd", Args: []string{"a"}},
+				{Name: "cmd", Args: []string{"b"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParserWithOptions(tt.input, zapscript.WithStrictMode()).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got.Cmds, diffOpts); diff != "" {
+				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -105,6 +105,43 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "hyphen in command name",
+			input: `**mister.load-core:snes`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "mister.load-core", Args: []string{"snes"}},
+				},
+			},
+		},
+		{
+			name:  "underscore in command name",
+			input: `**My_Ext.do_thing?when=true||**a-b_c`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "my_ext.do_thing", AdvArgs: zapscript.NewAdvArgs(map[string]string{"when": "true"})},
+					{Name: "a-b_c"},
+				},
+			},
+		},
+		{
+			name:  "leading hyphen in command name",
+			input: `**-load:snes`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "launch", Args: []string{`**-load:snes`}},
+				},
+			},
+		},
+		{
+			name:  "leading underscore in command name",
+			input: `**_load`,
+			want: zapscript.Script{
+				Cmds: []zapscript.Command{
+					{Name: "launch", Args: []string{`**_load`}},
+				},
+			},
+		},
 		{
 			name:    "unexpected EOF after asterisk",
 			input:   `*`,
@@ -285,7 +322,7 @@ func TestParse(t *testing.T) {
 				t.Errorf("ParseScript() error = %v, wantErr = %v", err, tt.wantErr)
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseScript() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -353,7 +390,7 @@ func TestParseExpressions(t *testing.T) {
 				t.Errorf("ParseExpressions() error = %v, wantErr = %v", err, tt.wantErr)
 				return
 			}
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("ParseExpressions() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -457,7 +494,7 @@ func TestPostProcess(t *testing.T) {
 				return
 			}
 
-			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
 				t.Errorf("EvalExpressions() mismatch (-want +got):\n%s", diff)
 			}
 		})
@@ -514,6 +551,69 @@ func TestAdvArgs_WithMustAssignReturn(t *testing.T) {
 	})
 }
 
+func TestAdvArgs_WithoutMustAssignReturn(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Without on nil map returns empty", func(t *testing.T) {
+		t.Parallel()
+
+		advArgs := zapscript.NewAdvArgs(nil).Without(zapscript.KeyWhen)
+		if !advArgs.IsEmpty() {
+			t.Errorf("Expected empty AdvArgs, got %v", advArgs.Raw())
+		}
+		if got := zapscript.NewAdvArgs(nil).WithoutKeys(zapscript.KeyWhen, zapscript.KeySystem); !got.IsEmpty() {
+			t.Errorf("Expected empty AdvArgs, got %v", got.Raw())
+		}
+	})
+
+	t.Run("Without does not mutate original", func(t *testing.T) {
+		t.Parallel()
+
+		original := zapscript.NewAdvArgs(map[string]string{
+			"when":     "[[media_playing]]",
+			"existing": "value",
+		})
+		modified := original.Without(zapscript.KeyWhen)
+
+		if !original.Has(zapscript.KeyWhen) {
+			t.Error("Original should still have removed key")
+		}
+		if modified.Has(zapscript.KeyWhen) {
+			t.Error("Modified should not have removed key")
+		}
+		if modified.Get("existing") != "value" {
+			t.Errorf("Modified should preserve other keys, got %q", modified.Get("existing"))
+		}
+	})
+
+	t.Run("Without absent key is a no-op", func(t *testing.T) {
+		t.Parallel()
+
+		original := zapscript.NewAdvArgs(map[string]string{"existing": "value"})
+		modified := original.Without(zapscript.KeyWhen)
+		if !modified.Equal(original) {
+			t.Errorf("Expected %v, got %v", original.Raw(), modified.Raw())
+		}
+	})
+
+	t.Run("WithoutKeys removes every key", func(t *testing.T) {
+		t.Parallel()
+
+		original := zapscript.NewAdvArgs(map[string]string{
+			"when":   "true",
+			"system": "snes",
+			"slot":   "1",
+		})
+		modified := original.WithoutKeys(zapscript.KeyWhen, zapscript.KeySlot, "absent")
+		if got := modified.Raw(); len(got) != 1 || got["system"] != "snes" {
+			t.Errorf("Expected only system, got %v", got)
+		}
+		if original.Len() != 3 {
+			t.Errorf("Original should keep all keys, got %v", original.Raw())
+		}
+	})
+}
+
 func TestAdvArgs_GetWhen(t *testing.T) {
 	t.Parallel()
 
@@ -595,6 +695,67 @@ func TestAdvArgs_IsEmpty(t *testing.T) {
 	})
 }
 
+func TestAdvArgs_All(t *testing.T) {
+	t.Parallel()
+
+	t.Run("yields pairs in order", func(t *testing.T) {
+		t.Parallel()
+
+		script, err := zapscript.Parse("**launch:a?system=snes&launcher=retroarch&slot=2")
+		if err != nil {
+			t.Fatalf("Parse() unexpected error: %v", err)
+		}
+
+		type pair struct {
+			key   zapscript.Key
+			value string
+		}
+		var collected []pair
+		for k, v := range script.Cmds[0].AdvArgs.All() {
+			collected = append(collected, pair{key: k, value: v})
+		}
+
+		want := []pair{
+			{key: zapscript.KeySystem, value: "snes"},
+			{key: zapscript.KeyLauncher, value: "retroarch"},
+			{key: zapscript.KeySlot, value: "2"},
+		}
+		if diff := cmp.Diff(want, collected, cmp.AllowUnexported(pair{})); diff != "" {
+			t.Errorf("All() mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("break stops iteration", func(t *testing.T) {
+		t.Parallel()
+
+		advArgs := zapscript.NewAdvArgs(map[string]string{
+			"a": "1",
+			"b": "2",
+			"c": "3",
+		})
+
+		var keys []zapscript.Key
+		for k := range advArgs.All() {
+			keys = append(keys, k)
+			if k == "b" {
+				break
+			}
+		}
+
+		if diff := cmp.Diff([]zapscript.Key{"a", "b"}, keys); diff != "" {
+			t.Errorf("All() keys mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("nil map", func(t *testing.T) {
+		t.Parallel()
+
+		for k, v := range zapscript.NewAdvArgs(nil).All() {
+			t.Errorf("Expected no iterations for nil map, got %q=%q", k, v)
+		}
+	})
+}
+
 func TestAdvArgs_Range(t *testing.T) {
 	t.Parallel()
 
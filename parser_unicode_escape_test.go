@@ -0,0 +1,159 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseUnicodeEscape(t *testing.T) {
+	t.Parallel()
+
+	echo := func(arg string) zapscript.Script {
+		return zapscript.Script{Cmds: []zapscript.Command{{Name: "echo", Args: []string{arg}}}}
+	}
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		want    zapscript.Script
+	}{
+		{
+			name:  "bmp",
+			input: "**echo:a^u{2192}b",
+			want:  echo("a→b"),
+		},
+		{
+			name:  "one digit",
+			input: "**echo:^u{41}^u{9}x",
+			want:  echo("A\tx"),
+		},
+		{
+			name:  "lowercase hex",
+			input: "**echo:^u{2014}^u{6f22}",
+			want:  echo("—漢"),
+		},
+		{
+			name:  "astral plane",
+			input: "**echo:^u{1F3AE}",
+			want:  echo("🎮"),
+		},
+		{
+			name:  "six digits",
+			input: "**echo:^u{10FFFF}",
+			want:  echo("\U0010FFFF"),
+		},
+		{
+			name:  "quoted arg",
+			input: `**echo:"x, ^u{1F3AE}"`,
+			want:  echo("x, 🎮"),
+		},
+		{
+			name:  "escaped caret",
+			input: "**echo:^^u{41}",
+			want:  echo("^u{41}"),
+		},
+		{
+			name:  "adv arg value",
+			input: "**echo:x?name=^u{1F3AE}&launcher=\"^u{2192}\"",
+			want: zapscript.Script{Cmds: []zapscript.Command{{
+				Name: "echo",
+				Args: []string{"x"},
+				AdvArgs: zapscript.NewAdvArgs(map[string]string{
+					"name":     "🎮",
+					"launcher": "→",
+				}),
+			}}},
+		},
+		{
+			name:  "trait value",
+			input: "#title=^u{1F3AE} #quoted=\"a^u{2192}b\"",
+			want: zapscript.Script{
+				Traits: map[string]any{"title": "🎮", "quoted": "a→b"},
+			},
+		},
+		{
+			name:    "missing opening brace",
+			input:   "**echo:^u2192",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+		{
+			name:    "missing closing brace",
+			input:   "**echo:^u{2192",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+		{
+			name:    "bad hex",
+			input:   "**echo:^u{21G2}",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+		{
+			name:    "no digits",
+			input:   "**echo:^u{}",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+		{
+			name:    "too many digits",
+			input:   "**echo:^u{0010FFFF}",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+		{
+			name:    "surrogate",
+			input:   "**echo:^u{D83C}",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+		{
+			name:    "out of range",
+			input:   "**echo:^u{110000}",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+		{
+			name:    "malformed in adv arg",
+			input:   "**echo:x?name=^u{zz}",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+		{
+			name:    "malformed in trait",
+			input:   "#title=^u{D800}",
+			wantErr: zapscript.ErrInvalidUnicodeEscape,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := zapscript.NewParser(tt.input).ParseScript()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got, diffOpts); diff != "" {
+				t.Errorf("script mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
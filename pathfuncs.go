@@ -0,0 +1,134 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/expr-lang/expr"
+)
+
+// Path expression function names.
+const (
+	ExprFuncPathJoin  = "pathjoin"
+	ExprFuncPathClean = "pathclean"
+	ExprFuncBasename  = "basename"
+)
+
+// pathFunctions returns the pathjoin, pathclean and basename expression
+// functions configured by opts. basename is left out if exprEnv has a field
+// of that name, see envHasName.
+func pathFunctions(opts EvalOptions, exprEnv any) []expr.Option {
+	funcs := []expr.Option{
+		expr.Function(ExprFuncPathJoin, func(params ...any) (any, error) {
+			parts := make([]string, 0, len(params))
+			for _, p := range params {
+				s, ok := p.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s: expected string argument, got %T", ExprFuncPathJoin, p)
+				}
+				parts = append(parts, s)
+			}
+			return opts.cleanPath(parts...)
+		}, new(func(...string) string)),
+		expr.Function(ExprFuncPathClean, func(params ...any) (any, error) {
+			s, ok := params[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected string argument, got %T", ExprFuncPathClean, params[0])
+			}
+			return opts.cleanPath(s)
+		}, new(func(string) string)),
+	}
+	if !envHasName(exprEnv, ExprFuncBasename) {
+		funcs = append(funcs, expr.Function(ExprFuncBasename, func(params ...any) (any, error) {
+			s, ok := params[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected string argument, got %T", ExprFuncBasename, params[0])
+			}
+			return opts.basename(s), nil
+		}, new(func(string) string)))
+	}
+	return funcs
+}
+
+// basename returns the last element of p, ignoring trailing separators, or
+// an empty string if p is empty or a root.
+func (o EvalOptions) basename(p string) string {
+	base := path.Base(o.toSlash(p))
+	if base == "." || base == "/" {
+		return ""
+	}
+	return base
+}
+
+// cleanPath joins and cleans parts with forward slash semantics, checks the
+// result against the sandbox root and converts it to the host separator.
+func (o EvalOptions) cleanPath(parts ...string) (string, error) {
+	for i, p := range parts {
+		parts[i] = o.toSlash(p)
+	}
+	cleaned := path.Join(parts...)
+	if len(parts) == 0 || cleaned == "" {
+		return "", nil
+	}
+
+	if o.SandboxRoot != "" {
+		root := path.Clean(o.toSlash(o.SandboxRoot))
+		resolved := cleaned
+		if !path.IsAbs(resolved) && !o.hasVolume(resolved) {
+			resolved = path.Join(root, resolved)
+		}
+		if !o.withinRoot(resolved, root) {
+			return "", fmt.Errorf("%w: %q is outside %q", ErrPathEscapesSandbox, cleaned, o.SandboxRoot)
+		}
+	}
+
+	if o.WindowsPaths {
+		return strings.ReplaceAll(cleaned, "/", `\`), nil
+	}
+	return cleaned, nil
+}
+
+func (o EvalOptions) toSlash(p string) string {
+	if o.WindowsPaths {
+		return strings.ReplaceAll(p, `\`, "/")
+	}
+	return p
+}
+
+// hasVolume reports whether p starts with a Windows drive letter, which makes
+// it absolute even without a leading slash.
+func (o EvalOptions) hasVolume(p string) bool {
+	return o.WindowsPaths && len(p) >= 2 && p[1] == ':'
+}
+
+func (o EvalOptions) withinRoot(p, root string) bool {
+	hasPrefix := strings.HasPrefix
+	equal := func(a, b string) bool { return a == b }
+	if o.WindowsPaths {
+		// Windows paths are case-insensitive
+		hasPrefix = func(s, prefix string) bool {
+			return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+		}
+		equal = strings.EqualFold
+	}
+	if equal(p, root) || root == "/" && path.IsAbs(p) {
+		return true
+	}
+	return hasPrefix(p, strings.TrimSuffix(root, "/")+"/")
+}
@@ -22,78 +22,748 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"sort"
+	"iter"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
 // AdvArgs is a wrapper around raw advanced arguments that enforces type-safe access.
 // Direct map access is not allowed; use the getter/setter methods for pre-parse operations.
+// The parser lowercases keys, so ?Launcher=x is stored under KeyLauncher.
+// Keys keep the order they were parsed in, see OrderedKeys. A key written
+// more than once keeps every value, see GetAll, while the other getters use
+// the last one.
 type AdvArgs struct {
 	raw map[string]string
+	// repeats holds every value, in order, of keys the parser read more
+	// than once
+	repeats map[string][]string
+	// keys holds the keys of raw in insertion order, or is nil if the order
+	// is unknown
+	keys []string
+	// json records keys whose value the parser read as a JSON object
+	json map[string]bool
+	// styles records how each value was quoted, see Options.KeepStyle
+	styles map[string]QuoteStyle
 }
 
 func NewAdvArgs(m map[string]string) AdvArgs {
 	return AdvArgs{raw: m}
 }
 
+// NewAdvArgsStrict is NewAdvArgs for keys that must survive a round trip
+// through ZapScript text. It returns an ErrInvalidAdvArgName error naming
+// the first invalid key, in sorted order, if any key is empty or has
+// characters the parser does not accept in a name: letters, digits and
+// underscores.
+func NewAdvArgsStrict(m map[string]string) (AdvArgs, error) {
+	a := NewAdvArgs(m)
+	if err := a.checkKeys(); err != nil {
+		return AdvArgs{}, err
+	}
+	return a, nil
+}
+
+// checkKeys returns an ErrInvalidAdvArgName error for the first key, in
+// sorted order, that the parser would not read back, see NewAdvArgsStrict.
+func (a AdvArgs) checkKeys() error {
+	for _, k := range a.SortedKeys() {
+		if !isValidAdvArgKey(string(k)) {
+			return fmt.Errorf("%w: %q", ErrInvalidAdvArgName, k)
+		}
+	}
+	return nil
+}
+
 func (a AdvArgs) Get(key Key) string {
 	return a.raw[string(key)]
 }
 
+// GetAll returns every value written for key in the order they appear, so
+// ?tag=region:us&tag=lang:en gives both filters where Get gives only the
+// last. A key written once gives a single value and a key that is not set
+// gives nil.
+func (a AdvArgs) GetAll(key Key) []string {
+	if values, ok := a.repeats[string(key)]; ok {
+		return slices.Clone(values)
+	}
+	if v, ok := a.raw[string(key)]; ok {
+		return []string{v}
+	}
+	return nil
+}
+
+// Lookup returns the value for key and whether it is set. A key written
+// without a value, as in ?flag or ?name=, is set to an empty string.
+func (a AdvArgs) Lookup(key Key) (string, bool) {
+	v, ok := a.raw[string(key)]
+	return v, ok
+}
+
+// Has reports whether key is set, even to an empty value, see Lookup.
+func (a AdvArgs) Has(key Key) bool {
+	_, ok := a.raw[string(key)]
+	return ok
+}
+
+// Len returns the number of adv args that are set.
+func (a AdvArgs) Len() int {
+	return len(a.raw)
+}
+
 // With returns a new AdvArgs with the key set to value. Does not mutate the receiver.
+// A new key is added after the existing ones; setting an existing key keeps
+// its position and replaces all of its values.
 func (a AdvArgs) With(key Key, value string) AdvArgs {
 	newMap := make(map[string]string, len(a.raw)+1)
 	for k, v := range a.raw {
 		newMap[k] = v
 	}
 	newMap[string(key)] = value
-	return AdvArgs{raw: newMap}
+
+	newKeys := make([]string, 0, len(a.raw)+1)
+	for _, k := range a.OrderedKeys() {
+		newKeys = append(newKeys, string(k))
+	}
+	if _, ok := a.raw[string(key)]; !ok {
+		newKeys = append(newKeys, string(key))
+	}
+
+	var newJSON map[string]bool
+	for k := range a.json {
+		if k == string(key) {
+			continue
+		}
+		if newJSON == nil {
+			newJSON = make(map[string]bool, len(a.json))
+		}
+		newJSON[k] = true
+	}
+
+	// the new value has no source style, so it is quoted automatically
+	var newStyles map[string]QuoteStyle
+	for k, style := range a.styles {
+		if k == string(key) {
+			continue
+		}
+		if newStyles == nil {
+			newStyles = make(map[string]QuoteStyle, len(a.styles))
+		}
+		newStyles[k] = style
+	}
+
+	return AdvArgs{
+		raw:     newMap,
+		repeats: copyRepeats(a.repeats, func(k string) bool { return k != string(key) }),
+		keys:    newKeys,
+		json:    newJSON,
+		styles:  newStyles,
+	}
+}
+
+// copyRepeats returns the entries of repeats for which keep returns true, or
+// nil if there are none.
+func copyRepeats(repeats map[string][]string, keep func(k string) bool) map[string][]string {
+	var out map[string][]string
+	for k, values := range repeats {
+		if !keep(k) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string][]string, len(repeats))
+		}
+		out[k] = values
+	}
+	return out
+}
+
+// Merge returns a new AdvArgs with the adv args of a and overrides, where
+// overrides win for keys set in both, e.g. to apply per-token adv args over
+// a launcher's defaults. The keys of a come first in their order, followed
+// by the new keys of overrides. Does not mutate either AdvArgs.
+func (a AdvArgs) Merge(overrides AdvArgs) AdvArgs {
+	if a.raw == nil && overrides.raw == nil {
+		return AdvArgs{}
+	}
+
+	newMap := make(map[string]string, len(a.raw)+len(overrides.raw))
+	newKeys := make([]string, 0, len(a.raw)+len(overrides.raw))
+	for _, k := range a.OrderedKeys() {
+		newMap[string(k)] = a.raw[string(k)]
+		newKeys = append(newKeys, string(k))
+	}
+	for _, k := range overrides.OrderedKeys() {
+		if _, ok := newMap[string(k)]; !ok {
+			newKeys = append(newKeys, string(k))
+		}
+		newMap[string(k)] = overrides.raw[string(k)]
+	}
+
+	// each value keeps how the AdvArgs it came from recorded it
+	var newJSON map[string]bool
+	var newStyles map[string]QuoteStyle
+	var newRepeats map[string][]string
+	for _, k := range newKeys {
+		src := a
+		if _, ok := overrides.raw[k]; ok {
+			src = overrides
+		}
+		if values, ok := src.repeats[k]; ok {
+			if newRepeats == nil {
+				newRepeats = make(map[string][]string)
+			}
+			newRepeats[k] = values
+		}
+		if src.json[k] {
+			if newJSON == nil {
+				newJSON = make(map[string]bool)
+			}
+			newJSON[k] = true
+		}
+		if style, ok := src.styles[k]; ok {
+			if newStyles == nil {
+				newStyles = make(map[string]QuoteStyle)
+			}
+			newStyles[k] = style
+		}
+	}
+
+	return AdvArgs{raw: newMap, repeats: newRepeats, keys: newKeys, json: newJSON, styles: newStyles}
+}
+
+// Without returns a new AdvArgs without key, e.g. to drop when once the host
+// has evaluated it. Does not mutate the receiver. Removing a key that is not
+// set returns an unchanged copy.
+func (a AdvArgs) Without(key Key) AdvArgs {
+	return a.WithoutKeys(key)
+}
+
+// WithoutKeys returns a new AdvArgs without any of keys, as Without does.
+// The remaining keys keep their order.
+func (a AdvArgs) WithoutKeys(keys ...Key) AdvArgs {
+	if a.raw == nil {
+		return AdvArgs{}
+	}
+	removed := func(k string) bool {
+		return slices.Contains(keys, Key(k))
+	}
+
+	newMap := make(map[string]string, len(a.raw))
+	for k, v := range a.raw {
+		if !removed(k) {
+			newMap[k] = v
+		}
+	}
+
+	// keys of unknown order stay unknown and are sorted by OrderedKeys
+	var newKeys []string
+	if len(a.keys) == len(a.raw) {
+		newKeys = make([]string, 0, len(newMap))
+		for _, k := range a.keys {
+			if !removed(k) {
+				newKeys = append(newKeys, k)
+			}
+		}
+	}
+
+	var newJSON map[string]bool
+	for k := range a.json {
+		if removed(k) {
+			continue
+		}
+		if newJSON == nil {
+			newJSON = make(map[string]bool, len(a.json))
+		}
+		newJSON[k] = true
+	}
+
+	var newStyles map[string]QuoteStyle
+	for k, style := range a.styles {
+		if removed(k) {
+			continue
+		}
+		if newStyles == nil {
+			newStyles = make(map[string]QuoteStyle, len(a.styles))
+		}
+		newStyles[k] = style
+	}
+
+	return AdvArgs{
+		raw:     newMap,
+		repeats: copyRepeats(a.repeats, func(k string) bool { return !removed(k) }),
+		keys:    newKeys,
+		json:    newJSON,
+		styles:  newStyles,
+	}
+}
+
+// Keys returns the keys as OrderedKeys does, but never nil, so an empty
+// AdvArgs gives an empty slice.
+func (a AdvArgs) Keys() []Key {
+	if keys := a.OrderedKeys(); keys != nil {
+		return keys
+	}
+	return []Key{}
+}
+
+// SortedKeys returns the keys sorted lexicographically, whatever order they
+// were parsed or added in. It never returns nil.
+func (a AdvArgs) SortedKeys() []Key {
+	keys := make([]Key, 0, len(a.raw))
+	for k := range a.raw {
+		keys = append(keys, Key(k))
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// OrderedKeys returns the keys in the order they were parsed or added with
+// With. Keys of an AdvArgs created by NewAdvArgs, whose order is unknown,
+// are sorted.
+func (a AdvArgs) OrderedKeys() []Key {
+	if len(a.raw) == 0 {
+		return nil
+	}
+	if len(a.keys) != len(a.raw) {
+		return a.SortedKeys()
+	}
+	keys := make([]Key, 0, len(a.raw))
+	for _, k := range a.keys {
+		keys = append(keys, Key(k))
+	}
+	return keys
+}
+
+// Equal reports whether a and b hold the same keys and values, comparing the
+// last value of repeated keys as Get does. Key order and the parse metadata
+// reported by Style and IsJSON are not compared, and nil
+// and empty AdvArgs are equal. go-cmp uses it, so cmp.Diff can compare
+// commands and scripts without cmp.AllowUnexported.
+func (a AdvArgs) Equal(b AdvArgs) bool {
+	if len(a.raw) != len(b.raw) {
+		return false
+	}
+	for k, v := range a.raw {
+		if bv, ok := b.raw[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Style returns how the value for key was quoted when parsed with
+// Options.KeepStyle, or QuoteStyleAuto if it was not recorded.
+func (a AdvArgs) Style(key Key) QuoteStyle {
+	return a.styles[string(key)]
+}
+
+// IsJSON reports whether the parser read the value for key as a JSON object,
+// meaning it is already known to be valid JSON.
+func (a AdvArgs) IsJSON(key Key) bool {
+	return a.json[string(key)]
+}
+
+// GetJSON unmarshals the value for key into v. It returns an error wrapping
+// ErrInvalidJSON if the key is not set or its value is not valid JSON.
+func (a AdvArgs) GetJSON(key Key, v any) error {
+	value, ok := a.raw[string(key)]
+	if !ok {
+		return fmt.Errorf("%w: adv arg %q is not set", ErrInvalidJSON, key)
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("%w: adv arg %q: %w", ErrInvalidJSON, key, err)
+	}
+	return nil
+}
+
+// GetBool reads the value for key as a bool, accepting true, false, 1, 0,
+// yes and no in any case, so that [[media_playing]] and a hand-written yes
+// read the same. A key that is not set or is empty reads as false. Any other
+// value is an ErrInvalidAdvArgBool error naming the key and value.
+func (a AdvArgs) GetBool(key Key) (bool, error) {
+	return parseAdvArgBool(key, a.raw[string(key)])
+}
+
+// IsFlagSet reports whether key is set as a flag: written alone, as in
+// **launch.random:snes?verbose, or with a value GetBool reads as true. A key
+// that is not set, is false, 0 or no, or has any other value is not set.
+func (a AdvArgs) IsFlagSet(key Key) bool {
+	value, ok := a.raw[string(key)]
+	if !ok {
+		return false
+	}
+	if strings.TrimSpace(value) == "" {
+		return true
+	}
+	set, err := parseAdvArgBool(key, value)
+	return err == nil && set
+}
+
+// GetInt reads the value for key as a base 10 int, ignoring surrounding
+// whitespace. A key that is not set or is empty is an ErrAdvArgMissing
+// error, so callers can apply a default; any other value that is not an
+// integer is an ErrInvalidAdvArgNumber error naming the key and value.
+func (a AdvArgs) GetInt(key Key) (int, error) {
+	value, err := a.getSet(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: adv arg %q is %q, want an integer", ErrInvalidAdvArgNumber, key, value)
+	}
+	return n, nil
+}
+
+// GetFloat reads the value for key as a finite float64, as GetInt does.
+func (a AdvArgs) GetFloat(key Key) (float64, error) {
+	value, err := a.getSet(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("%w: adv arg %q is %q, want a number", ErrInvalidAdvArgNumber, key, value)
+	}
+	return f, nil
+}
+
+// GetDuration reads the value for key as a duration, as GetInt does. A bare
+// integer is a number of milliseconds, as the delay command takes, and
+// anything else is parsed with time.ParseDuration, such as 1.5s or 2m.
+// Other values are an ErrInvalidAdvArgDuration error.
+func (a AdvArgs) GetDuration(key Key) (time.Duration, error) {
+	value, err := a.getSet(key)
+	if err != nil {
+		return 0, err
+	}
+	if ms, atoiErr := strconv.Atoi(value); atoiErr == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: adv arg %q is %q, want milliseconds or a duration such as 1.5s",
+			ErrInvalidAdvArgDuration, key, value)
+	}
+	return d, nil
+}
+
+// getSet returns the trimmed value for key, or an ErrAdvArgMissing error if
+// it is not set or is empty.
+func (a AdvArgs) getSet(key Key) (string, error) {
+	value := strings.TrimSpace(a.raw[string(key)])
+	if value == "" {
+		return "", fmt.Errorf("%w: %q", ErrAdvArgMissing, key)
+	}
+	return value, nil
 }
 
 func (a AdvArgs) GetWhen() (string, bool) {
-	v, ok := a.raw[string(KeyWhen)]
-	return v, ok
+	return a.Lookup(KeyWhen)
+}
+
+// GetTags parses the tags adv arg with ParseTagFilters. A key that is not
+// set gives an empty list; an invalid value is an error naming it.
+func (a AdvArgs) GetTags() ([]TagFilter, error) {
+	value := a.raw[string(KeyTags)]
+	filters, err := ParseTagFilters(value)
+	if err != nil {
+		return nil, fmt.Errorf("adv arg %q is %q: %w", KeyTags, value, err)
+	}
+	return filters, nil
+}
+
+// GetAction returns the action adv arg, for IsActionRun and
+// IsActionDetails. It is empty if not set, which IsActionRun accepts.
+func (a AdvArgs) GetAction() string {
+	return a.raw[string(KeyAction)]
+}
+
+// GetMode returns the mode adv arg, for IsModeShuffle.
+func (a AdvArgs) GetMode() string {
+	return a.raw[string(KeyMode)]
+}
+
+// The getters below return the value of their key and whether it is set, as
+// Lookup does. Every Key constant has one, so a key is never read through a
+// mistyped string. Numeric and bool values can be read with GetInt and
+// GetBool instead.
+
+// GetLauncher returns the launcher adv arg, the ID of the launcher to use.
+func (a AdvArgs) GetLauncher() (string, bool) {
+	return a.Lookup(KeyLauncher)
+}
+
+// GetSystem returns the system adv arg, the ID of the system to launch for.
+func (a AdvArgs) GetSystem() (string, bool) {
+	return a.Lookup(KeySystem)
+}
+
+// GetSetName returns the set_name adv arg.
+func (a AdvArgs) GetSetName() (string, bool) {
+	return a.Lookup(KeySetName)
+}
+
+// GetSetNameSameDir returns the set_name_same_dir adv arg.
+func (a AdvArgs) GetSetNameSameDir() (string, bool) {
+	return a.Lookup(KeySetNameSameDir)
+}
+
+// GetSlot returns the slot adv arg.
+func (a AdvArgs) GetSlot() (string, bool) {
+	return a.Lookup(KeySlot)
+}
+
+// GetRepeat returns the repeat adv arg.
+func (a AdvArgs) GetRepeat() (string, bool) {
+	return a.Lookup(KeyRepeat)
+}
+
+// GetName returns the name adv arg.
+func (a AdvArgs) GetName() (string, bool) {
+	return a.Lookup(KeyName)
+}
+
+// GetPreNotice returns the pre_notice adv arg.
+func (a AdvArgs) GetPreNotice() (string, bool) {
+	return a.Lookup(KeyPreNotice)
+}
+
+// GetHidden returns the hidden adv arg.
+func (a AdvArgs) GetHidden() (string, bool) {
+	return a.Lookup(KeyHidden)
+}
+
+// GetMaxLen returns the max_len adv arg, see TruncateDisplay.
+func (a AdvArgs) GetMaxLen() (string, bool) {
+	return a.Lookup(KeyMaxLen)
 }
 
 func (a AdvArgs) IsEmpty() bool {
 	return len(a.raw) == 0
 }
 
-func (a AdvArgs) Range(fn func(key Key, value string) bool) {
-	for k, v := range a.raw {
-		if !fn(Key(k), v) {
-			return
+// All returns an iterator over the adv args in OrderedKeys order, so parsed
+// adv args come in the order they were written and those from NewAdvArgs
+// sorted by key:
+//
+//	for k, v := range cmd.AdvArgs.All() {
+//		...
+//	}
+func (a AdvArgs) All() iter.Seq2[Key, string] {
+	return func(yield func(Key, string) bool) {
+		for _, k := range a.OrderedKeys() {
+			if !yield(k, a.raw[string(k)]) {
+				return
+			}
 		}
 	}
 }
 
+// Range calls fn for each adv arg in OrderedKeys order until fn returns false.
+// It is the same as ranging over All.
+func (a AdvArgs) Range(fn func(key Key, value string) bool) {
+	a.All()(fn)
+}
+
+// earlierValues returns the values of a repeated key before its last one.
+func (a AdvArgs) earlierValues(key Key) []string {
+	values := a.repeats[string(key)]
+	if len(values) < 2 {
+		return nil
+	}
+	return values[:len(values)-1]
+}
+
+// Raw returns a copy of the adv args as a map, holding the last value of
+// repeated keys. Modifying it does not change a; use With and Without for
+// that. It is nil if a is.
 func (a AdvArgs) Raw() map[string]string {
-	return a.raw
+	return maps.Clone(a.raw)
+}
+
+// IsZero reports whether there are no adv args, so the omitzero JSON option
+// leaves them out.
+func (a AdvArgs) IsZero() bool {
+	return a.IsEmpty()
 }
 
+// MarshalJSON writes the adv args as a JSON object with keys in OrderedKeys
+// order. Expressions in values are written as [[...]], see
+// DetokenizeExpressions. A repeated key is written once with its last value,
+// as Get returns, so GetAll gives a single value after a round trip.
 func (a AdvArgs) MarshalJSON() ([]byte, error) {
 	if a.raw == nil {
 		return []byte("null"), nil
 	}
-	b, err := json.Marshal(a.raw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal AdvArgs: %w", err)
+	var b bytes.Buffer
+	_ = b.WriteByte('{')
+	for i, k := range a.OrderedKeys() {
+		if i > 0 {
+			_ = b.WriteByte(',')
+		}
+		key, err := json.Marshal(string(k))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal AdvArgs: %w", err)
+		}
+		value, err := json.Marshal(DetokenizeExpressions(a.raw[string(k)]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal AdvArgs: %w", err)
+		}
+		_, _ = b.Write(key)
+		_ = b.WriteByte(':')
+		_, _ = b.Write(value)
 	}
-	return b, nil
+	_ = b.WriteByte('}')
+	return b.Bytes(), nil
 }
 
+// UnmarshalJSON reads a JSON object of string values, keeping the order of
+// its keys. Expressions in values are read from [[...]] syntax, see
+// TokenizeExpressions. Numbers and bools are accepted as the text they are
+// written as, so 5 reads as "5" and true as "true", and null reads as an
+// empty value. An object or array value is an ErrInvalidAdvArgValue error
+// naming the key.
 func (a *AdvArgs) UnmarshalJSON(data []byte) error {
-	if err := json.Unmarshal(data, &a.raw); err != nil {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
 		return fmt.Errorf("failed to unmarshal AdvArgs: %w", err)
 	}
+	var raw map[string]string
+	if values != nil {
+		raw = make(map[string]string, len(values))
+	}
+	for k, v := range values {
+		value, err := jsonAdvArgValue(v)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal AdvArgs: adv arg %q: %w", k, err)
+		}
+		tokenized, err := TokenizeExpressions(value)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal AdvArgs: adv arg %q: %w", k, err)
+		}
+		raw[k] = tokenized
+	}
+	keys, err := jsonObjectKeys(data)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal AdvArgs: %w", err)
+	}
+	*a = AdvArgs{raw: raw, keys: keys}
 	return nil
 }
 
+// jsonAdvArgValue returns the text of a JSON adv arg value, see
+// AdvArgs.UnmarshalJSON.
+func jsonAdvArgValue(v json.RawMessage) (string, error) {
+	switch v[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			return "", fmt.Errorf("failed to unmarshal string: %w", err)
+		}
+		return s, nil
+	case 'n':
+		return "", nil
+	case '{':
+		return "", fmt.Errorf("%w: got a JSON object, want a string, number or bool", ErrInvalidAdvArgValue)
+	case '[':
+		return "", fmt.Errorf("%w: got a JSON array, want a string, number or bool", ErrInvalidAdvArgValue)
+	default:
+		// a number, true or false, already validated by json.Unmarshal
+		return string(v), nil
+	}
+}
+
+// jsonObjectKeys returns the distinct keys of a JSON object in the order
+// they first appear, or nil for JSON null.
+func jsonObjectKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok == nil {
+		return nil, err //nolint:wrapcheck // wrapped by the caller
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err //nolint:wrapcheck // wrapped by the caller
+		}
+		key, _ := tok.(string)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+		var value json.RawMessage
+		if decodeErr := dec.Decode(&value); decodeErr != nil {
+			return nil, decodeErr //nolint:wrapcheck // wrapped by the caller
+		}
+	}
+	return keys, nil
+}
+
+// Command is a single parsed ZapScript command. JSON field names are
+// camelCase like the rest of the package's JSON types; empty args and adv
+// args are omitted. In JSON, expressions in args and adv args are written as
+// [[...]] instead of expression tokens, see DetokenizeExpressions.
 type Command struct {
-	AdvArgs AdvArgs
-	Name    string
-	Args    []string
+	AdvArgs AdvArgs `json:"advArgs,omitzero"`
+	Name    string  `json:"name"`
+	// Raw is the text the command was parsed from, without its || separator
+	// and with escape sequences unresolved. It is empty for commands that
+	// were not parsed from ZapScript text, such as those from a JSON script.
+	Raw  string   `json:"-"`
+	Args []string `json:"args,omitempty"`
+	// ArgStyles records how each of Args was quoted when parsed with
+	// Options.KeepStyle so String can reproduce it. Args without an entry use
+	// QuoteStyleAuto.
+	ArgStyles []QuoteStyle `json:"-"`
+	// Span is where Raw is in the input, in runes.
+	Span Span `json:"-"`
+}
+
+// jsonCommand has Command's JSON fields without its methods.
+type jsonCommand Command
+
+// MarshalJSON implements json.Marshaler, writing expressions as [[...]].
+func (c Command) MarshalJSON() ([]byte, error) {
+	out := jsonCommand(c)
+	if c.Args != nil {
+		out.Args = make([]string, len(c.Args))
+		for i, arg := range c.Args {
+			out.Args[i] = DetokenizeExpressions(arg)
+		}
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Command: %w", err)
+	}
+	return b, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading expressions from
+// [[...]] syntax. It returns an error wrapping ErrUnmatchedExpression if an
+// expression is not closed.
+func (c *Command) UnmarshalJSON(data []byte) error {
+	var in jsonCommand
+	if err := json.Unmarshal(data, &in); err != nil {
+		return fmt.Errorf("failed to unmarshal Command: %w", err)
+	}
+	for i, arg := range in.Args {
+		tokenized, err := TokenizeExpressions(arg)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal Command: arg %d: %w", i, err)
+		}
+		in.Args[i] = tokenized
+	}
+	*c = Command(in)
+	return nil
 }
 
 // argNeedsQuoting returns true if the arg contains characters that require
@@ -111,16 +781,15 @@ func argNeedsQuoting(s string) bool {
 	return false
 }
 
-// escapeArg re-escapes control characters using ZapScript escape sequences
-// and wraps the arg in double quotes.
-func escapeArg(s string) string {
-	var b strings.Builder
-	_, _ = b.WriteRune('"')
+// writeEscaped writes s for use inside an arg quoted with quote, re-escaping
+// the quote, control characters, carets and expression brackets using
+// ZapScript escape sequences.
+func writeEscaped(b *strings.Builder, s string, quote rune) {
 	for _, ch := range s {
 		switch ch {
-		case '"':
+		case quote:
 			_, _ = b.WriteRune(SymEscapeSeq)
-			_, _ = b.WriteRune('"')
+			_, _ = b.WriteRune(quote)
 		case '\n':
 			_, _ = b.WriteRune(SymEscapeSeq)
 			_, _ = b.WriteRune('n')
@@ -140,14 +809,154 @@ func escapeArg(s string) string {
 			_, _ = b.WriteRune(ch)
 		}
 	}
-	_, _ = b.WriteRune('"')
-	return b.String()
+}
+
+// writeValue writes a parsed arg or adv arg value in the given quote style,
+// converting expression tokens back to [[...]] syntax. QuoteStyleAuto and
+// styles that cannot represent the value fall back to double quotes when
+// quoting is needed.
+func writeValue(b *strings.Builder, value string, style QuoteStyle, advArg bool) {
+	parts := SplitArgParts(value)
+
+	switch style {
+	case QuoteStyleJSON:
+		if strings.HasPrefix(value, string(SymJSONStart)) && json.Valid([]byte(value)) {
+			_, _ = b.WriteString(value)
+			return
+		}
+	case QuoteStyleNone:
+		if value != "" {
+			writeUnquoted(b, parts, advArg)
+			return
+		}
+	case QuoteStyleSingle:
+		writeQuoted(b, parts, SymArgSingleQuote)
+		return
+	case QuoteStyleDouble:
+		writeQuoted(b, parts, SymArgDoubleQuote)
+		return
+	case QuoteStyleAuto:
+	}
+
+	quote := value == ""
+	for _, part := range parts {
+		if part.Type == ArgPartTypeString && argNeedsQuoting(part.Value) {
+			quote = true
+		}
+	}
+	if quote {
+		writeQuoted(b, parts, SymArgDoubleQuote)
+	} else {
+		writeUnquoted(b, parts, advArg)
+	}
+}
+
+// writePreferredValue is writeValue for a value with no recorded style,
+// choosing between quotes and escapes by preference.
+func writePreferredValue(b *strings.Builder, value string, style QuoteStyle, advArg bool, pref QuotePreference) {
+	if style != QuoteStyleAuto || pref == QuotePreferQuotes || value == "" {
+		writeValue(b, value, style, advArg)
+		return
+	}
+
+	var escaped strings.Builder
+	writeUnquoted(&escaped, SplitArgParts(value), advArg)
+	if pref == QuotePreferAuto {
+		var quoted strings.Builder
+		writeValue(&quoted, value, style, advArg)
+		if quoted.Len() < escaped.Len() {
+			_, _ = b.WriteString(quoted.String())
+			return
+		}
+	}
+	_, _ = b.WriteString(escaped.String())
+}
+
+func writeExpression(b *strings.Builder, expr string) {
+	_, _ = b.WriteString(string([]rune{SymExpressionStart, SymExpressionStart}))
+	_, _ = b.WriteString(expr)
+	_, _ = b.WriteString(string([]rune{SymExpressionEnd, SymExpressionEnd}))
+}
+
+func writeQuoted(b *strings.Builder, parts []PostArgPart, quote rune) {
+	_, _ = b.WriteRune(quote)
+	for _, part := range parts {
+		if part.Type == ArgPartTypeExpression {
+			writeExpression(b, part.Value)
+		} else {
+			writeEscaped(b, part.Value, quote)
+		}
+	}
+	_, _ = b.WriteRune(quote)
+}
+
+// writeUnquoted writes parts without quotes, escaping only the characters
+// that would otherwise end or change the meaning of the value, so values
+// that were written unquoted are reproduced as they were.
+func writeUnquoted(b *strings.Builder, parts []PostArgPart, advArg bool) {
+	for i, part := range parts {
+		if part.Type == ArgPartTypeExpression {
+			writeExpression(b, part.Value)
+			continue
+		}
+
+		runes := []rune(part.Value)
+		for j, ch := range runes {
+			last := j == len(runes)-1
+			var escape bool
+			switch ch {
+			case '\n', '\r', '\t':
+				_, _ = b.WriteString(escapeControl(ch))
+				continue
+			case SymEscapeSeq:
+				escape = true
+			case SymArgDoubleQuote, SymArgSingleQuote, SymJSONStart:
+				// only special as the first character of a value
+				escape = i == 0 && j == 0
+			case SymCmdSep:
+				// every pipe in a run is escaped, so || reads as ^|^|
+				escape = last || runes[j+1] == SymCmdSep || (j > 0 && runes[j-1] == SymCmdSep)
+			case SymExpressionStart:
+				// a trailing [ would merge with a following expression
+				escape = last || runes[j+1] == SymExpressionStart
+			case SymAdvArgSep:
+				escape = advArg
+			case SymArgSep, SymAdvArgStart:
+				escape = !advArg
+			}
+			if escape {
+				_, _ = b.WriteRune(SymEscapeSeq)
+			}
+			_, _ = b.WriteRune(ch)
+		}
+	}
+}
+
+func escapeControl(ch rune) string {
+	switch ch {
+	case '\n':
+		return string([]rune{SymEscapeSeq, 'n'})
+	case '\r':
+		return string([]rune{SymEscapeSeq, 'r'})
+	default:
+		return string([]rune{SymEscapeSeq, 't'})
+	}
 }
 
 // String returns the canonical ZapScript representation of the command.
 // The output is valid ZapScript that can be re-parsed to produce an
 // equivalent Command.
 func (c Command) String() string {
+	return c.StringWithOptions()
+}
+
+// StringWithOptions is String with opts applied, see StringOptions.
+func (c Command) StringWithOptions(opts ...StringOption) string {
+	var o StringOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var b strings.Builder
 	_, _ = b.WriteString("**")
 	_, _ = b.WriteString(c.Name)
@@ -159,9 +968,27 @@ func (c Command) String() string {
 		case isInputMacroCmd(normalizeCmdName(c.Name)):
 			// Input macro commands concatenate args directly
 			for _, arg := range c.Args {
+				parts, complete := splitArgParts(arg)
+				hasExpr := complete && slices.ContainsFunc(parts, func(p PostArgPart) bool {
+					return p.Type == ArgPartTypeExpression
+				})
 				if len(arg) > 1 && rune(arg[0]) == SymInputMacroExtStart &&
 					rune(arg[len(arg)-1]) == SymInputMacroExtEnd {
-					_, _ = b.WriteString(arg)
+					if !hasExpr {
+						_, _ = b.WriteString(arg)
+						continue
+					}
+					// Expressions inside braces are written back as [[...]]
+					// around the verbatim macro text.
+					for _, part := range parts {
+						if part.Type == ArgPartTypeExpression {
+							writeExpression(&b, part.Value)
+						} else {
+							_, _ = b.WriteString(part.Value)
+						}
+					}
+				} else if hasExpr && len(parts) == 1 {
+					writeExpression(&b, parts[0].Value)
 				} else {
 					for _, ch := range arg {
 						switch ch {
@@ -196,11 +1023,11 @@ func (c Command) String() string {
 				if i > 0 {
 					_, _ = b.WriteRune(SymArgSep)
 				}
-				if arg == "" || argNeedsQuoting(arg) {
-					_, _ = b.WriteString(escapeArg(arg))
-				} else {
-					_, _ = b.WriteString(arg)
+				var style QuoteStyle
+				if i < len(c.ArgStyles) {
+					style = c.ArgStyles[i]
 				}
+				writePreferredValue(&b, arg, style, false, o.QuotePreference)
 			}
 		}
 	}
@@ -208,25 +1035,25 @@ func (c Command) String() string {
 	if !c.AdvArgs.IsEmpty() {
 		_, _ = b.WriteRune(SymAdvArgStart)
 
-		// Collect and sort keys for deterministic output
-		var keys []string
-		c.AdvArgs.Range(func(key Key, _ string) bool {
-			keys = append(keys, string(key))
-			return true
-		})
-		sort.Strings(keys)
-
-		for i, key := range keys {
+		for i, key := range c.AdvArgs.OrderedKeys() {
 			if i > 0 {
 				_, _ = b.WriteRune(SymAdvArgSep)
 			}
-			_, _ = b.WriteString(key)
+			// earlier values of a repeated key have no recorded style
+			for _, value := range c.AdvArgs.earlierValues(key) {
+				_, _ = b.WriteString(string(key))
+				_, _ = b.WriteRune(SymAdvArgEq)
+				writePreferredValue(&b, value, QuoteStyleAuto, true, o.QuotePreference)
+				_, _ = b.WriteRune(SymAdvArgSep)
+			}
+			_, _ = b.WriteString(string(key))
 			_, _ = b.WriteRune(SymAdvArgEq)
-			value := c.AdvArgs.Get(Key(key))
-			if argNeedsQuoting(value) {
-				_, _ = b.WriteString(escapeArg(value))
-			} else {
+			value := c.AdvArgs.Get(key)
+			if c.AdvArgs.IsJSON(key) {
+				// JSON values are written verbatim so they re-parse as JSON
 				_, _ = b.WriteString(value)
+			} else {
+				writePreferredValue(&b, value, c.AdvArgs.Style(key), true, o.QuotePreference)
 			}
 		}
 	}
@@ -234,11 +1061,118 @@ func (c Command) String() string {
 	return b.String()
 }
 
+// String returns the script as ZapScript text. Commands are joined with ||
+// and traits are written first, as #key=value shorthand where possible and
+// otherwise using the **traits:{...} syntax. Parsing the result produces an
+// equivalent Script. Warnings are not included.
+func (s Script) String() string {
+	return s.StringWithOptions()
+}
+
+// StringWithOptions is String with opts applied to each command, see
+// StringOptions.
+func (s Script) StringWithOptions(opts ...StringOption) string {
+	var b strings.Builder
+
+	if len(s.Traits) > 0 {
+		writeTraits(&b, s.Traits, " ")
+	}
+
+	for _, cmd := range s.Cmds {
+		if b.Len() > 0 {
+			_, _ = b.WriteString(string([]rune{SymCmdSep, SymCmdSep}))
+		}
+		_, _ = b.WriteString(cmd.StringWithOptions(opts...))
+	}
+
+	return b.String()
+}
+
+// MarshalText implements encoding.TextMarshaler using String, so a Script
+// field is stored as ZapScript text by text encoders such as TOML libraries
+// and encoding/csv. encoding/json uses MarshalJSON instead. It refuses adv
+// arg keys that String would write but the parser could not read back, such
+// as those set with NewAdvArgs, with an ErrInvalidAdvArgName error, see
+// NewAdvArgsStrict.
+func (s Script) MarshalText() ([]byte, error) {
+	for i, cmd := range s.Cmds {
+		if err := cmd.AdvArgs.checkKeys(); err != nil {
+			return nil, fmt.Errorf("failed to marshal script: command %d (%s): %w", i+1, cmd.Name, err)
+		}
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler by parsing text with the
+// default options. Empty text decodes to the zero Script so that a zero value
+// round-trips. Parse errors wrap the package's sentinel errors.
+func (s *Script) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = Script{}
+		return nil
+	}
+	script, err := Parse(string(text))
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal script: %w", err)
+	}
+	*s = script
+	return nil
+}
+
+// jsonScriptFields has Script's JSON fields without its methods.
+type jsonScriptFields Script
+
+// MarshalJSON implements json.Marshaler, writing the script as a JSON object
+// in the same shape as CanonicalJSON, with warnings included when there are
+// any. Expressions in args and trait values are written as [[...]]. It takes
+// precedence over MarshalText for encoding/json.
+func (s Script) MarshalJSON() ([]byte, error) {
+	out := jsonScriptFields(s)
+	out.Traits = detokenizeTraits(s.Traits)
+	if out.Cmds == nil {
+		out.Cmds = []Command{}
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal script: %w", err)
+	}
+	return b, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading the JSON object written
+// by MarshalJSON. Commands are decoded with Command.UnmarshalJSON and trait
+// values read expressions from [[...]] syntax the same way. It returns an
+// error wrapping ErrUnmatchedExpression if an expression is not closed.
+func (s *Script) UnmarshalJSON(data []byte) error {
+	var in jsonScriptFields
+	if err := json.Unmarshal(data, &in); err != nil {
+		return fmt.Errorf("failed to unmarshal script: %w", err)
+	}
+	traits, err := tokenizeTraits(in.Traits)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal script: %w", err)
+	}
+	in.Traits = traits
+	*s = Script(in)
+	return nil
+}
+
+// Script is a parsed ZapScript. encoding/json writes it as a JSON object,
+// see MarshalJSON, and text encoders write it as ZapScript text, see
+// MarshalText.
 type Script struct {
 	Traits map[string]any `json:"traits,omitempty"`
 	Cmds   []Command      `json:"cmds"`
+	// TraitsSpans are where the #key=value segments and **traits commands
+	// that set Traits are in the input, in order.
+	TraitsSpans []Span `json:"-"`
+	// Warnings are problems the parser recovered from, such as a repeated
+	// adv arg key where the last value is kept, and hints about likely
+	// mistakes, such as WarningLonePipe.
+	Warnings []Warning `json:"warnings,omitempty"`
 }
 
+// PostArgPartType is the kind of segment returned by SplitArgParts.
 type PostArgPartType int
 
 const (
@@ -247,45 +1181,297 @@ const (
 	ArgPartTypeExpression
 )
 
+// PostArgPart is a literal string or expression segment of a parsed value.
 type PostArgPart struct {
 	Value string
 	Type  PostArgPartType
 }
 
 type mediaTitleParseResult struct {
-	advArgs    map[string]string
+	advArgs    AdvArgs
 	rawContent string
 	valid      bool
 }
 
+// ScriptReader parses ZapScript from its input. It reads its input as it
+// goes, so it is not safe for concurrent use; Parse and Eval are safe to
+// call from any goroutine. Once read, it can be pointed at new input with
+// Reset.
 type ScriptReader struct {
-	r   *bufio.Reader
-	pos int64
+	// input is the text being parsed, used to slice Command.Raw. It is
+	// empty when reading from an io.Reader, see segment.
+	input string
+	src   *strings.Reader
+	r     *bufio.Reader
+	// segment holds the bytes read since segmentStart when streaming, so
+	// Command.Raw can be sliced without keeping the whole input.
+	segment []byte
+	opts    Options
+	pos     int64
+	line    int64
+	col     int64
+	// off is the number of input bytes consumed.
+	off int
+	// segmentStart is the byte offset segment starts at.
+	segmentStart int
+	// prevCol, last and lastSize let unread restore the column and offset
+	// after a newline.
+	prevCol  int64
+	last     rune
+	lastSize int
+	// streaming is set for parsers created by NewParserFromReader.
+	streaming bool
+	// warnings collects recoverable problems for Script.Warnings.
+	warnings []Warning
+	// cmdIndex is the index of the command being parsed, or TraitsCmdIndex
+	// within a traits segment. It is only used to annotate warnings.
+	cmdIndex int
+	// argStyles holds the quote style of each arg from the last parseArgs
+	// call when Options.KeepStyle is set.
+	argStyles []QuoteStyle
+	// cmdEnd and cmdEndPos are the byte and rune offsets of the || that
+	// ended the current command, or -1 if it has not ended at a separator.
+	cmdEnd    int
+	cmdEndPos int64
+	// state is kept between NextCommand calls.
+	state parseState
+	// splitSep is the separator SplitEscaped splits on, or 0 when parsing a
+	// script. When set, parseArgs splits on it and reads ? and || as text.
+	splitSep rune
 }
 
 func NewParser(value string) *ScriptReader {
-	return &ScriptReader{
-		r: bufio.NewReader(bytes.NewReader([]byte(value))),
+	return NewParserWithOptions(value)
+}
+
+// NewParserWithOptions creates a parser for value with the given options
+// applied on top of the defaults. A leading UTF-8 byte order mark is ignored.
+func NewParserWithOptions(value string, opts ...Option) *ScriptReader {
+	sr := &ScriptReader{}
+	sr.reset(value, opts...)
+	return sr
+}
+
+// reset prepares sr to parse value as if newly created, reusing its read
+// buffer. Slices handed out in a previous Script are not reused.
+func (sr *ScriptReader) reset(value string, opts ...Option) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	value = strings.TrimPrefix(value, utf8BOM)
+
+	src, r := sr.src, sr.r
+	if src == nil {
+		src = strings.NewReader(value)
+	} else {
+		src.Reset(value)
+	}
+	if r == nil {
+		r = bufio.NewReader(src)
+	} else {
+		r.Reset(src)
+	}
+	*sr = ScriptReader{
+		input:     value,
+		src:       src,
+		r:         r,
+		opts:      o,
+		line:      1,
+		cmdEnd:    -1,
+		cmdEndPos: -1,
+	}
+}
+
+// NewParserFromReader creates a parser that reads the script from r as it
+// parses, with the given options applied on top of the defaults, so a large
+// file does not have to be read into a string first. Only the command being
+// parsed is held in memory, for Command.Raw. MaxInputRunes still applies and
+// stops reading once exceeded. A leading UTF-8 byte order mark is ignored
+// and errors from r are returned wrapped.
+func NewParserFromReader(r io.Reader, opts ...Option) *ScriptReader {
+	sr := &ScriptReader{}
+	sr.reset("", opts...)
+	sr.src = nil
+	sr.r = bufio.NewReader(r)
+	sr.streaming = true
+	if bom, err := sr.r.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		_, _ = sr.r.Discard(len(utf8BOM))
+	}
+	return sr
+}
+
+// markSegment starts a new command or traits segment at the current offset,
+// dropping the bytes of earlier segments when streaming.
+func (sr *ScriptReader) markSegment() {
+	sr.segmentStart = sr.off
+	sr.segment = sr.segment[:0]
+}
+
+// raw returns the input between byte offsets start and end, which must be
+// within the current segment when streaming.
+func (sr *ScriptReader) raw(start, end int) string {
+	if sr.streaming {
+		return string(sr.segment[start-sr.segmentStart : end-sr.segmentStart])
 	}
+	return sr.input[start:end]
+}
+
+// Reset prepares sr to parse input as if newly created with the options it
+// already has, reusing its read buffer, so a parser can be kept for many
+// scripts, e.g. in a sync.Pool. A parser created by NewParserFromReader
+// reads input instead of its reader afterwards. Slices in Scripts returned
+// before are not reused.
+func (sr *ScriptReader) Reset(input string) {
+	opts := sr.opts
+	sr.reset(input)
+	sr.opts = opts
+}
+
+var parserPool = sync.Pool{
+	New: func() any {
+		return &ScriptReader{}
+	},
+}
+
+// Parse parses value as a script with opts applied on top of the defaults.
+// It is safe for concurrent use and reuses parsers internally, so it
+// allocates less than NewParserWithOptions(value, opts...).ParseScript().
+func Parse(value string, opts ...Option) (Script, error) {
+	sr, _ := parserPool.Get().(*ScriptReader)
+	sr.reset(value, opts...)
+	script, err := sr.ParseScript()
+
+	// drop references to the input and options before pooling
+	sr.reset("")
+	parserPool.Put(sr)
+	return script, err
+}
+
+// Pos returns the number of runes consumed so far. After an error it is the
+// 1-based position of the rune being processed when parsing stopped.
+func (sr *ScriptReader) Pos() int64 {
+	return sr.pos
+}
+
+// Line returns the 1-based line of the last consumed rune. Only '\n' starts
+// a new line.
+func (sr *ScriptReader) Line() int64 {
+	return sr.line
+}
+
+// Column returns the 1-based column of the last consumed rune on the current
+// line, or 0 if nothing has been consumed on it yet.
+func (sr *ScriptReader) Column() int64 {
+	return sr.col
+}
+
+// offset returns the number of input bytes consumed so far.
+func (sr *ScriptReader) offset() int {
+	return sr.off
 }
 
+// read returns the next rune. With Options.MultiLine, a ^ that ends a line
+// is a line continuation and is skipped along with the line break.
 func (sr *ScriptReader) read() (rune, error) {
-	ch, _, err := sr.r.ReadRune()
+	ch, err := sr.readRune()
+	if err != nil || ch != SymEscapeSeq || !sr.opts.MultiLine {
+		return ch, err
+	}
+	n, err := sr.lineBreakAhead()
+	if err != nil || n == 0 {
+		return ch, err
+	}
+	for range n {
+		if _, readErr := sr.readRune(); readErr != nil {
+			return eof, readErr
+		}
+	}
+	return sr.read()
+}
+
+// lineBreakAhead returns the number of runes in the line break, \n or \r\n,
+// that the next input starts with, or 0 if there is none.
+func (sr *ScriptReader) lineBreakAhead() (int, error) {
+	next, err := sr.peek()
+	if err != nil {
+		return 0, err
+	}
+	switch next {
+	case '\n':
+		return 1, nil
+	case '\r':
+		if b, peekErr := sr.r.Peek(2); peekErr == nil && b[1] == '\n' {
+			return 2, nil
+		}
+	}
+	return 0, nil
+}
+
+// readRune returns the next rune without handling line continuations, for
+// the rune following a ^, which is escaped rather than continuing a line.
+func (sr *ScriptReader) readRune() (rune, error) {
+	ch, size, err := sr.r.ReadRune()
 	if errors.Is(err, io.EOF) {
 		return eof, nil
 	} else if err != nil {
 		return eof, fmt.Errorf("failed to read rune: %w", err)
 	}
 	sr.pos++
+	sr.off += size
+	sr.last, sr.lastSize = ch, size
+	if sr.streaming {
+		sr.segment = utf8.AppendRune(sr.segment, ch)
+	}
+	if ch == '\n' {
+		sr.line++
+		sr.prevCol = sr.col
+		sr.col = 0
+	} else {
+		sr.col++
+	}
+	if limit := sr.opts.MaxInputRunes; limit > 0 && sr.pos > int64(limit) {
+		return eof, fmt.Errorf("%w: limit is %d runes", ErrScriptTooLarge, limit)
+	}
+	if encErr := checkEncoding(ch, size, sr.pos); encErr != nil {
+		return eof, encErr
+	}
+	if sr.opts.DisableExpressions && isExprToken(ch) {
+		return eof, fmt.Errorf("%w: expression token at %d", ErrExpressionsDisabled, sr.pos)
+	}
 	return ch, nil
 }
 
+// checkEncoding rejects invalid UTF-8 and control characters other than
+// whitespace, which only appear in scripts read from corrupt payloads. A
+// literal U+FFFD is allowed, only a 1 byte RuneError marks invalid input.
+func checkEncoding(ch rune, size int, pos int64) error {
+	switch {
+	case ch == utf8.RuneError && size == 1:
+		return fmt.Errorf("%w: invalid UTF-8 at position %d", ErrInvalidEncoding, pos)
+	case ch < 0x20 && !isWhitespace(ch):
+		return fmt.Errorf("%w: control character %U at position %d", ErrInvalidEncoding, ch, pos)
+	default:
+		return nil
+	}
+}
+
 func (sr *ScriptReader) unread() error {
 	err := sr.r.UnreadRune()
 	if err != nil {
 		return fmt.Errorf("failed to unread rune: %w", err)
 	}
 	sr.pos--
+	sr.off -= sr.lastSize
+	if sr.streaming {
+		sr.segment = sr.segment[:len(sr.segment)-utf8.RuneLen(sr.last)]
+	}
+	if sr.last == '\n' {
+		sr.line--
+		sr.col = sr.prevCol
+	} else {
+		sr.col--
+	}
 	return nil
 }
 
@@ -293,9 +1479,9 @@ func (sr *ScriptReader) peek() (rune, error) {
 	for peekBytes := 4; peekBytes > 0; peekBytes-- {
 		b, err := sr.r.Peek(peekBytes)
 		if err == nil {
-			r, _ := utf8.DecodeRune(b)
-			if r == utf8.RuneError {
-				return r, errors.New("rune error")
+			r, size := utf8.DecodeRune(b)
+			if encErr := checkEncoding(r, size, sr.pos+1); encErr != nil {
+				return eof, encErr
 			}
 			return r, nil
 		}
@@ -312,10 +1498,14 @@ func (sr *ScriptReader) skip() error {
 }
 
 func (sr *ScriptReader) checkEndOfCmd(ch rune) (bool, error) {
+	if sr.opts.MultiLine && (ch == '\n' || ch == '\r') {
+		return sr.checkEndOfLine(ch)
+	}
 	if ch != SymCmdSep {
 		return false, nil
 	}
 
+	sepStart, sepStartPos := sr.offset()-1, sr.pos-1
 	next, err := sr.peek()
 	if err != nil {
 		return false, err
@@ -323,40 +1513,133 @@ func (sr *ScriptReader) checkEndOfCmd(ch rune) (bool, error) {
 
 	switch next {
 	case eof:
+		sr.cmdEnd, sr.cmdEndPos = sepStart, sepStartPos
 		return true, nil
 	case SymCmdSep:
 		err := sr.skip()
 		if err != nil {
 			return false, err
 		}
+		sr.cmdEnd, sr.cmdEndPos = sepStart, sepStartPos
 		return true, nil
 	default:
 		return false, nil
 	}
 }
 
+// checkEndOfLine ends the command at a line break in multi-line mode. A \r
+// only ends it as part of \r\n.
+func (sr *ScriptReader) checkEndOfLine(ch rune) (bool, error) {
+	breakStart, breakStartPos := sr.offset()-1, sr.pos-1
+	if ch == '\r' {
+		next, err := sr.peek()
+		if err != nil {
+			return false, err
+		}
+		if next != '\n' {
+			return false, nil
+		}
+		if skipErr := sr.skip(); skipErr != nil {
+			return false, skipErr
+		}
+	}
+	sr.cmdEnd, sr.cmdEndPos = breakStart, breakStartPos
+	return true, nil
+}
+
 func (sr *ScriptReader) parseEscapeSeq() (string, error) {
-	ch, err := sr.read()
+	value, _, err := sr.parseEscapeSeqRaw()
+	return value, err
+}
+
+// parseEscapeSeqRaw reads the escape sequence following a ^, returning the
+// text it produces and the input it was read from. Both are empty at EOF.
+func (sr *ScriptReader) parseEscapeSeqRaw() (value, raw string, err error) {
+	ch, err := sr.readRune()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	switch ch {
 	case eof:
-		return "", nil
+		return "", "", nil
 	case 'n':
-		return "\n", nil
+		return "\n", string(ch), nil
 	case 'r':
-		return "\r", nil
+		return "\r", string(ch), nil
 	case 't':
-		return "\t", nil
-	case SymEscapeSeq:
-		return string(SymEscapeSeq), nil
-	case SymArgDoubleQuote:
-		return string(SymArgDoubleQuote), nil
-	case SymArgSingleQuote:
-		return string(SymArgSingleQuote), nil
+		return "\t", string(ch), nil
+	case SymUnicodeEscape:
+		return sr.parseUnicodeEscape()
+	default:
+		return string(ch), string(ch), nil
+	}
+}
+
+// maxUnicodeEscapeDigits is the most hex digits a ^u{...} escape may have.
+const maxUnicodeEscapeDigits = 6
+
+// parseUnicodeEscape reads the {XXXX} part of a ^u{XXXX} escape, which
+// holds 1 to 6 hex digits naming any Unicode code point except surrogates.
+func (sr *ScriptReader) parseUnicodeEscape() (value, raw string, err error) {
+	rawBuf := []rune{SymUnicodeEscape}
+	invalid := func(reason string) (string, string, error) {
+		return "", string(rawBuf), fmt.Errorf("%w: ^%s: %s", ErrInvalidUnicodeEscape, string(rawBuf), reason)
+	}
+
+	ch, err := sr.readRune()
+	if err != nil {
+		return "", string(rawBuf), err
+	}
+	if ch != SymUnicodeEscapeStart {
+		return invalid("expected {")
+	}
+	rawBuf = append(rawBuf, ch)
+
+	var code rune
+	digits := 0
+	for {
+		ch, err = sr.readRune()
+		if err != nil {
+			return "", string(rawBuf), err
+		} else if ch == eof {
+			return invalid("missing }")
+		}
+		rawBuf = append(rawBuf, ch)
+		if ch == SymUnicodeEscapeEnd {
+			break
+		}
+		digit, ok := hexDigit(ch)
+		if !ok {
+			return invalid("invalid hex digit")
+		}
+		digits++
+		if digits > maxUnicodeEscapeDigits {
+			return invalid("too many hex digits")
+		}
+		code = code<<4 | digit
+	}
+
+	switch {
+	case digits == 0:
+		return invalid("no hex digits")
+	case code >= 0xD800 && code <= 0xDFFF:
+		return invalid("surrogate code point")
+	case code > unicode.MaxRune:
+		return invalid("code point out of range")
+	}
+	return string(code), string(rawBuf), nil
+}
+
+func hexDigit(ch rune) (rune, bool) {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return ch - '0', true
+	case ch >= 'a' && ch <= 'f':
+		return ch - 'a' + 10, true
+	case ch >= 'A' && ch <= 'F':
+		return ch - 'A' + 10, true
 	default:
-		return string(ch), nil
+		return 0, false
 	}
 }
 
@@ -0,0 +1,530 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import "strings"
+
+// TokenKind is the lexical category of a Token.
+type TokenKind int
+
+const (
+	// TokenEOF marks the end of the input, with an empty span at its end.
+	TokenEOF TokenKind = iota
+	// TokenCmdStart is the ** that starts a command.
+	TokenCmdStart
+	// TokenCmdName is a command name.
+	TokenCmdName
+	// TokenArgStart is the : between a command name and its args.
+	TokenArgStart
+	// TokenArgSep is a , between args.
+	TokenArgSep
+	// TokenText is unquoted text in an arg, adv arg value, media title or
+	// trait value, including auto-launch content and whitespace within it.
+	TokenText
+	// TokenEscape is an escape sequence in unquoted text, such as ^, or
+	// ^u{2192}, or \{ in an input macro.
+	TokenEscape
+	// TokenQuoted is a quoted value, including its quotes and any escapes
+	// and expressions inside it.
+	TokenQuoted
+	// TokenJSON is a {...} JSON value, or a whole JSON script.
+	TokenJSON
+	// TokenExpression is a [[...]] expression, including its brackets.
+	TokenExpression
+	// TokenAdvArgStart is the ? that starts adv args.
+	TokenAdvArgStart
+	// TokenAdvArgKey is an adv arg name.
+	TokenAdvArgKey
+	// TokenAdvArgSep is an & between adv args.
+	TokenAdvArgSep
+	// TokenEquals is the = between an adv arg or trait key and its value.
+	TokenEquals
+	// TokenMediaTitleStart is the @ that starts a media title.
+	TokenMediaTitleStart
+	// TokenTraitStart is the # that starts a trait.
+	TokenTraitStart
+	// TokenTraitKey is a trait name.
+	TokenTraitKey
+	// TokenComment is a whole **# comment segment.
+	TokenComment
+	// TokenCmdSep is the || between commands, or a | ending the input.
+	TokenCmdSep
+)
+
+// Token is a lexical token of a script. Value is the source text of the
+// token, with any quotes and escapes, and Start and End are its rune offsets
+// as in Span.
+type Token struct {
+	Value string
+	Kind  TokenKind
+	Start int64
+	End   int64
+}
+
+// Scanner splits a script into tokens for syntax highlighters and linters,
+// following the parser's lexical rules. It does not check what it reads, so
+// any input can be scanned: an unterminated quote, expression or JSON value
+// runs to the end of the input, and a segment the parser would read as
+// auto-launch content, such as **bad name, is scanned as text. Whitespace
+// between tokens is skipped. Parser options, such as MultiLine, are not
+// applied.
+type Scanner struct {
+	input []rune
+	// tokens holds the scanned tokens of the current segment not yet
+	// returned by Next.
+	tokens []Token
+	pos    int
+	// valueStart is the offset the value being scanned starts at, for
+	// quotes and JSON, which are only special at the start of a value.
+	valueStart int
+}
+
+// NewScanner returns a Scanner for input. A leading UTF-8 byte order mark
+// is skipped and not counted in offsets.
+func NewScanner(input string) *Scanner {
+	return &Scanner{input: []rune(strings.TrimPrefix(input, utf8BOM))}
+}
+
+// Tokenize returns the tokens of input, not including the final TokenEOF.
+func Tokenize(input string) []Token {
+	s := NewScanner(input)
+	var tokens []Token
+	for tok := s.Next(); tok.Kind != TokenEOF; tok = s.Next() {
+		tokens = append(tokens, tok)
+	}
+	return tokens
+}
+
+// Next returns the next token, or a TokenEOF token at the end of the input.
+func (s *Scanner) Next() Token {
+	if len(s.tokens) == 0 {
+		s.scanSegment()
+	}
+	if len(s.tokens) == 0 {
+		end := int64(len(s.input))
+		return Token{Kind: TokenEOF, Start: end, End: end}
+	}
+	tok := s.tokens[0]
+	s.tokens = s.tokens[1:]
+	return tok
+}
+
+// valueMode is how scanValue treats the special characters of the kind of
+// value being scanned.
+type valueMode struct {
+	// argSep makes , separate args.
+	argSep bool
+	// advArgSep makes & end the value.
+	advArgSep bool
+	// advArgs lets ? start adv args.
+	advArgs bool
+	// quotes lets a value start with a quote or JSON object.
+	quotes bool
+	// inputMacro reads \ escapes and ^| instead of ^ escapes.
+	inputMacro bool
+	// trait ends the value at whitespace and # and reads [...] arrays.
+	trait bool
+}
+
+var (
+	argsMode       = valueMode{argSep: true, advArgs: true, quotes: true}
+	autoLaunchMode = valueMode{advArgs: true, quotes: true}
+	titleMode      = valueMode{advArgs: true}
+	advArgMode     = valueMode{advArgSep: true, quotes: true}
+	inputMacroMode = valueMode{advArgs: true, inputMacro: true}
+	traitMode      = valueMode{quotes: true, trait: true}
+)
+
+func (s *Scanner) at(i int) rune {
+	if i < len(s.input) {
+		return s.input[i]
+	}
+	return eof
+}
+
+func (s *Scanner) more() bool {
+	return s.pos < len(s.input)
+}
+
+// sepAt reports whether a command separator starts at i: || or a | ending
+// the input.
+func (s *Scanner) sepAt(i int) bool {
+	return s.at(i) == SymCmdSep && (i+1 == len(s.input) || s.input[i+1] == SymCmdSep)
+}
+
+// segmentEndAt reports whether the segment ends at i.
+func (s *Scanner) segmentEndAt(i int) bool {
+	return i >= len(s.input) || s.sepAt(i)
+}
+
+func (s *Scanner) emit(kind TokenKind, start int) {
+	s.tokens = append(s.tokens, Token{
+		Kind:  kind,
+		Value: string(s.input[start:s.pos]),
+		Start: int64(start),
+		End:   int64(s.pos),
+	})
+}
+
+// emitNext emits the next n runes as a token.
+func (s *Scanner) emitNext(kind TokenKind, n int) {
+	start := s.pos
+	s.pos = min(s.pos+n, len(s.input))
+	s.emit(kind, start)
+}
+
+func (s *Scanner) skipWhitespace() {
+	for s.more() && isWhitespace(s.input[s.pos]) {
+		s.pos++
+	}
+}
+
+// scanSegment scans the next command, traits or comment segment and the
+// separator after it. It scans nothing at the end of the input.
+func (s *Scanner) scanSegment() {
+	s.skipWhitespace()
+	if !s.more() {
+		return
+	}
+
+	switch ch := s.input[s.pos]; {
+	case s.pos == 0 && ch == SymJSONStart:
+		s.emitNext(TokenJSON, len(s.input))
+	case ch == SymCmdStart && s.at(s.pos+1) == SymCmdStart && s.at(s.pos+2) == SymCommentStart:
+		start := s.pos
+		for !s.segmentEndAt(s.pos) {
+			s.pos++
+		}
+		s.emit(TokenComment, start)
+	case ch == SymCmdStart && s.at(s.pos+1) == SymCmdStart:
+		s.scanCommand()
+	case ch == SymMediaTitleStart:
+		s.emitNext(TokenMediaTitleStart, 1)
+		s.scanValues(titleMode)
+	case ch == SymTraitsStart:
+		s.scanTraits()
+	case s.sepAt(s.pos):
+		// an empty segment, only the separator is scanned
+	default:
+		s.scanValues(autoLaunchMode)
+	}
+
+	if s.sepAt(s.pos) {
+		s.emitNext(TokenCmdSep, 2)
+	}
+}
+
+// scanCommand scans a segment starting with **, which is auto-launch
+// content if the command name is invalid.
+func (s *Scanner) scanCommand() {
+	nameStart := s.pos + 2
+	nameEnd := nameStart
+	if isCmdNameStart(s.at(nameEnd)) {
+		nameEnd++
+		for isCmdName(s.at(nameEnd)) {
+			nameEnd++
+		}
+	}
+	if next := s.at(nameEnd); !s.segmentEndAt(nameEnd) && next != SymArgStart && next != SymAdvArgStart {
+		s.scanValues(autoLaunchMode)
+		return
+	}
+
+	s.emitNext(TokenCmdStart, 2)
+	if nameEnd > nameStart {
+		s.emitNext(TokenCmdName, nameEnd-nameStart)
+	}
+	name := normalizeCmdName(string(s.input[nameStart:nameEnd]))
+
+	switch s.at(s.pos) {
+	case SymArgStart:
+		s.emitNext(TokenArgStart, 1)
+		switch {
+		case isInputRawCmd(name):
+			s.scanRawArg()
+		case isInputMacroCmd(name):
+			s.scanValues(inputMacroMode)
+		default:
+			s.scanValues(argsMode)
+		}
+	case SymAdvArgStart:
+		s.scanAdvArgs()
+	}
+}
+
+// scanRawArg scans the arg of a raw input command, which is all text.
+func (s *Scanner) scanRawArg() {
+	start := s.pos
+	for !s.segmentEndAt(s.pos) {
+		s.pos++
+	}
+	if s.pos > start {
+		s.emit(TokenText, start)
+	}
+}
+
+// scanValues scans args or a single value up to the end of the segment, or
+// up to the & or whitespace that ends an adv arg or trait value.
+func (s *Scanner) scanValues(mode valueMode) {
+	s.valueStart = s.pos
+	for !s.segmentEndAt(s.pos) {
+		ch, next := s.input[s.pos], s.at(s.pos+1)
+		switch {
+		case mode.advArgSep && ch == SymAdvArgSep,
+			mode.trait && (isWhitespace(ch) || ch == SymTraitsStart):
+			return
+		case mode.argSep && ch == SymArgSep:
+			s.emitNext(TokenArgSep, 1)
+			s.valueStart = s.pos
+		case mode.advArgs && ch == SymAdvArgStart && s.advArgsAt(s.pos):
+			s.scanAdvArgs()
+			return
+		case ch == SymExpressionStart && next == SymExpressionStart:
+			s.scanExpression()
+		case mode.inputMacro && (ch == SymInputMacroEscapeSeq || (ch == SymEscapeSeq && next == SymCmdSep)):
+			s.emitNext(TokenEscape, 2)
+		case !mode.inputMacro && ch == SymEscapeSeq:
+			s.scanEscape()
+		case mode.quotes && (ch == SymArgDoubleQuote || ch == SymArgSingleQuote) && s.atValueStart():
+			s.scanQuoted(ch)
+		case mode.quotes && !mode.trait && ch == SymJSONStart && s.atValueStart():
+			s.scanJSON()
+		case mode.trait && ch == SymArrayStart && s.pos == s.valueStart:
+			s.scanArray()
+		default:
+			s.scanText(mode)
+		}
+	}
+}
+
+// atValueStart reports whether only whitespace precedes s.pos in the value
+// being scanned.
+func (s *Scanner) atValueStart() bool {
+	for _, ch := range s.input[s.valueStart:s.pos] {
+		if !isWhitespace(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// scanText scans unquoted text up to the next character that is special in
+// mode. It always scans at least one rune.
+func (s *Scanner) scanText(mode valueMode) {
+	start := s.pos
+	s.pos++
+	for !s.segmentEndAt(s.pos) {
+		ch := s.input[s.pos]
+		stop := ch == SymExpressionStart ||
+			(ch == SymEscapeSeq && (!mode.inputMacro || s.at(s.pos+1) == SymCmdSep)) ||
+			(mode.inputMacro && ch == SymInputMacroEscapeSeq) ||
+			(mode.argSep && ch == SymArgSep) ||
+			(mode.advArgSep && ch == SymAdvArgSep) ||
+			(mode.advArgs && ch == SymAdvArgStart) ||
+			(mode.trait && (isWhitespace(ch) || ch == SymTraitsStart))
+		if stop {
+			break
+		}
+		s.pos++
+	}
+	s.emit(TokenText, start)
+}
+
+// scanEscape scans a ^ escape sequence.
+func (s *Scanner) scanEscape() {
+	n := 2
+	if s.at(s.pos+1) == SymUnicodeEscape && s.at(s.pos+2) == SymUnicodeEscapeStart {
+		for i := s.pos + 3; i < len(s.input) && i <= s.pos+3+maxUnicodeEscapeDigits; i++ {
+			if s.input[i] == SymUnicodeEscapeEnd {
+				n = i + 1 - s.pos
+				break
+			}
+		}
+	}
+	s.emitNext(TokenEscape, n)
+}
+
+// scanQuoted scans a value quoted with quote. Separators, escapes and
+// expressions inside it are part of the token.
+func (s *Scanner) scanQuoted(quote rune) {
+	start := s.pos
+	s.pos++
+	for s.more() {
+		ch := s.input[s.pos]
+		switch {
+		case ch == SymEscapeSeq:
+			s.pos += 2
+		case ch == SymExpressionStart && s.at(s.pos+1) == SymExpressionStart:
+			s.pos = s.expressionEnd()
+		case ch == quote:
+			s.pos++
+			s.emit(TokenQuoted, start)
+			return
+		default:
+			s.pos++
+		}
+	}
+	s.pos = len(s.input)
+	s.emit(TokenQuoted, start)
+}
+
+// scanExpression scans a [[...]] expression.
+func (s *Scanner) scanExpression() {
+	start := s.pos
+	s.pos = s.expressionEnd()
+	s.emit(TokenExpression, start)
+}
+
+// expressionEnd returns the offset after the expression starting at s.pos,
+// or the end of the input if it is not closed.
+func (s *Scanner) expressionEnd() int {
+	end := exprEnd(s.input, s.pos+2)
+	if end == -1 {
+		return len(s.input)
+	}
+	return end + 2
+}
+
+// scanJSON scans a JSON object value, matching braces outside strings.
+func (s *Scanner) scanJSON() {
+	start := s.pos
+	depth, inString, escaped := 0, false, false
+	for s.more() {
+		ch := s.input[s.pos]
+		s.pos++
+		switch {
+		case escaped:
+			escaped = false
+		case ch == SymJSONEscapeSeq:
+			escaped = true
+		case ch == SymJSONString:
+			inString = !inString
+		case inString:
+		case ch == SymJSONStart:
+			depth++
+		case ch == SymJSONEnd:
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	s.emit(TokenJSON, start)
+}
+
+// scanArray scans a [...] trait array value as text, matching brackets
+// outside quotes.
+func (s *Scanner) scanArray() {
+	start := s.pos
+	depth := 0
+	var quote rune
+	for !s.segmentEndAt(s.pos) {
+		ch := s.input[s.pos]
+		s.pos++
+		switch {
+		case ch == SymEscapeSeq:
+			s.pos = min(s.pos+1, len(s.input))
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == SymArgDoubleQuote || ch == SymArgSingleQuote:
+			quote = ch
+		case ch == SymArrayStart:
+			depth++
+		case ch == SymArrayEnd:
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	s.emit(TokenText, start)
+}
+
+// advArgsAt reports whether the ? at i starts adv args: a name followed by
+// =, & or the end of the segment.
+func (s *Scanner) advArgsAt(i int) bool {
+	end := i + 1
+	for isAdvArgName(s.at(end)) {
+		end++
+	}
+	if end == i+1 {
+		return false
+	}
+	next := s.at(end)
+	return next == SymAdvArgEq || next == SymAdvArgSep || s.segmentEndAt(end)
+}
+
+// scanAdvArgs scans adv args from their ? to the end of the segment.
+func (s *Scanner) scanAdvArgs() {
+	s.emitNext(TokenAdvArgStart, 1)
+	for !s.segmentEndAt(s.pos) {
+		switch ch := s.input[s.pos]; {
+		case isAdvArgName(ch):
+			start := s.pos
+			for isAdvArgName(s.at(s.pos)) {
+				s.pos++
+			}
+			s.emit(TokenAdvArgKey, start)
+		case ch == SymAdvArgEq:
+			s.emitNext(TokenEquals, 1)
+			s.scanValues(advArgMode)
+		case ch == SymAdvArgSep:
+			s.emitNext(TokenAdvArgSep, 1)
+		default:
+			// not a name, the parser falls back to reading it as text
+			s.scanValues(advArgMode)
+		}
+	}
+}
+
+// scanTraits scans a segment of #key=value traits.
+func (s *Scanner) scanTraits() {
+	for {
+		s.skipWhitespace()
+		if s.segmentEndAt(s.pos) {
+			return
+		}
+		if s.input[s.pos] != SymTraitsStart {
+			s.scanRawArg()
+			return
+		}
+		s.emitNext(TokenTraitStart, 1)
+
+		if !isAdvArgNameStart(s.at(s.pos)) {
+			s.scanRawArg()
+			return
+		}
+		start := s.pos
+		for isAdvArgName(s.at(s.pos)) {
+			s.pos++
+		}
+		s.emit(TokenTraitKey, start)
+
+		switch next := s.at(s.pos); {
+		case next == SymAdvArgEq:
+			s.emitNext(TokenEquals, 1)
+			s.scanValues(traitMode)
+		case s.segmentEndAt(s.pos) || isWhitespace(next) || next == SymTraitsStart:
+		default:
+			// an invalid key, the parser falls back to reading the segment
+			// as auto-launch content
+			s.scanRawArg()
+			return
+		}
+	}
+}
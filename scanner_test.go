@@ -0,0 +1,256 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+// tok builds the token for value starting at rune offset start.
+func tok(kind zapscript.TokenKind, value string, start int64) zapscript.Token {
+	return zapscript.Token{
+		Kind:  kind,
+		Value: value,
+		Start: start,
+		End:   start + int64(utf8.RuneCountInString(value)),
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []zapscript.Token
+	}{
+		{
+			name:  "command with args and adv args",
+			input: `**launch.random:snes,"a, b"?launcher=retroarch&hidden`,
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "launch.random", 2),
+				tok(zapscript.TokenArgStart, ":", 15),
+				tok(zapscript.TokenText, "snes", 16),
+				tok(zapscript.TokenArgSep, ",", 20),
+				tok(zapscript.TokenQuoted, `"a, b"`, 21),
+				tok(zapscript.TokenAdvArgStart, "?", 27),
+				tok(zapscript.TokenAdvArgKey, "launcher", 28),
+				tok(zapscript.TokenEquals, "=", 36),
+				tok(zapscript.TokenText, "retroarch", 37),
+				tok(zapscript.TokenAdvArgSep, "&", 46),
+				tok(zapscript.TokenAdvArgKey, "hidden", 47),
+			},
+		},
+		{
+			name:  "command chain",
+			input: "**stop|| /games/mario.sfc",
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "stop", 2),
+				tok(zapscript.TokenCmdSep, "||", 6),
+				tok(zapscript.TokenText, "/games/mario.sfc", 9),
+			},
+		},
+		{
+			name:  "escapes and expressions",
+			input: "**echo:a^,b[[device.id]]^u{2192}",
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "echo", 2),
+				tok(zapscript.TokenArgStart, ":", 6),
+				tok(zapscript.TokenText, "a", 7),
+				tok(zapscript.TokenEscape, "^,", 8),
+				tok(zapscript.TokenText, "b", 10),
+				tok(zapscript.TokenExpression, "[[device.id]]", 11),
+				tok(zapscript.TokenEscape, "^u{2192}", 24),
+			},
+		},
+		{
+			name:  "escaped separator",
+			input: "**echo:a^|^|b",
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "echo", 2),
+				tok(zapscript.TokenArgStart, ":", 6),
+				tok(zapscript.TokenText, "a", 7),
+				tok(zapscript.TokenEscape, "^|", 8),
+				tok(zapscript.TokenEscape, "^|", 10),
+				tok(zapscript.TokenText, "b", 12),
+			},
+		},
+		{
+			name:  "separator inside quotes and expression",
+			input: `**echo:"a||b",[[x || y]]`,
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "echo", 2),
+				tok(zapscript.TokenArgStart, ":", 6),
+				tok(zapscript.TokenQuoted, `"a||b"`, 7),
+				tok(zapscript.TokenArgSep, ",", 13),
+				tok(zapscript.TokenExpression, "[[x || y]]", 14),
+			},
+		},
+		{
+			name:  "JSON arg",
+			input: `**http.post:url,{"a":"}"}`,
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "http.post", 2),
+				tok(zapscript.TokenArgStart, ":", 11),
+				tok(zapscript.TokenText, "url", 12),
+				tok(zapscript.TokenArgSep, ",", 15),
+				tok(zapscript.TokenJSON, `{"a":"}"}`, 16),
+			},
+		},
+		{
+			name:  "media title",
+			input: "@SNES/Super Mario World (USA)?launcher=x",
+			want: []zapscript.Token{
+				tok(zapscript.TokenMediaTitleStart, "@", 0),
+				tok(zapscript.TokenText, "SNES/Super Mario World (USA)", 1),
+				tok(zapscript.TokenAdvArgStart, "?", 29),
+				tok(zapscript.TokenAdvArgKey, "launcher", 30),
+				tok(zapscript.TokenEquals, "=", 38),
+				tok(zapscript.TokenText, "x", 39),
+			},
+		},
+		{
+			name:  "traits",
+			input: `#name="Mario Kart" #level=5 #tags=[a,b] #on||**stop`,
+			want: []zapscript.Token{
+				tok(zapscript.TokenTraitStart, "#", 0),
+				tok(zapscript.TokenTraitKey, "name", 1),
+				tok(zapscript.TokenEquals, "=", 5),
+				tok(zapscript.TokenQuoted, `"Mario Kart"`, 6),
+				tok(zapscript.TokenTraitStart, "#", 19),
+				tok(zapscript.TokenTraitKey, "level", 20),
+				tok(zapscript.TokenEquals, "=", 25),
+				tok(zapscript.TokenText, "5", 26),
+				tok(zapscript.TokenTraitStart, "#", 28),
+				tok(zapscript.TokenTraitKey, "tags", 29),
+				tok(zapscript.TokenEquals, "=", 33),
+				tok(zapscript.TokenText, "[a,b]", 34),
+				tok(zapscript.TokenTraitStart, "#", 40),
+				tok(zapscript.TokenTraitKey, "on", 41),
+				tok(zapscript.TokenCmdSep, "||", 43),
+				tok(zapscript.TokenCmdStart, "**", 45),
+				tok(zapscript.TokenCmdName, "stop", 47),
+			},
+		},
+		{
+			name:  "comment",
+			input: "**# a note, with ?marks||**stop",
+			want: []zapscript.Token{
+				tok(zapscript.TokenComment, "**# a note, with ?marks", 0),
+				tok(zapscript.TokenCmdSep, "||", 23),
+				tok(zapscript.TokenCmdStart, "**", 25),
+				tok(zapscript.TokenCmdName, "stop", 27),
+			},
+		},
+		{
+			name:  "invalid command name is auto-launch text",
+			input: "**he@llo,x",
+			want: []zapscript.Token{
+				tok(zapscript.TokenText, "**he@llo,x", 0),
+			},
+		},
+		{
+			name:  "input macro",
+			input: `**input.keyboard:ab{enter}\{^|`,
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "input.keyboard", 2),
+				tok(zapscript.TokenArgStart, ":", 16),
+				tok(zapscript.TokenText, "ab{enter}", 17),
+				tok(zapscript.TokenEscape, `\{`, 26),
+				tok(zapscript.TokenEscape, "^|", 28),
+			},
+		},
+		{
+			name:  "JSON script",
+			input: `{"cmds":[{"name":"stop"}]}`,
+			want: []zapscript.Token{
+				tok(zapscript.TokenJSON, `{"cmds":[{"name":"stop"}]}`, 0),
+			},
+		},
+		{
+			name:  "unterminated quote",
+			input: `**echo:"abc||**stop`,
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "echo", 2),
+				tok(zapscript.TokenArgStart, ":", 6),
+				tok(zapscript.TokenQuoted, `"abc||**stop`, 7),
+			},
+		},
+		{
+			name:  "unicode offsets are in runes",
+			input: "\uFEFF**echo:ö||é",
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "echo", 2),
+				tok(zapscript.TokenArgStart, ":", 6),
+				tok(zapscript.TokenText, "ö", 7),
+				tok(zapscript.TokenCmdSep, "||", 8),
+				tok(zapscript.TokenText, "é", 10),
+			},
+		},
+		{
+			name:  "trailing pipe",
+			input: "**stop|",
+			want: []zapscript.Token{
+				tok(zapscript.TokenCmdStart, "**", 0),
+				tok(zapscript.TokenCmdName, "stop", 2),
+				tok(zapscript.TokenCmdSep, "|", 6),
+			},
+		},
+		{
+			name:  "whitespace only",
+			input: "  \n ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, zapscript.Tokenize(tt.input)); diff != "" {
+				t.Errorf("Tokenize() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScannerNextEOF(t *testing.T) {
+	t.Parallel()
+
+	s := zapscript.NewScanner("**stop")
+	for range 2 {
+		if got := s.Next(); got.Kind == zapscript.TokenEOF {
+			t.Fatalf("Next() = %+v before the end", got)
+		}
+	}
+	for range 2 {
+		if got, want := s.Next(), (zapscript.Token{Kind: zapscript.TokenEOF, Start: 6, End: 6}); got != want {
+			t.Errorf("Next() = %+v, want %+v", got, want)
+		}
+	}
+}
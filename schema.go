@@ -0,0 +1,140 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) for the JSON object form
+// of a script produced by CanonicalJSON. It is generated from the JSON
+// struct tags of the script and Command types, so it always matches them:
+// fields without omitempty or omitzero are required, args are strings, adv
+// args are an object of strings and traits are a free-form object. Unknown
+// properties are allowed because the parser ignores them. The parser also
+// accepts a document with traits and no cmds, and number, bool and null adv
+// arg values, which the schema rejects.
+func JSONSchema() ([]byte, error) {
+	defs := make(map[string]any)
+	root, err := structSchema(reflect.TypeFor[jsonScript](), defs)
+	if err != nil {
+		return nil, err
+	}
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "ZapScript"
+	root["$defs"] = defs
+
+	b, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON schema: %w", err)
+	}
+	return b, nil
+}
+
+// typeSchema returns the schema for a field type. Named struct types are
+// added to defs and referenced.
+func typeSchema(t reflect.Type, defs map[string]any) (map[string]any, error) {
+	if t == reflect.TypeFor[AdvArgs]() {
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": map[string]any{"type": "string"},
+		}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice:
+		items, err := typeSchema(t.Elem(), defs)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type in JSON schema: %s", t)
+		}
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object"}, nil
+		}
+		values, err := typeSchema(t.Elem(), defs)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			// reserve the name first so recursive types terminate
+			defs[t.Name()] = nil
+			def, err := structSchema(t, defs)
+			if err != nil {
+				return nil, err
+			}
+			defs[t.Name()] = def
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type in JSON schema: %s", t)
+	}
+}
+
+func structSchema(t reflect.Type, defs map[string]any) (map[string]any, error) {
+	properties := make(map[string]any)
+	required := make([]string, 0)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := typeSchema(field.Type, defs)
+		if err != nil {
+			return nil, fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+		properties[name] = schema
+
+		optional := false
+		for _, opt := range strings.Split(opts, ",") {
+			optional = optional || opt == "omitempty" || opt == "omitzero"
+		}
+		if !optional {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
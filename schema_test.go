@@ -0,0 +1,195 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+var updateSchema = flag.Bool("update-schema", false, "regenerate testdata/schema.json")
+
+// schemaDocument mirrors the ZapScript JSON schema so documents can be
+// checked with encoding/json alone. TestJSONSchemaGolden pins the schema
+// itself, so a change to either must be made in both places.
+type schemaDocument struct {
+	Traits map[string]any `json:"traits"`
+	Cmds   []struct {
+		Name    *string           `json:"name"`
+		AdvArgs map[string]string `json:"advArgs"`
+		Args    []string          `json:"args"`
+	} `json:"cmds"`
+}
+
+func validateJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var doc schemaDocument
+	if err := dec.Decode(&doc); err != nil {
+		return err //nolint:wrapcheck // test helper
+	}
+	if doc.Cmds == nil {
+		return errors.New("missing required cmds")
+	}
+	for _, cmd := range doc.Cmds {
+		if cmd.Name == nil {
+			return errors.New("command missing required name")
+		}
+	}
+	return nil
+}
+
+// TestJSONSchemaGolden fails if the generated schema differs from
+// testdata/schema.json. Run with -update-schema to rewrite it.
+func TestJSONSchemaGolden(t *testing.T) {
+	t.Parallel()
+
+	data, err := zapscript.JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() unexpected error: %v", err)
+	}
+	if !json.Valid(data) {
+		t.Fatalf("JSONSchema() is not valid JSON: %s", data)
+	}
+
+	golden := filepath.Join("testdata", "schema.json")
+	if *updateSchema {
+		if writeErr := os.WriteFile(golden, data, 0o600); writeErr != nil {
+			t.Fatalf("write schema: %v", writeErr)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	if diff := cmp.Diff(string(want), string(data)); diff != "" {
+		t.Errorf("%s is stale, run go test -run TestJSONSchemaGolden -update-schema (-golden +generated):\n%s",
+			golden, diff)
+	}
+}
+
+func TestJSONSchemaValidatesParsedScripts(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"**stop",
+		"**launch:snes/mario.sfc?system=snes&launcher=retroarch",
+		"**greet:hi,there||**delay:500||**stop",
+		`**launch:game?tags={"a":[1,2]}`,
+		`**launch:/roms/[[platform]]/game.bin?when=[[media_playing]]`,
+		"#name=mario #level=5 #tags=[a,b]||@snes/Super Mario World",
+		`**traits:{"data":{"x":1}}`,
+		"**input.keyboard:ab{enter}",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			data, err := zapscript.CanonicalJSON(script)
+			if err != nil {
+				t.Fatalf("CanonicalJSON() unexpected error: %v", err)
+			}
+			if validateErr := validateJSON(data); validateErr != nil {
+				t.Errorf("schema validation failed: %v\njson=%s", validateErr, data)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaRejectsInvalid(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		`{"cmds":[{"args":["x"]}]}`,
+		`{"cmds":[{"name":1}]}`,
+		`{"cmds":[{"name":"a","args":"x"}]}`,
+		`{"cmds":[{"name":"a","args":[1]}]}`,
+		`{"cmds":[{"name":"a","advArgs":{"k":1}}]}`,
+		`{"cmds":{}}`,
+		`{"cmds":[],"traits":[]}`,
+		`{"traits":{}}`,
+		`{"cmds":[{"name":"a","extra":true}]}`,
+	}
+
+	for _, input := range inputs {
+		if err := validateJSON([]byte(input)); err == nil {
+			t.Errorf("schema accepted invalid document %s", input)
+		}
+	}
+}
+
+func TestJSONSchemaMatchesCommandTags(t *testing.T) {
+	t.Parallel()
+
+	data, err := zapscript.JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema() unexpected error: %v", err)
+	}
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		} `json:"$defs"`
+	}
+	if unmarshalErr := json.Unmarshal(data, &schema); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", unmarshalErr)
+	}
+
+	// every key a marshaled command can have must be a schema property
+	cmd := zapscript.Command{
+		Name:    "launch",
+		Args:    []string{"a"},
+		AdvArgs: zapscript.NewAdvArgs(map[string]string{"k": "v"}),
+	}
+	cmdJSON, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if unmarshalErr := json.Unmarshal(cmdJSON, &fields); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", unmarshalErr)
+	}
+	var got, want []string
+	for name := range schema.Defs["Command"].Properties {
+		got = append(got, name)
+	}
+	for name := range fields {
+		want = append(want, name)
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Command schema properties mismatch (-json +schema):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"name"}, schema.Defs["Command"].Required); diff != "" {
+		t.Errorf("Command schema required mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+var (
+	_ encoding.TextMarshaler   = zapscript.Script{}
+	_ encoding.TextUnmarshaler = (*zapscript.Script)(nil)
+)
+
+// mapping embeds a Script in a struct for encoding/xml, which like TOML
+// libraries stores fields through encoding.TextMarshaler.
+type mapping struct {
+	XMLName xml.Name         `xml:"mapping"`
+	ID      string           `xml:"id"`
+	Script  zapscript.Script `xml:"script"`
+}
+
+func TestScriptTextRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{
+		"**stop",
+		"**launch:snes/mario.sfc?system=snes&launcher=retroarch",
+		"**greet:\"hi, there\"||**delay:500||**stop",
+		`**launch:/roms/[[platform]]/game.bin?when=[[media_playing]]`,
+		"#name=mario #favorite||@snes/Super Mario World",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			want, err := zapscript.Parse(input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+
+			text, err := want.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText() unexpected error: %v", err)
+			}
+			var got zapscript.Script
+			if unmarshalErr := got.UnmarshalText(text); unmarshalErr != nil {
+				t.Fatalf("UnmarshalText(%q) unexpected error: %v", text, unmarshalErr)
+			}
+
+			opts := cmp.Options{
+				diffOpts,
+				cmpopts.IgnoreFields(zapscript.Script{}, "Warnings"),
+			}
+			if diff := cmp.Diff(want, got, opts); diff != "" {
+				t.Errorf("text round trip mismatch (-want +got):\n%s\ntext=%s", diff, text)
+			}
+		})
+	}
+}
+
+func TestScriptTextInStruct(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**launch:game?system=snes||**stop")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	data, err := xml.Marshal(mapping{ID: "abc", Script: script})
+	if err != nil {
+		t.Fatalf("xml.Marshal() unexpected error: %v", err)
+	}
+	want := `<mapping><id>abc</id><script>**launch:game?system=snes||**stop</script></mapping>`
+	if diff := cmp.Diff(want, string(data)); diff != "" {
+		t.Errorf("xml.Marshal() mismatch (-want +got):\n%s", diff)
+	}
+
+	var got mapping
+	if unmarshalErr := xml.Unmarshal(data, &got); unmarshalErr != nil {
+		t.Fatalf("xml.Unmarshal() unexpected error: %v", unmarshalErr)
+	}
+	if diff := cmp.Diff(script.Cmds, got.Script.Cmds, cmp.AllowUnexported(zapscript.AdvArgs{})); diff != "" {
+		t.Errorf("xml round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestScriptTextZeroValue(t *testing.T) {
+	t.Parallel()
+
+	data, err := xml.Marshal(mapping{ID: "abc"})
+	if err != nil {
+		t.Fatalf("xml.Marshal() unexpected error: %v", err)
+	}
+	var got mapping
+	if unmarshalErr := xml.Unmarshal(data, &got); unmarshalErr != nil {
+		t.Fatalf("xml.Unmarshal(%s) unexpected error: %v", data, unmarshalErr)
+	}
+	if len(got.Script.Cmds) != 0 || got.ID != "abc" {
+		t.Errorf("xml.Unmarshal(%s) = %+v, want empty script", data, got)
+	}
+}
+
+func TestScriptUnmarshalTextErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr error
+		input   string
+	}{
+		{input: `**say:"hello`, wantErr: zapscript.ErrUnmatchedQuote},
+		{input: "   ", wantErr: zapscript.ErrWhitespaceOnlyZapScript},
+		{input: "**zap.internal.run", wantErr: zapscript.ErrReservedCommandName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			var script zapscript.Script
+			if err := script.UnmarshalText([]byte(tt.input)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalText() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var escaped strings.Builder
+			if err := xml.EscapeText(&escaped, []byte(tt.input)); err != nil {
+				t.Fatalf("xml.EscapeText() unexpected error: %v", err)
+			}
+			data := "<mapping><script>" + escaped.String() + "</script></mapping>"
+			var m mapping
+			if err := xml.Unmarshal([]byte(data), &m); !errors.Is(err, tt.wantErr) {
+				t.Errorf("xml.Unmarshal() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
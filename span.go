@@ -0,0 +1,48 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+// Span is a range of an input in runes, from Start up to but not including
+// End, like slicing []rune(input). Offsets are 0-based, so the rune at a
+// 1-based position such as ParseError.Pos or Warning.Pos is at offset
+// Pos-1. A leading byte order mark is not counted.
+type Span struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// Contains reports whether the rune at offset is in the span.
+func (s Span) Contains(offset int64) bool {
+	return s.Start <= offset && offset < s.End
+}
+
+// CmdIndexAt returns the index in Cmds of the command whose Span contains
+// the rune at offset, or TraitsCmdIndex if one of TraitsSpans does, for
+// finding the command under an editor's cursor. It returns false if offset
+// is between segments, such as on a || separator or surrounding whitespace.
+func (s Script) CmdIndexAt(offset int64) (int, bool) {
+	for i, cmd := range s.Cmds {
+		if cmd.Span.Contains(offset) {
+			return i, true
+		}
+	}
+	for _, span := range s.TraitsSpans {
+		if span.Contains(offset) {
+			return TraitsCmdIndex, true
+		}
+	}
+	return 0, false
+}
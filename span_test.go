@@ -0,0 +1,112 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommandSpan(t *testing.T) {
+	t.Parallel()
+
+	input := "#fav #n=1 **stop||@snes/Mario Wörld?x=1||ünï.rom|"
+	script, err := zapscript.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	want := []zapscript.Span{{Start: 10, End: 16}, {Start: 18, End: 39}, {Start: 41, End: 48}}
+	got := make([]zapscript.Span, 0, len(script.Cmds))
+	runes := []rune(input)
+	for _, cmd := range script.Cmds {
+		got = append(got, cmd.Span)
+		if text := string(runes[cmd.Span.Start:cmd.Span.End]); text != cmd.Raw {
+			t.Errorf("input at %v = %q, want Raw %q", cmd.Span, text, cmd.Raw)
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Command.Span mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]zapscript.Span{{Start: 0, End: 10}}, script.TraitsSpans); diff != "" {
+		t.Errorf("Script.TraitsSpans mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestScriptCmdIndexAt(t *testing.T) {
+	t.Parallel()
+
+	//        0         1         2         3         4
+	//        0123456789012345678901234567890123456789012345678
+	input := "#fav #n=1 **stop||@snes/Mario Wörld?x=1||ünï.rom|"
+	script, err := zapscript.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		offset int64
+		want   int
+		wantOK bool
+	}{
+		{name: "traits start", offset: 0, want: zapscript.TraitsCmdIndex, wantOK: true},
+		{name: "second trait", offset: 7, want: zapscript.TraitsCmdIndex, wantOK: true},
+		{name: "command start", offset: 10, want: 0, wantOK: true},
+		{name: "command end", offset: 15, want: 0, wantOK: true},
+		{name: "separator", offset: 16},
+		{name: "media title", offset: 18, want: 1, wantOK: true},
+		{name: "media title adv arg", offset: 38, want: 1, wantOK: true},
+		{name: "auto-launch after multibyte runes", offset: 47, want: 2, wantOK: true},
+		{name: "trailing separator", offset: 48},
+		{name: "past end", offset: 100},
+		{name: "negative", offset: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := script.CmdIndexAt(tt.offset)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("CmdIndexAt(%d) = %d, %v, want %d, %v", tt.offset, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCommandSpanAutoLaunchFallback(t *testing.T) {
+	t.Parallel()
+
+	// a media title without a / and a command with an invalid name are
+	// parsed as auto-launch commands
+	script, err := zapscript.Parse("**my game.rom||@Mario World")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	want := []zapscript.Span{{Start: 0, End: 13}, {Start: 15, End: 27}}
+	got := make([]zapscript.Span, 0, len(script.Cmds))
+	for _, cmd := range script.Cmds {
+		if cmd.Name != zapscript.ZapScriptCmdLaunch {
+			t.Errorf("command %q, want an auto-launch", cmd.Raw)
+		}
+		got = append(got, cmd.Span)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Command.Span mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommandStringQuotePreference(t *testing.T) {
+	t.Parallel()
+
+	expr := zapscript.TokExpStart + "v" + zapscript.TokExprEnd
+	tests := []struct {
+		name  string
+		value string
+		want  map[zapscript.QuotePreference]string
+	}{
+		{
+			name:  "plain",
+			value: "game.rom",
+			want: map[zapscript.QuotePreference]string{
+				zapscript.QuotePreferQuotes:  "**cmd:game.rom",
+				zapscript.QuotePreferEscapes: "**cmd:game.rom",
+				zapscript.QuotePreferAuto:    "**cmd:game.rom",
+			},
+		},
+		{
+			name:  "one comma",
+			value: "a,b",
+			want: map[zapscript.QuotePreference]string{
+				zapscript.QuotePreferQuotes:  `**cmd:"a,b"`,
+				zapscript.QuotePreferEscapes: "**cmd:a^,b",
+				zapscript.QuotePreferAuto:    "**cmd:a^,b",
+			},
+		},
+		{
+			name:  "many commas",
+			value: "a,b,c,d",
+			want: map[zapscript.QuotePreference]string{
+				zapscript.QuotePreferQuotes:  `**cmd:"a,b,c,d"`,
+				zapscript.QuotePreferEscapes: "**cmd:a^,b^,c^,d",
+				zapscript.QuotePreferAuto:    `**cmd:"a,b,c,d"`,
+			},
+		},
+		{
+			name:  "two specials tie",
+			value: "a,b|",
+			want: map[zapscript.QuotePreference]string{
+				zapscript.QuotePreferQuotes:  `**cmd:"a,b|"`,
+				zapscript.QuotePreferEscapes: "**cmd:a^,b^|",
+				zapscript.QuotePreferAuto:    "**cmd:a^,b^|",
+			},
+		},
+		{
+			name:  "quotes inside",
+			value: `it's "q"`,
+			want: map[zapscript.QuotePreference]string{
+				zapscript.QuotePreferQuotes:  `**cmd:"it's ^"q^""`,
+				zapscript.QuotePreferEscapes: `**cmd:it's "q"`,
+				zapscript.QuotePreferAuto:    `**cmd:it's "q"`,
+			},
+		},
+		{
+			name:  "expression",
+			value: expr + ",x",
+			want: map[zapscript.QuotePreference]string{
+				zapscript.QuotePreferQuotes:  `**cmd:"[[v]],x"`,
+				zapscript.QuotePreferEscapes: "**cmd:[[v]]^,x",
+				zapscript.QuotePreferAuto:    "**cmd:[[v]]^,x",
+			},
+		},
+		{
+			name:  "empty",
+			value: "",
+			want: map[zapscript.QuotePreference]string{
+				zapscript.QuotePreferQuotes:  `**cmd:""`,
+				zapscript.QuotePreferEscapes: `**cmd:""`,
+				zapscript.QuotePreferAuto:    `**cmd:""`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cmd := zapscript.Command{Name: "cmd", Args: []string{tt.value}}
+			for pref, want := range tt.want {
+				if got := cmd.StringWithOptions(zapscript.WithQuotePreference(pref)); got != want {
+					t.Errorf("StringWithOptions(%d) = %q, want %q", pref, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandStringQuotePreferenceKeepsStyle(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse(`**cmd:"a,b",c^,d?k='x&y'`, zapscript.WithKeepStyle())
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	want := `**cmd:"a,b",c^,d?k='x&y'`
+	got := script.StringWithOptions(zapscript.WithQuotePreference(zapscript.QuotePreferAuto))
+	if got != want {
+		t.Errorf("StringWithOptions() = %q, want %q", got, want)
+	}
+}
+
+func TestScriptStringQuotePreferenceRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	values := []string{
+		"a,b,c",
+		"x||y|",
+		"c^d^",
+		`it's "quoted"`,
+		`'"`,
+		"[[literal]]",
+		"[",
+		"a?b&c=d",
+		"{not json",
+		"https://example.com/?a=1&b=2",
+		zapscript.TokExpStart + "media.path" + zapscript.TokExprEnd + "^[,",
+		"C:\\games\\snes\\a, b.sfc",
+	}
+	want := zapscript.Script{Cmds: []zapscript.Command{
+		{
+			Name:    "cmd",
+			Args:    values,
+			AdvArgs: zapscript.NewAdvArgs(map[string]string{"k": values[0]}),
+		},
+	}}
+	for _, value := range values {
+		want.Cmds = append(want.Cmds, zapscript.Command{
+			Name:    "launch",
+			Args:    []string{value},
+			AdvArgs: zapscript.NewAdvArgs(map[string]string{"k": value}),
+		})
+	}
+
+	prefs := []zapscript.QuotePreference{
+		zapscript.QuotePreferQuotes, zapscript.QuotePreferEscapes, zapscript.QuotePreferAuto,
+	}
+	for _, pref := range prefs {
+		text := want.StringWithOptions(zapscript.WithQuotePreference(pref))
+		got, err := zapscript.Parse(text)
+		if err != nil {
+			t.Fatalf("Parse(%q) unexpected error: %v", text, err)
+		}
+		if diff := cmp.Diff(want.Cmds, got.Cmds, diffOpts); diff != "" {
+			t.Errorf("QuotePreference %d round trip mismatch (-want +got):\n%s\ntext=%s", pref, diff, text)
+		}
+	}
+}
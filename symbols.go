@@ -17,6 +17,7 @@ package zapscript
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 )
 
@@ -25,14 +26,46 @@ var (
 	ErrInvalidCmdName         = errors.New("invalid characters in command name")
 	ErrInvalidAdvArgName      = errors.New("invalid characters in advanced arg name")
 	ErrEmptyCmdName           = errors.New("command name is empty")
+	ErrReservedCommandName    = errors.New("command name is reserved")
 	ErrEmptyZapScript         = errors.New("script is empty")
 	ErrUnmatchedQuote         = errors.New("unmatched quote")
 	ErrInvalidJSON            = errors.New("invalid JSON argument")
 	ErrUnmatchedInputMacroExt = errors.New("unmatched input macro extension")
 	ErrUnmatchedExpression    = errors.New("unmatched expression")
 	ErrBadExpressionReturn    = errors.New("expression return type not supported")
+	ErrPathEscapesSandbox     = errors.New("path escapes sandbox root")
+	ErrInvalidExprFunction    = errors.New("invalid expression function")
 	ErrInvalidTraitKey        = errors.New("invalid trait key")
 	ErrUnmatchedArrayBracket  = errors.New("unmatched array bracket")
+	ErrTrailingAfterQuote     = errors.New("unexpected text after closing quote")
+	ErrInvalidEncoding        = errors.New("invalid encoding")
+	ErrDuplicateAdvArg        = errors.New("duplicate advanced arg")
+	ErrDuplicateTraitKey      = errors.New("duplicate trait key")
+	ErrExpressionsDisabled    = errors.New("expressions are disabled")
+	ErrFieldNotAllowed        = errors.New("env field not allowed")
+	ErrUnknownVariable        = errors.New("unknown variable")
+	ErrInvalidAdvArgBool      = errors.New("invalid boolean adv arg")
+	ErrAdvArgMissing          = errors.New("adv arg missing")
+	ErrInvalidAdvArgNumber    = errors.New("invalid number adv arg")
+	ErrInvalidAdvArgDuration  = errors.New("invalid duration adv arg")
+	ErrUnknownAdvArg          = errors.New("unknown adv arg")
+	ErrInvalidDecodeTarget    = errors.New("invalid adv arg decode target")
+	ErrInvalidEncodeSource    = errors.New("invalid adv arg encode source")
+	ErrInvalidAdvArgValue     = errors.New("invalid adv arg value")
+	ErrAdvArgConflict         = errors.New("conflicting adv args")
+	ErrInvalidPercentEscape   = errors.New("invalid percent escape")
+	ErrAutoLaunchDisabled     = errors.New("auto-launch is disabled")
+	ErrInvalidUnicodeEscape   = errors.New("invalid unicode escape")
+
+	// ErrWhitespaceOnlyZapScript wraps ErrEmptyZapScript for input that was
+	// not empty but contained only whitespace.
+	ErrWhitespaceOnlyZapScript = fmt.Errorf("%w: input contained only whitespace", ErrEmptyZapScript)
+
+	// Parser limit errors, see Options.
+	ErrScriptTooLarge  = errors.New("script exceeds maximum input size")
+	ErrTooManyArgs     = errors.New("command exceeds maximum number of args")
+	ErrTooManyCommands = errors.New("script exceeds maximum number of commands")
+	ErrAdvArgTooLarge  = errors.New("adv arg value exceeds maximum size")
 
 	// Input macro expansion errors.
 	ErrInputMacroRepeatTooLarge = errors.New("input macro repeat count exceeds maximum")
@@ -51,6 +84,9 @@ const (
 	SymCmdStart            = '*'
 	SymCmdSep              = '|'
 	SymEscapeSeq           = '^'
+	SymUnicodeEscape       = 'u'
+	SymUnicodeEscapeStart  = '{'
+	SymUnicodeEscapeEnd    = '}'
 	SymArgStart            = ':'
 	SymArgSep              = ','
 	SymArgDoubleQuote      = '"'
@@ -73,6 +109,7 @@ const (
 	SymTagNot              = '-'
 	SymTagOr               = '~'
 	SymTraitsStart         = '#'
+	SymCommentStart        = '#'
 	SymArrayStart          = '['
 	SymArrayEnd            = ']'
 	SymArraySep            = ','
@@ -82,11 +119,29 @@ const (
 
 var eof = rune(0)
 
+// utf8BOM is stripped from the start of the input; some tag writers add it.
+const utf8BOM = "\uFEFF"
+
 func normalizeCmdName(name string) string {
 	return strings.ToLower(name)
 }
 
+// ReservedCmdNamespace is reserved for synthetic commands injected by the
+// host application. Scripts may not use it or any name under it.
+const ReservedCmdNamespace = "zap.internal"
+
+// isReservedCmdName expects a normalized command name.
+func isReservedCmdName(name string) bool {
+	return name == ReservedCmdNamespace || strings.HasPrefix(name, ReservedCmdNamespace+".")
+}
+
+// isCmdName reports whether ch may appear in a command name. A name cannot
+// start with - or _, see isCmdNameStart.
 func isCmdName(ch rune) bool {
+	return isCmdNameStart(ch) || ch == '-' || ch == '_'
+}
+
+func isCmdNameStart(ch rune) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '.'
 }
 
@@ -94,10 +149,30 @@ func isAdvArgName(ch rune) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') || ch == '_'
 }
 
+// isValidAdvArgKey reports whether the parser accepts key as an adv arg
+// name, which may start with any name character.
+func isValidAdvArgKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, ch := range key {
+		if !isAdvArgName(ch) {
+			return false
+		}
+	}
+	return true
+}
+
 func isAdvArgNameStart(ch rune) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
 }
 
+// isExprToken reports whether ch is one of the private-use runes that
+// delimit a parsed expression.
+func isExprToken(ch rune) bool {
+	return ch == '\uE000' || ch == '\uE001'
+}
+
 func isWhitespace(ch rune) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
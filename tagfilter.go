@@ -66,13 +66,17 @@ func NormalizeTag(s string) string {
 //
 // Format: "type:value" or "+type:value" (AND), "-type:value" (NOT), "~type:value" (OR)
 // Example: "region:usa,-unfinished:demo,~lang:en,~lang:es"
+// The input is an adv arg value the parser has already unescaped, so it is
+// split on every comma and quotes or ^ are not treated specially; splitting
+// it with SplitEscaped, which is for ZapScript source text, would unescape
+// it twice.
 // Returns normalized, deduplicated filters.
 func ParseTagFilters(raw string) ([]TagFilter, error) {
 	if raw == "" {
 		return []TagFilter{}, nil
 	}
 
-	parts := strings.Split(raw, ",")
+	parts := strings.Split(raw, string(SymArgSep))
 
 	// Use map for deduplication while maintaining order
 	type filterKey struct {
@@ -141,3 +145,34 @@ func ParseTagFilters(raw string) ([]TagFilter, error) {
 
 	return result, nil
 }
+
+// String returns the filter in the form ParseTagFilters reads, e.g.
+// "region:usa", "-unfinished:demo" or "~lang:en". It is not escaped, so a
+// filter containing a comma does not read back as one entry of a list.
+func (f TagFilter) String() string {
+	var prefix string
+	switch f.Operator {
+	case TagOperatorNOT:
+		prefix = "-"
+	case TagOperatorOR:
+		prefix = "~"
+	case TagOperatorAND, "":
+		// AND is the default, so it only needs a prefix if the type starts
+		// with one
+		if strings.HasPrefix(f.Type, "+") || strings.HasPrefix(f.Type, "-") || strings.HasPrefix(f.Type, "~") {
+			prefix = "+"
+		}
+	}
+	return prefix + f.Type + ":" + f.Value
+}
+
+// FormatTagFilters returns filters as a comma-separated list that
+// ParseTagFilters reads back to the same filters, as long as none contains a
+// comma.
+func FormatTagFilters(filters []TagFilter) string {
+	parts := make([]string, len(filters))
+	for i, f := range filters {
+		parts[i] = f.String()
+	}
+	return strings.Join(parts, string(SymArgSep))
+}
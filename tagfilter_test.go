@@ -287,6 +287,19 @@ func TestParseTagFilters_EdgeCases(t *testing.T) {
 				{Type: "url", Value: "http:example-com", Operator: TagOperatorAND},
 			},
 		},
+		{
+			name:  "caret is not an escape",
+			input: "region:usa^,lang:en",
+			want: []TagFilter{
+				{Type: "region", Value: "usa", Operator: TagOperatorAND},
+				{Type: "lang", Value: "en", Operator: TagOperatorAND},
+			},
+		},
+		{
+			name:    "quotes are not special",
+			input:   `"series:one,two"`,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -304,3 +317,56 @@ func TestParseTagFilters_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatTagFilters_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		want    string
+		filters []TagFilter
+	}{
+		{
+			name: "operators",
+			filters: []TagFilter{
+				{Type: "region", Value: "usa", Operator: TagOperatorAND},
+				{Type: "unfinished", Value: "demo", Operator: TagOperatorNOT},
+				{Type: "lang", Value: "en", Operator: TagOperatorOR},
+			},
+			want: "region:usa,-unfinished:demo,~lang:en",
+		},
+		{
+			name:    "colon in value",
+			filters: []TagFilter{{Type: "extension", Value: "v:2", Operator: TagOperatorOR}},
+			want:    "~extension:v:2",
+		},
+		{
+			name:    "type starting with an operator",
+			filters: []TagFilter{{Type: "-x", Value: "y", Operator: TagOperatorAND}},
+			want:    "+-x:y",
+		},
+		{
+			name:    "none",
+			filters: []TagFilter{},
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := FormatTagFilters(tt.filters)
+			if got != tt.want {
+				t.Errorf("FormatTagFilters() = %q, want %q", got, tt.want)
+			}
+			parsed, err := ParseTagFilters(got)
+			if err != nil {
+				t.Fatalf("ParseTagFilters(%q) unexpected error: %v", got, err)
+			}
+			if diff := cmp.Diff(tt.filters, parsed); diff != "" {
+				t.Errorf("ParseTagFilters(FormatTagFilters()) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/expr-lang/expr"
+)
+
+// Time expression function names.
+const (
+	ExprFuncWeekday    = "weekday"
+	ExprFuncHour       = "hour"
+	ExprFuncFormatTime = "format_time"
+)
+
+// timeFunctions returns the weekday, hour and format_time expression
+// functions, leaving out any that exprEnv has a field of the same name for,
+// see envHasName.
+func timeFunctions(exprEnv any) []expr.Option {
+	var funcs []expr.Option
+	if !envHasName(exprEnv, ExprFuncWeekday) {
+		funcs = append(funcs, expr.Function(ExprFuncWeekday, func(params ...any) (any, error) {
+			t, err := timeParam(ExprFuncWeekday, params[0])
+			if err != nil {
+				return nil, err
+			}
+			return t.Weekday().String(), nil
+		}, new(func(time.Time) string)))
+	}
+	if !envHasName(exprEnv, ExprFuncHour) {
+		funcs = append(funcs, expr.Function(ExprFuncHour, func(params ...any) (any, error) {
+			t, err := timeParam(ExprFuncHour, params[0])
+			if err != nil {
+				return nil, err
+			}
+			return t.Hour(), nil
+		}, new(func(time.Time) int)))
+	}
+	if !envHasName(exprEnv, ExprFuncFormatTime) {
+		funcs = append(funcs, expr.Function(ExprFuncFormatTime, func(params ...any) (any, error) {
+			t, err := timeParam(ExprFuncFormatTime, params[0])
+			if err != nil {
+				return nil, err
+			}
+			layout, ok := params[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected string layout, got %T", ExprFuncFormatTime, params[1])
+			}
+			return t.Format(layout), nil
+		}, new(func(time.Time, string) string)))
+	}
+	return funcs
+}
+
+func timeParam(name string, param any) (time.Time, error) {
+	t, ok := param.(time.Time)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%s: expected time argument, got %T", name, param)
+	}
+	return t, nil
+}
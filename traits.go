@@ -16,6 +16,11 @@
 package zapscript
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -24,7 +29,9 @@ type traitsParseResult struct {
 	traits         map[string]any
 	fallback       string
 	invalidKeyName string
-	invalidKey     bool
+	// duplicates lists keys set more than once in this segment.
+	duplicates []duplicateKey
+	invalidKey bool
 }
 
 // parseTraitsSyntax parses trait shorthand syntax: #key=value #key2=value2
@@ -64,6 +71,7 @@ func (sr *ScriptReader) parseTraitsSyntax() (*traitsParseResult, error) {
 		}
 
 		// Read the rest of the key
+		keyStart := sr.pos
 		key := strings.ToLower(string(ch))
 		var keySb strings.Builder
 		for {
@@ -122,6 +130,9 @@ func (sr *ScriptReader) parseTraitsSyntax() (*traitsParseResult, error) {
 			return result, nil
 		}
 
+		if _, ok := result.traits[key]; ok {
+			result.duplicates = addDuplicateKey(result.duplicates, key, keyStart)
+		}
 		result.traits[key] = value
 
 		// Look for next trait, whitespace, or end
@@ -180,7 +191,9 @@ func (sr *ScriptReader) parseTraitsSyntax() (*traitsParseResult, error) {
 	return result, nil
 }
 
-// parseTraitValue parses a trait value with type inference.
+// parseTraitValue parses a trait value with type inference. Expressions are
+// converted to expression tokens, and a value holding one is kept as a string
+// for its type to be inferred once evaluated, see Script.EvalTraits.
 // Returns (parsed value, raw string for fallback, error).
 func (sr *ScriptReader) parseTraitValue() (parsedValue any, rawStr string, err error) {
 	var rawBuf strings.Builder   // tracks raw input for fallback
@@ -216,13 +229,7 @@ func (sr *ScriptReader) parseTraitValue() (parsedValue any, rawStr string, err e
 			_, _ = rawBuf.WriteRune(ch)
 
 			if ch == SymEscapeSeq {
-				// Peek next char for raw tracking before parseEscapeSeq consumes it
-				nextRaw, peekErr := sr.peek()
-				if peekErr != nil {
-					return "", rawBuf.String(), peekErr
-				}
-
-				escaped, escapeErr := sr.parseEscapeSeq()
+				escaped, nextRaw, escapeErr := sr.parseEscapeSeqRaw()
 				if escapeErr != nil {
 					return "", rawBuf.String(), escapeErr
 				}
@@ -231,11 +238,18 @@ func (sr *ScriptReader) parseTraitValue() (parsedValue any, rawStr string, err e
 					_, _ = valueBuf.WriteRune(SymEscapeSeq)
 					continue
 				}
-				_, _ = rawBuf.WriteRune(nextRaw)
+				_, _ = rawBuf.WriteString(nextRaw)
 				_, _ = valueBuf.WriteString(escaped)
 				continue
 			}
 
+			if ch == SymExpressionStart {
+				if exprErr := sr.readTraitExpression(&valueBuf, &rawBuf); exprErr != nil {
+					return "", rawBuf.String(), exprErr
+				}
+				continue
+			}
+
 			if ch == quoteChar {
 				// End of quoted string
 				return valueBuf.String(), rawBuf.String(), nil
@@ -245,9 +259,23 @@ func (sr *ScriptReader) parseTraitValue() (parsedValue any, rawStr string, err e
 		}
 	}
 
-	// Check if value is an array
+	// A value starting with [ is an array unless it starts with an
+	// expression, but [[[ is an array whose first element is an expression.
+	// With expressions disabled, a value starting with [[ is plain text.
 	if first == SymArrayStart {
-		return sr.parseTraitArray()
+		// a short peek at the end of the input returns what there is
+		ahead, _ := sr.r.Peek(3)
+		ch, readErr := sr.read()
+		if readErr != nil {
+			return "", "", readErr
+		}
+		if !strings.HasPrefix(string(ahead), "[[") || (string(ahead) == "[[[" && !sr.opts.DisableExpressions) {
+			return sr.parseTraitArray()
+		}
+		_, _ = rawBuf.WriteRune(ch)
+		if exprErr := sr.readTraitExpression(&valueBuf, &rawBuf); exprErr != nil {
+			return "", rawBuf.String(), exprErr
+		}
 	}
 
 	// Unquoted value - read until whitespace, #, or end of command
@@ -269,13 +297,7 @@ func (sr *ScriptReader) parseTraitValue() (parsedValue any, rawStr string, err e
 
 		// Handle escape sequences
 		if ch == SymEscapeSeq {
-			// Peek next char for raw tracking before parseEscapeSeq consumes it
-			nextRaw, peekErr := sr.peek()
-			if peekErr != nil {
-				return "", rawBuf.String(), peekErr
-			}
-
-			escaped, escapeErr := sr.parseEscapeSeq()
+			escaped, nextRaw, escapeErr := sr.parseEscapeSeqRaw()
 			if escapeErr != nil {
 				return "", rawBuf.String(), escapeErr
 			}
@@ -283,17 +305,44 @@ func (sr *ScriptReader) parseTraitValue() (parsedValue any, rawStr string, err e
 				_, _ = valueBuf.WriteRune(SymEscapeSeq)
 				continue
 			}
-			_, _ = rawBuf.WriteRune(nextRaw)
+			_, _ = rawBuf.WriteString(nextRaw)
 			_, _ = valueBuf.WriteString(escaped)
 			continue
 		}
 
+		if ch == SymExpressionStart {
+			if exprErr := sr.readTraitExpression(&valueBuf, &rawBuf); exprErr != nil {
+				return "", rawBuf.String(), exprErr
+			}
+			continue
+		}
+
 		_, _ = valueBuf.WriteRune(ch)
 	}
 
 	return inferType(valueBuf.String(), quoted), rawBuf.String(), nil
 }
 
+// readTraitExpression reads an expression in a trait value whose first [ has
+// been read and written to raw. The expression is written to value as
+// expression tokens and its remaining source to raw. A [ that does not start
+// an expression is written to value as is.
+func (sr *ScriptReader) readTraitExpression(value, raw *strings.Builder) error {
+	exprValue, err := sr.parseExpression()
+	if err != nil {
+		return err
+	}
+	_, _ = value.WriteString(exprValue)
+	if exprValue == string(SymExpressionStart) {
+		return nil
+	}
+	source := strings.TrimSuffix(strings.TrimPrefix(exprValue, TokExpStart), TokExprEnd)
+	_, _ = raw.WriteRune(SymExpressionStart)
+	_, _ = raw.WriteString(source)
+	_, _ = raw.WriteString(string([]rune{SymExpressionEnd, SymExpressionEnd}))
+	return nil
+}
+
 // consumeToEndOfCmd reads all characters until end of command or EOF.
 func (sr *ScriptReader) consumeToEndOfCmd() (string, error) {
 	var buf strings.Builder
@@ -319,18 +368,16 @@ func (sr *ScriptReader) consumeToEndOfCmd() (string, error) {
 	return buf.String(), nil
 }
 
-// parseTraitArray parses an array value: [a,b,c]
+// parseTraitArray parses an array value: [a,b,c], after the opening bracket
+// has been read.
 // Returns ([]any, raw string for fallback, error).
 func (sr *ScriptReader) parseTraitArray() (parsedValue any, rawStr string, err error) {
 	var rawBuf strings.Builder
 	elements := make([]any, 0)
+	_, _ = rawBuf.WriteRune(SymArrayStart)
 
-	// Consume opening bracket
-	ch, readErr := sr.read()
-	if readErr != nil {
-		return nil, "", readErr
-	}
-	_, _ = rawBuf.WriteRune(ch)
+	var ch rune
+	var readErr error
 
 	// Parse array elements
 	for {
@@ -453,12 +500,7 @@ func (sr *ScriptReader) parseArrayElement() (parsedValue any, rawStr string, err
 			_, _ = rawBuf.WriteRune(ch)
 
 			if ch == SymEscapeSeq {
-				nextRaw, peekErr := sr.peek()
-				if peekErr != nil {
-					return "", rawBuf.String(), peekErr
-				}
-
-				escaped, escapeErr := sr.parseEscapeSeq()
+				escaped, nextRaw, escapeErr := sr.parseEscapeSeqRaw()
 				if escapeErr != nil {
 					return "", rawBuf.String(), escapeErr
 				}
@@ -466,11 +508,18 @@ func (sr *ScriptReader) parseArrayElement() (parsedValue any, rawStr string, err
 					_, _ = valueBuf.WriteRune(SymEscapeSeq)
 					continue
 				}
-				_, _ = rawBuf.WriteRune(nextRaw)
+				_, _ = rawBuf.WriteString(nextRaw)
 				_, _ = valueBuf.WriteString(escaped)
 				continue
 			}
 
+			if ch == SymExpressionStart {
+				if exprErr := sr.readTraitExpression(&valueBuf, &rawBuf); exprErr != nil {
+					return "", rawBuf.String(), exprErr
+				}
+				continue
+			}
+
 			if ch == quoteChar {
 				return valueBuf.String(), rawBuf.String(), nil
 			}
@@ -497,12 +546,7 @@ func (sr *ScriptReader) parseArrayElement() (parsedValue any, rawStr string, err
 		_, _ = rawBuf.WriteRune(ch)
 
 		if ch == SymEscapeSeq {
-			nextRaw, peekErr := sr.peek()
-			if peekErr != nil {
-				return "", rawBuf.String(), peekErr
-			}
-
-			escaped, escapeErr := sr.parseEscapeSeq()
+			escaped, nextRaw, escapeErr := sr.parseEscapeSeqRaw()
 			if escapeErr != nil {
 				return "", rawBuf.String(), escapeErr
 			}
@@ -510,11 +554,18 @@ func (sr *ScriptReader) parseArrayElement() (parsedValue any, rawStr string, err
 				_, _ = valueBuf.WriteRune(SymEscapeSeq)
 				continue
 			}
-			_, _ = rawBuf.WriteRune(nextRaw)
+			_, _ = rawBuf.WriteString(nextRaw)
 			_, _ = valueBuf.WriteString(escaped)
 			continue
 		}
 
+		if ch == SymExpressionStart {
+			if exprErr := sr.readTraitExpression(&valueBuf, &rawBuf); exprErr != nil {
+				return "", rawBuf.String(), exprErr
+			}
+			continue
+		}
+
 		_, _ = valueBuf.WriteRune(ch)
 	}
 
@@ -550,3 +601,213 @@ func inferType(value string, quoted bool) any {
 
 	return value
 }
+
+// writeTraits writes traits sorted by key as #key=value shorthand separated
+// by sep where that parses back to the same value, and any remaining traits
+// as a **traits:{...} command. JSON numbers always parse as float64, so the
+// shorthand keeps int64 values and nested arrays intact.
+func writeTraits(b *strings.Builder, traits map[string]any, sep string) {
+	keys := make([]string, 0, len(traits))
+	for k := range traits {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var rest map[string]any
+	wrote := false
+	for _, k := range keys {
+		var value strings.Builder
+		if !isTraitShorthandKey(k) || !writeTraitValue(&value, traits[k], false) {
+			if rest == nil {
+				rest = make(map[string]any)
+			}
+			rest[k] = traits[k]
+			continue
+		}
+		if wrote {
+			_, _ = b.WriteString(sep)
+		}
+		wrote = true
+		_, _ = b.WriteRune(SymTraitsStart)
+		_, _ = b.WriteString(k)
+		if traits[k] != true {
+			_, _ = b.WriteRune(SymAdvArgEq)
+			_, _ = b.WriteString(value.String())
+		}
+	}
+
+	if len(rest) == 0 {
+		return
+	}
+	var data bytes.Buffer
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+	// map[string]any built by the parser or from JSON always encodes
+	if err := enc.Encode(rest); err != nil {
+		return
+	}
+	if wrote {
+		_, _ = b.WriteString(string([]rune{SymCmdSep, SymCmdSep}))
+	}
+	_, _ = b.WriteString("**")
+	_, _ = b.WriteString(ZapScriptCmdTraits)
+	_, _ = b.WriteRune(SymArgStart)
+	_, _ = b.WriteString(strings.TrimSuffix(data.String(), "\n"))
+}
+
+// detokenizeTraits returns a copy of traits with the expressions in string
+// values, including those in arrays and objects, written as [[...]], see
+// DetokenizeExpressions. It is used for JSON and other readable output.
+func detokenizeTraits(traits map[string]any) map[string]any {
+	if traits == nil {
+		return nil
+	}
+	out := make(map[string]any, len(traits))
+	for k, v := range traits {
+		out[k] = detokenizeTraitValue(v)
+	}
+	return out
+}
+
+func detokenizeTraitValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return DetokenizeExpressions(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = detokenizeTraitValue(elem)
+		}
+		return out
+	case map[string]any:
+		return detokenizeTraits(v)
+	default:
+		return v
+	}
+}
+
+// tokenizeTraits is the inverse of detokenizeTraits, converting [[...]] in
+// string values to expression tokens with TokenizeExpressions. It returns
+// ErrUnmatchedExpression naming the key if an expression is not closed.
+func tokenizeTraits(traits map[string]any) (map[string]any, error) {
+	if traits == nil {
+		return nil, nil
+	}
+	out := make(map[string]any, len(traits))
+	for k, v := range traits {
+		tokenized, err := tokenizeTraitValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("trait %q: %w", k, err)
+		}
+		out[k] = tokenized
+	}
+	return out, nil
+}
+
+func tokenizeTraitValue(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return TokenizeExpressions(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			tokenized, err := tokenizeTraitValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = tokenized
+		}
+		return out, nil
+	case map[string]any:
+		return tokenizeTraits(v)
+	default:
+		return v, nil
+	}
+}
+
+// isTraitShorthandKey reports whether key can be written as #key. The parser
+// lowercases shorthand keys.
+func isTraitShorthandKey(key string) bool {
+	if key == "" || !isAdvArgNameStart(rune(key[0])) || key != strings.ToLower(key) {
+		return false
+	}
+	for _, ch := range key {
+		if !isAdvArgName(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeTraitValue writes a shorthand trait value or array element that
+// parses back to v, or reports false if there is no such form.
+func writeTraitValue(b *strings.Builder, v any, inArray bool) bool {
+	switch v := v.(type) {
+	case bool:
+		_, _ = b.WriteString(strconv.FormatBool(v))
+	case int64:
+		_, _ = b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		f := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(f, ".") && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			// keep whole numbers from being read as int64
+			f += ".0"
+		}
+		_, _ = b.WriteString(f)
+	case string:
+		if !isTraitPlainString(v) {
+			parts, complete := splitArgParts(v)
+			writeQuoted(b, parts, SymArgDoubleQuote)
+			return complete && isTraitQuotable(v)
+		}
+		_, _ = b.WriteString(v)
+	case []any:
+		if inArray {
+			return false
+		}
+		_, _ = b.WriteRune(SymArrayStart)
+		for i, elem := range v {
+			if i > 0 {
+				_, _ = b.WriteRune(SymArraySep)
+			}
+			if !writeTraitValue(b, elem, true) {
+				return false
+			}
+		}
+		_, _ = b.WriteRune(SymArrayEnd)
+	default:
+		return false
+	}
+	return true
+}
+
+// isTraitPlainString reports whether s can be written unquoted and still be
+// read as the same string.
+func isTraitPlainString(s string) bool {
+	if _, ok := inferType(s, false).(string); !ok || s == "" || strings.ContainsAny(s, TokExpStart+TokExprEnd) {
+		return false
+	}
+	for _, ch := range s {
+		switch ch {
+		case SymTraitsStart, SymCmdSep, SymEscapeSeq, SymArgDoubleQuote, SymArgSingleQuote,
+			SymArrayStart, SymArrayEnd, SymArraySep:
+			return false
+		}
+		if ch < ' ' || ch == 0x7f || isWhitespace(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTraitQuotable reports whether s survives a double-quoted trait value.
+// Control characters other than newline, tab and carriage return cannot be
+// escaped in ZapScript.
+func isTraitQuotable(s string) bool {
+	for _, ch := range s {
+		if (ch < ' ' && ch != '\n' && ch != '\r' && ch != '\t') || ch == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,268 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func tokExpr(expr string) string {
+	return zapscript.TokExpStart + expr + zapscript.TokExprEnd
+}
+
+func TestParseTraitsExpressions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		wantErr    error
+		wantTraits map[string]any
+		name       string
+		input      string
+	}{
+		{
+			name:       "unquoted",
+			input:      "#difficulty=[[scanned.data]]",
+			wantTraits: map[string]any{"difficulty": tokExpr("scanned.data")},
+		},
+		{
+			name:       "unquoted with text",
+			input:      "#name=v[[version]]-x #next=1",
+			wantTraits: map[string]any{"name": "v" + tokExpr("version") + "-x", "next": int64(1)},
+		},
+		{
+			name:       "quoted",
+			input:      `#label="[[device.os]] build"`,
+			wantTraits: map[string]any{"label": tokExpr("device.os") + " build"},
+		},
+		{
+			name:       "expression with spaces and quotes",
+			input:      `#d=[[platform == "mister" ? 1 : 2]]`,
+			wantTraits: map[string]any{"d": tokExpr(`platform == "mister" ? 1 : 2`)},
+		},
+		{
+			name:  "array elements",
+			input: `#tags=[a,[[platform]],"x [[version]]",2]`,
+			wantTraits: map[string]any{"tags": []any{
+				"a", tokExpr("platform"), "x " + tokExpr("version"), int64(2),
+			}},
+		},
+		{
+			name:       "array starting with expression",
+			input:      "#tags=[[[platform]]]",
+			wantTraits: map[string]any{"tags": []any{tokExpr("platform")}},
+		},
+		{
+			name:       "escaped",
+			input:      "#raw=^[[scanned.data]]",
+			wantTraits: map[string]any{"raw": "[[scanned.data]]"},
+		},
+		{
+			name:       "escaped quoted",
+			input:      `#raw="^[[x]]"`,
+			wantTraits: map[string]any{"raw": "[[x]]"},
+		},
+		{
+			name:       "single bracket",
+			input:      "#a=x[y] #b=[1]",
+			wantTraits: map[string]any{"a": "x[y]", "b": []any{int64(1)}},
+		},
+		{
+			name:    "unmatched",
+			input:   "#a=[[x",
+			wantErr: zapscript.ErrUnmatchedExpression,
+		},
+		{
+			name:    "unmatched in array",
+			input:   "#a=[1,[[x]",
+			wantErr: zapscript.ErrUnmatchedExpression,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantTraits, script.Traits); diff != "" {
+				t.Errorf("traits mismatch (-want +got):\n%s", diff)
+			}
+
+			reparsed, err := zapscript.Parse(script.String())
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", script.String(), err)
+			}
+			if diff := cmp.Diff(script.Traits, reparsed.Traits); diff != "" {
+				t.Errorf("String() %q does not round trip (-want +got):\n%s", script.String(), diff)
+			}
+		})
+	}
+}
+
+func TestScriptEvalTraits(t *testing.T) {
+	t.Parallel()
+
+	env := zapscript.ArgExprEnv{
+		Platform: "mister",
+		Version:  "2.5.0",
+		ScanMode: "tap",
+		Scanned:  zapscript.ExprEnvScanned{Value: "3"},
+	}
+
+	tests := []struct {
+		want    map[string]any
+		wantErr error
+		name    string
+		input   string
+	}{
+		{
+			name:  "inferred from result",
+			input: `#level=[[scanned.value]] #half=[[1.5]] #on=[[platform == "mister"]] #name="[[platform]]"`,
+			want: map[string]any{
+				"level": int64(3),
+				"half":  1.5,
+				"on":    true,
+				"name":  "mister",
+			},
+		},
+		{
+			name:  "mixed text",
+			input: `#v=v[[version]] #n="[[scanned.value]]0"`,
+			want:  map[string]any{"v": "v2.5.0", "n": int64(30)},
+		},
+		{
+			name:  "array elements",
+			input: "#tags=[[[scan_mode]],[[1 + 1]],x]",
+			want:  map[string]any{"tags": []any{"tap", int64(2), "x"}},
+		},
+		{
+			name:  "without expressions",
+			input: `#a=1 #b="^[[" #c=^[[x]]`,
+			want:  map[string]any{"a": int64(1), "b": "[[", "c": "[[x]]"},
+		},
+		{
+			name:    "error names trait",
+			input:   "#bad=[[nope()]]",
+			wantErr: errors.New(`trait "bad"`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			got, err := script.EvalTraits(env)
+			if tt.wantErr != nil {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr.Error()) {
+					t.Fatalf("EvalTraits() error = %v, want prefix %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvalTraits() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("EvalTraits() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScriptEvalTraitsDoesNotModify(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("#a=[[platform]] #b=[[[platform]]]")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	want := script.Clone().Traits
+	if _, err := script.EvalTraits(zapscript.ArgExprEnv{Platform: "pc"}); err != nil {
+		t.Fatalf("EvalTraits() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, script.Traits); diff != "" {
+		t.Errorf("EvalTraits() modified traits (-want +got):\n%s", diff)
+	}
+}
+
+func TestTraitExpressionsJSON(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("#d=[[x]] #e=[a,[[y]]]||**stop")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+
+	want := `{"traits":{"d":"[[x]]","e":["a","[[y]]"]},"cmds":[{"name":"stop"}]}`
+	data, err := json.Marshal(script)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, string(data)); diff != "" {
+		t.Errorf("json.Marshal() mismatch (-want +got):\n%s", diff)
+	}
+	canonical, err := zapscript.CanonicalJSON(script)
+	if err != nil {
+		t.Fatalf("CanonicalJSON() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, string(canonical)); diff != "" {
+		t.Errorf("CanonicalJSON() mismatch (-want +got):\n%s", diff)
+	}
+
+	var got zapscript.Script
+	if unmarshalErr := json.Unmarshal(data, &got); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal(%s) unexpected error: %v", data, unmarshalErr)
+	}
+	if diff := cmp.Diff(script.Traits, got.Traits); diff != "" {
+		t.Errorf("json.Unmarshal() traits mismatch (-want +got):\n%s", diff)
+	}
+
+	parsed, err := zapscript.Parse(string(data))
+	if err != nil {
+		t.Fatalf("Parse(%s) unexpected error: %v", data, err)
+	}
+	if diff := cmp.Diff(script.Traits, parsed.Traits); diff != "" {
+		t.Errorf("JSON script traits mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTraitExpressionsJSONUnmatched(t *testing.T) {
+	t.Parallel()
+
+	input := `{"traits":{"d":"[[x"},"cmds":[{"name":"stop"}]}`
+	var script zapscript.Script
+	if err := json.Unmarshal([]byte(input), &script); !errors.Is(err, zapscript.ErrUnmatchedExpression) {
+		t.Errorf("json.Unmarshal() error = %v, want %v", err, zapscript.ErrUnmatchedExpression)
+	}
+	if _, err := zapscript.Parse(input); !errors.Is(err, zapscript.ErrUnmatchedExpression) {
+		t.Errorf("Parse() error = %v, want %v", err, zapscript.ErrUnmatchedExpression)
+	}
+}
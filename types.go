@@ -51,6 +51,9 @@ const (
 	KeyName           Key = "name"
 	KeyPreNotice      Key = "pre_notice"
 	KeyHidden         Key = "hidden"
+	// KeyMaxLen is a display budget in runes that hosts may honor with
+	// TruncateDisplay.
+	KeyMaxLen Key = "max_len"
 )
 
 // Action values for the action advanced argument.
@@ -79,7 +82,8 @@ const (
 
 // GlobalArgs contains advanced arguments available to all commands.
 type GlobalArgs struct {
-	// When controls conditional execution. If non-empty and falsy, command is skipped.
+	// When controls conditional execution. If non-empty and falsy, command is skipped,
+	// see Command.ShouldRun.
 	When string `advarg:"when"`
 }
 
@@ -184,6 +188,7 @@ type PlaylistArgs struct {
 // MisterScriptArgs contains advanced arguments for MiSTer script commands.
 type MisterScriptArgs struct {
 	GlobalArgs
-	// Hidden controls whether the script window is hidden.
+	// Hidden controls whether the script window is hidden. Read it with
+	// IsHidden, which accepts the same values as AdvArgs.GetBool.
 	Hidden string `advarg:"hidden"`
 }
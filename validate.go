@@ -0,0 +1,144 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// TraitKeyZapScript is reserved for a future script version pragma.
+const TraitKeyZapScript = "zapscript"
+
+var reservedTraitKeys = map[string]bool{TraitKeyZapScript: true}
+
+// ReservedTraitKeys returns the sorted list of trait keys reserved for future
+// parser use. Hosts reserve their own keys with WithReservedTraitKeys.
+func ReservedTraitKeys() []string {
+	keys := make([]string, 0, len(reservedTraitKeys))
+	for k := range reservedTraitKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate reports non-fatal problems with a parsed script. It never changes
+// the script and an empty result means no problems were found.
+func (s Script) Validate(opts ...ValidateOption) []Warning {
+	options := defaultValidateOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var warnings []Warning
+
+	reserved := maps.Clone(reservedTraitKeys)
+	for _, k := range options.ReservedTraitKeys {
+		reserved[strings.ToLower(k)] = true
+	}
+
+	keys := make([]string, 0, len(s.Traits))
+	for k := range s.Traits {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if reserved[strings.ToLower(k)] {
+			warnings = append(warnings, Warning{
+				Code:     WarningReservedTraitKey,
+				Message:  fmt.Sprintf("trait key %q is reserved and may change meaning in a future version", k),
+				Fragment: k,
+				CmdIndex: TraitsCmdIndex,
+			})
+		}
+	}
+
+	if options.EscapeDensityThreshold > 0 {
+		warnings = append(warnings, s.escapeDensityWarnings(options.EscapeDensityThreshold)...)
+	}
+
+	SortWarnings(warnings)
+	return warnings
+}
+
+// minEscapeDensityCount is the fewest escape sequences a value needs before
+// its escape density is considered, so a lone ^ in short text is not
+// reported.
+const minEscapeDensityCount = 2
+
+func (s Script) escapeDensityWarnings(threshold float64) []Warning {
+	var warnings []Warning
+	for i, cmd := range s.Cmds {
+		check := func(what, value string) {
+			density, ok := escapeDensity(value)
+			if !ok || density <= threshold {
+				return
+			}
+			warnings = append(warnings, Warning{
+				Code: WarningEscapeDensity,
+				Message: fmt.Sprintf("%s is %.0f%% escape sequences and may be double-escaped, see UnescapeOnce",
+					what, density*100),
+				Fragment: value,
+				CmdIndex: i,
+			})
+		}
+
+		// input command args are single keys, not escaped text
+		name := normalizeCmdName(cmd.Name)
+		if !isInputMacroCmd(name) && !isInputRawCmd(name) {
+			for j, arg := range cmd.Args {
+				check(fmt.Sprintf("arg %d", j+1), arg)
+			}
+		}
+		for _, k := range cmd.AdvArgs.SortedKeys() {
+			check(fmt.Sprintf("adv arg %q", k), cmd.AdvArgs.Get(k))
+		}
+	}
+	return warnings
+}
+
+// isEscapedSyntax reports whether ^ch is an escape sequence a ZapScript writer
+// would produce, as opposed to a caret in ordinary text like 2^3.
+func isEscapedSyntax(ch rune) bool {
+	switch ch {
+	case SymEscapeSeq, SymArgDoubleQuote, SymArgSingleQuote, SymExpressionStart, SymArgSep, SymArgStart,
+		SymCmdSep, SymAdvArgStart, SymAdvArgSep, SymAdvArgEq, 'n', 'r', 't':
+		return true
+	default:
+		return false
+	}
+}
+
+// escapeDensity returns the fraction of runes in s that are part of ^ escape
+// sequences. It reports false if s has too few sequences to judge.
+func escapeDensity(s string) (float64, bool) {
+	runes := []rune(s)
+	escapes := 0
+	for i := 0; i < len(runes)-1; i++ {
+		if runes[i] == SymEscapeSeq && isEscapedSyntax(runes[i+1]) {
+			escapes++
+			i++
+		}
+	}
+	if escapes < minEscapeDensityCount {
+		return 0, false
+	}
+	return float64(escapes*2) / float64(len(runes)), true
+}
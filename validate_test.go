@@ -0,0 +1,198 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReservedTraitKeys(t *testing.T) {
+	t.Parallel()
+
+	keys := zapscript.ReservedTraitKeys()
+	if !slices.Contains(keys, zapscript.TraitKeyZapScript) {
+		t.Errorf("ReservedTraitKeys() = %v, want it to contain %q", keys, zapscript.TraitKeyZapScript)
+	}
+	if !slices.IsSorted(keys) {
+		t.Errorf("ReservedTraitKeys() = %v, want sorted", keys)
+	}
+}
+
+func reservedWarning(key string) zapscript.Warning {
+	return zapscript.Warning{
+		Code:     zapscript.WarningReservedTraitKey,
+		Message:  fmt.Sprintf("trait key %q is reserved and may change meaning in a future version", key),
+		Fragment: key,
+		CmdIndex: zapscript.TraitsCmdIndex,
+	}
+}
+
+func TestValidateReservedTraitKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		opts  []zapscript.ValidateOption
+		want  []zapscript.Warning
+	}{
+		{
+			name:  "normal keys pass clean",
+			input: `#favorite #count=5||**launch:game.rom`,
+		},
+		{
+			name:  "built-in reserved key",
+			input: `#zapscript=2||**launch:game.rom`,
+			want:  []zapscript.Warning{reservedWarning("zapscript")},
+		},
+		{
+			name:  "host registered key is case-insensitive",
+			input: `#hostpriority=1 #favorite`,
+			opts:  []zapscript.ValidateOption{zapscript.WithReservedTraitKeys("HostPriority")},
+			want:  []zapscript.Warning{reservedWarning("hostpriority")},
+		},
+		{
+			name:  "host key is only reserved when passed",
+			input: `#hostpriority=1 #favorite`,
+		},
+		{
+			name:  "reserved key in full traits syntax",
+			input: `**traits:{"zapscript":1,"a":2}`,
+			want:  []zapscript.Warning{reservedWarning("zapscript")},
+		},
+		{
+			name:  "no traits",
+			input: `**launch:game.rom`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, script.Validate(tt.opts...)); diff != "" {
+				t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReservedTraitKeysDoNotAffectParsing(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser(`#zapscript=2`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"zapscript": int64(2)}, script.Traits); diff != "" {
+		t.Errorf("Traits mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateEscapeDensity(t *testing.T) {
+	t.Parallel()
+
+	original := "2^3^4"
+	// a buggy writer escaped the value twice
+	doubled := zapscript.QuoteArg(zapscript.QuoteArg(original))
+	once := zapscript.QuoteArg(original)
+
+	tests := []struct {
+		name  string
+		input string
+		opts  []zapscript.ValidateOption
+		want  []zapscript.Warning
+	}{
+		{
+			name:  "single escaped is clean",
+			input: "**launch:" + once,
+		},
+		{
+			name:  "double escaped arg",
+			input: "**echo:ok||**launch:" + doubled,
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningEscapeDensity,
+				Message:  "arg 1 is 44% escape sequences and may be double-escaped, see UnescapeOnce",
+				Fragment: once,
+				CmdIndex: 1,
+			}},
+		},
+		{
+			name:  "double escaped adv arg",
+			input: "**launch:game?name=" + doubled,
+			want: []zapscript.Warning{{
+				Code:     zapscript.WarningEscapeDensity,
+				Message:  `adv arg "name" is 44% escape sequences and may be double-escaped, see UnescapeOnce`,
+				Fragment: once,
+			}},
+		},
+		{
+			name:  "single escape sequence ignored",
+			input: `**launch:"^^^^"`,
+		},
+		{
+			name:  "raised threshold",
+			input: "**launch:" + doubled,
+			opts:  []zapscript.ValidateOption{zapscript.WithEscapeDensityThreshold(0.5)},
+		},
+		{
+			name:  "disabled",
+			input: "**launch:" + doubled,
+			opts:  []zapscript.ValidateOption{zapscript.WithEscapeDensityThreshold(0)},
+		},
+		{
+			name:  "input commands skipped",
+			input: "**input.text:^^^^^^",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.NewParser(tt.input).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, script.Validate(tt.opts...)); diff != "" {
+				t.Errorf("Validate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnescapeOnceRepairsDoubleEscaped(t *testing.T) {
+	t.Parallel()
+
+	for _, original := range []string{"2^3^4", `say "hi", [[x]]`, "a\nb"} {
+		input := "**launch:" + zapscript.QuoteArg(zapscript.QuoteArg(original))
+		script, err := zapscript.NewParser(input).ParseScript()
+		if err != nil {
+			t.Fatalf("ParseScript(%q) unexpected error: %v", input, err)
+		}
+		if got := zapscript.UnescapeOnce(script.Cmds[0].Args[0]); got != original {
+			t.Errorf("UnescapeOnce(%q) = %q, want %q", script.Cmds[0].Args[0], got, original)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"cmp"
+	"slices"
+)
+
+// WarningCode identifies the kind of a Warning so hosts can filter or
+// translate warnings without matching on the message.
+type WarningCode string
+
+const (
+	// WarningDuplicateAdvArg is an adv arg key set more than once in a
+	// command; the last value is kept.
+	WarningDuplicateAdvArg WarningCode = "duplicate_adv_arg"
+	// WarningDuplicateTraitKey is a trait key set more than once in a traits
+	// segment; the last value is kept.
+	WarningDuplicateTraitKey WarningCode = "duplicate_trait_key"
+	// WarningReservedTraitKey is a trait key reserved for future use.
+	WarningReservedTraitKey WarningCode = "reserved_trait_key"
+	// WarningEscapeDensity is an arg or adv arg value made up largely of
+	// escape sequences, which usually means it was escaped twice.
+	WarningEscapeDensity WarningCode = "escape_density"
+	// WarningLonePipe is a single | followed by a command, which was likely
+	// meant to be the || command separator. It is a best-effort hint and
+	// does not change how the script is parsed.
+	WarningLonePipe WarningCode = "lone_pipe"
+	// WarningSingleBracket is an expression env name in single brackets,
+	// such as [platform], which was likely meant to be a [[...]]
+	// expression. It is a best-effort hint like WarningLonePipe.
+	WarningSingleBracket WarningCode = "single_bracket"
+	// WarningUnsupportedCommand is a command a CapabilityProfile does not
+	// list.
+	WarningUnsupportedCommand WarningCode = "unsupported_command"
+	// WarningUnsupportedAdvArg is an adv arg key a CapabilityProfile does not
+	// accept for its command.
+	WarningUnsupportedAdvArg WarningCode = "unsupported_adv_arg"
+	// WarningUnsupportedFeature is optional syntax, such as traits or
+	// expressions, a CapabilityProfile does not support.
+	WarningUnsupportedFeature WarningCode = "unsupported_feature"
+	// WarningUnsupportedEnv is an expression env field or method that does
+	// not exist or is newer than a CapabilityProfile's env schema version.
+	WarningUnsupportedEnv WarningCode = "unsupported_env"
+)
+
+// Warning is a non-fatal diagnostic about a script. Every surface that
+// reports warnings, from the parser to Script.Validate, uses this type.
+type Warning struct {
+	Code    WarningCode `json:"code"`
+	Message string      `json:"message"`
+	// Fragment is the part of the script the warning is about, if any.
+	Fragment string `json:"fragment,omitempty"`
+	// Pos is the 1-based rune position the warning points at, or 0 if it
+	// is not tied to a position in the input.
+	Pos int64 `json:"pos,omitempty"`
+	// CmdIndex is the index in Script.Cmds the warning is about, or
+	// TraitsCmdIndex for traits.
+	CmdIndex int `json:"cmdIndex"`
+}
+
+// SortWarnings orders warnings by command index, then position, code and
+// message, so lists built from map iteration compare deterministically.
+// Traits warnings sort first.
+func SortWarnings(warnings []Warning) {
+	slices.SortStableFunc(warnings, func(a, b Warning) int {
+		return cmp.Or(
+			cmp.Compare(a.CmdIndex, b.CmdIndex),
+			cmp.Compare(a.Pos, b.Pos),
+			cmp.Compare(a.Code, b.Code),
+			cmp.Compare(a.Message, b.Message),
+		)
+	})
+}
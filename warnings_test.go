@@ -0,0 +1,82 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSortWarnings(t *testing.T) {
+	t.Parallel()
+
+	warnings := []zapscript.Warning{
+		{Code: "b", Message: "cmd 1 pos 5", CmdIndex: 1, Pos: 5},
+		{Code: "b", Message: "cmd 0 pos 9", CmdIndex: 0, Pos: 9},
+		{Code: "b", Message: "cmd 0 pos 3 code b", CmdIndex: 0, Pos: 3},
+		{Code: "a", Message: "cmd 0 pos 3 code a", CmdIndex: 0, Pos: 3},
+		{Code: "a", Message: "traits", CmdIndex: zapscript.TraitsCmdIndex},
+	}
+	zapscript.SortWarnings(warnings)
+
+	got := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		got = append(got, w.Message)
+	}
+	want := []string{"traits", "cmd 0 pos 3 code a", "cmd 0 pos 3 code b", "cmd 0 pos 9", "cmd 1 pos 5"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SortWarnings() order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseWarningsOrder(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.NewParser(`**launch:a?y=1&y=2&x=1&x=2||#b #a #b #a`).ParseScript()
+	if err != nil {
+		t.Fatalf("ParseScript() unexpected error: %v", err)
+	}
+
+	got := make([]string, 0, len(script.Warnings))
+	for _, w := range script.Warnings {
+		got = append(got, w.Fragment)
+	}
+	if diff := cmp.Diff([]string{"b", "a", "y", "x"}, got); diff != "" {
+		t.Errorf("Warnings order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWarningJSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(zapscript.Warning{
+		Code:     zapscript.WarningDuplicateAdvArg,
+		Message:  "msg",
+		Fragment: "launcher",
+		Pos:      12,
+		CmdIndex: 1,
+	})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"code":"duplicate_adv_arg","message":"msg","fragment":"launcher","pos":12,"cmdIndex":1}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ShouldRun reports whether the command's when adv arg lets it run. A
+// command without one, or with an empty one, always runs. Otherwise the
+// value's expressions are evaluated against exprEnv as EvalExpressions does
+// and the result is read as a condition:
+//
+//   - a bool is used as is
+//   - a number runs the command if it is not zero
+//   - a string is false if it is empty or a false value accepted by
+//     strconv.ParseBool, such as "false" or "0", and true otherwise
+//
+// A value that is a single expression uses the expression's result, so
+// [[media_playing]] is a bool; other values, such as a literal "false" or
+// "[[a]]-[[b]]", are read as the substituted string. Any other result, such
+// as a list or nil, is an ErrBadExpressionReturn error.
+//
+// Errors are *EvalError values with the command's name, AdvArgKey KeyWhen
+// and CmdIndex -1, since a Command does not know its place in a script.
+func (c Command) ShouldRun(exprEnv any, opts ...EvalOption) (bool, error) {
+	value, ok := c.AdvArgs.GetWhen()
+	if !ok || value == "" {
+		return true, nil
+	}
+	run, err := evalWhen(value, exprEnv, opts)
+	if err != nil {
+		return false, locateEvalError(err, -1, c.Name, -1, KeyWhen)
+	}
+	return run, nil
+}
+
+// evalWhen evaluates a non-empty when value as a condition, see
+// Command.ShouldRun.
+func evalWhen(value string, exprEnv any, opts []EvalOption) (bool, error) {
+	parts, complete := splitArgParts(value)
+	if !complete {
+		return false, ErrUnmatchedExpression
+	}
+	if len(parts) > 1 || parts[0].Type != ArgPartTypeExpression {
+		result, err := NewParser(value).EvalExpressions(exprEnv, opts...)
+		if err != nil {
+			return false, err
+		}
+		return whenCondition(result)
+	}
+
+	var evalOpts EvalOptions
+	for _, opt := range opts {
+		opt(&evalOpts)
+	}
+	custom, err := customFunctions(evalOpts.Functions)
+	if err != nil {
+		return false, err
+	}
+	expression := parts[0].Value
+	program, err := compileExpression(expression, exprEnv, evalOpts, compileOptions(evalOpts, custom, exprEnv))
+	if err != nil {
+		return false, newEvalError(expression, err)
+	}
+	if envErr := checkMapEnv(expression, exprEnv, evalOpts); envErr != nil {
+		return false, newEvalError(expression, envErr)
+	}
+	output, err := runProgram(context.Background(), program, exprEnv)
+	if err != nil {
+		return false, newEvalError(expression, err)
+	}
+	run, err := whenCondition(output)
+	if err != nil {
+		return false, newEvalError(expression, err)
+	}
+	return run, nil
+}
+
+// whenCondition reads an evaluated when value as a condition, see
+// Command.ShouldRun.
+func whenCondition(output any) (bool, error) {
+	v := reflect.ValueOf(output)
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return !v.IsZero(), nil
+	case reflect.String:
+		s := strings.TrimSpace(v.String())
+		if s == "" {
+			return false, nil
+		}
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: when: %v (%T)", ErrBadExpressionReturn, output, output)
+	}
+}
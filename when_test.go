@@ -0,0 +1,110 @@
+// Copyright 2026 The Zaparoo Project Contributors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zapscript_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/go-zapscript"
+)
+
+func TestCommandShouldRun(t *testing.T) {
+	t.Parallel()
+
+	env := zapscript.ArgExprEnv{
+		MediaPlaying: true,
+		Platform:     "mister",
+		Scanned:      zapscript.ExprEnvScanned{Value: "3"},
+	}
+
+	tests := []struct {
+		wantErr error
+		name    string
+		input   string
+		want    bool
+	}{
+		{name: "missing when", input: "**launch:game", want: true},
+		{name: "empty when", input: "**launch:game?when=", want: true},
+		{name: "literal false", input: "**launch:game?when=false", want: false},
+		{name: "literal zero", input: "**launch:game?when=0", want: false},
+		{name: "literal true", input: "**launch:game?when=true", want: true},
+		{name: "literal one", input: "**launch:game?when=1", want: true},
+		{name: "expression bool", input: "**launch:game?when=[[media_playing]]", want: true},
+		{name: "expression false", input: "**launch:game?when=[[!media_playing]]", want: false},
+		{name: "expression comparison", input: `**launch:game?when=[[platform == "mister"]]`, want: true},
+		{name: "expression number", input: "**launch:game?when=[[len(platform) - 6]]", want: false},
+		{name: "expression empty string", input: "**launch:game?when=[[active_media.name]]", want: false},
+		{name: "expression string", input: "**launch:game?when=[[scanned.value]]", want: true},
+		{name: "substituted string", input: "**launch:game?when=[[scanned.value]]0", want: true},
+		{name: "substituted false", input: "**launch:game?when=fal[[\"se\"]]", want: false},
+		{
+			name:    "nonsensical value",
+			input:   "**launch:game?when=[[ [1, 2] ]]",
+			wantErr: zapscript.ErrBadExpressionReturn,
+		},
+		{
+			name:    "nil",
+			input:   "**launch:game?when=[[nil]]",
+			wantErr: zapscript.ErrBadExpressionReturn,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			script, err := zapscript.Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error: %v", err)
+			}
+			got, err := script.Cmds[0].ShouldRun(env)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ShouldRun() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ShouldRun() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ShouldRun() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandShouldRunErrors(t *testing.T) {
+	t.Parallel()
+
+	script, err := zapscript.Parse("**launch:game?when=[[nope]]")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	if _, err := script.Cmds[0].ShouldRun(zapscript.ArgExprEnv{}); err == nil {
+		t.Error("ShouldRun() with an unknown field did not return an error")
+	}
+
+	script, err = zapscript.Parse("**launch:game?when=[[ready()]]")
+	if err != nil {
+		t.Fatalf("Parse() unexpected error: %v", err)
+	}
+	funcs := zapscript.WithFunctions(map[string]any{"ready": func() bool { return false }})
+	got, err := script.Cmds[0].ShouldRun(zapscript.ArgExprEnv{}, funcs)
+	if err != nil || got {
+		t.Errorf("ShouldRun() with a custom function = %v, %v, want false, nil", got, err)
+	}
+}